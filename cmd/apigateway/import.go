@@ -0,0 +1,111 @@
+package main
+
+import (
+	"chillit-rest-gateway/internal/app/importer"
+	"chillit-rest-gateway/internal/app/places"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+
+	"google.golang.org/grpc"
+)
+
+const importUsage = `Usage: apigateway [-config_path=<path>] import [flags] <file>
+
+Adds places from CSV, JSON or NDJSON file to places store. CSV header names columns:
+city_name, title, address, description, image_url, latitude, longitude. JSON records use
+the same keys. Coordinates are decimal degrees, given together or not at all.
+
+`
+
+// runImport implements import subcommand
+func runImport(storeURL string, args []string) error {
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	flags.Usage = func() {
+		io.WriteString(flags.Output(), importUsage)
+		flags.PrintDefaults()
+	}
+	format := flags.String("format", "", "format of file: csv, json or ndjson, guessed by extension by default")
+	concurrency := flags.Int("concurrency", 4, "number of simultaneous AddPlace calls")
+	dryRun := flags.Bool("dry_run", false, "only validate records")
+	checkpointPath := flags.String("checkpoint", "", "file logging created records, rerun with the same file resumes import")
+	reportPath := flags.String("report", "", "file for JSON report of created and rejected records, stdout by default")
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return errors.New("import file is required")
+	}
+
+	path := flags.Arg(0)
+	if *format == "" {
+		*format = importer.FormatOf(path)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	reader, err := importer.NewReader(file, *format)
+	if err != nil {
+		return err
+	}
+
+	var client places.PlacesStoreClient
+	if !*dryRun {
+		log.Println("Connecting places storage")
+		conn, err := grpc.Dial(storeURL, grpc.WithInsecure())
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		client = places.NewPlacesStoreClient(conn)
+	}
+
+	// Interrupted import is resumed from checkpoint
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	go func() {
+		<-interrupt
+		log.Println("Interrupted, waiting for pending calls")
+		cancel()
+	}()
+
+	options := importer.Options{Concurrency: *concurrency, DryRun: *dryRun}
+	if *checkpointPath != "" {
+		checkpoint := importer.NewFileCheckpoint(*checkpointPath)
+		defer checkpoint.Close()
+		options.Checkpoint = checkpoint
+	}
+
+	log.Printf("Importing %s", path)
+	report, runErr := importer.New(client, options).Run(ctx, reader)
+	if report != nil {
+		log.Printf("Created %d, rejected %d, skipped %d records", len(report.Created), len(report.Rejected), report.Skipped)
+		if err := writeReport(*reportPath, report); err != nil {
+			return err
+		}
+	}
+	return runErr
+}
+
+func writeReport(path string, report *importer.Report) error {
+	out := os.Stdout
+	if path != "" {
+		file, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		out = file
+	}
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
@@ -4,9 +4,11 @@ import (
 	"chillit-rest-gateway/internal/app/apiserver"
 	"chillit-rest-gateway/internal/app/configuration"
 	"chillit-rest-gateway/internal/app/places"
+	"chillit-rest-gateway/internal/app/redisconn"
 	"flag"
 	"log"
 
+	"github.com/go-redis/redis/v7"
 	"google.golang.org/grpc"
 )
 
@@ -25,6 +27,13 @@ func main() {
 		log.Fatalln(err)
 	}
 
+	if flag.Arg(0) == "import" {
+		if err := runImport(config.StoreService.URL, flag.Args()[1:]); err != nil {
+			log.Fatalln(err)
+		}
+		return
+	}
+
 	log.Println("Connecting places storage")
 	conn, err := grpc.Dial(config.StoreService.URL, grpc.WithInsecure())
 	if err != nil {
@@ -32,8 +41,18 @@ func main() {
 	}
 	defer conn.Close()
 
+	var redisClient *redis.Client
+	if config.Redis != nil {
+		log.Println("Connecting redis")
+		redisClient, err = redisconn.Connect(config.Redis)
+		if err != nil {
+			log.Printf("%v, working in local mode until it is available", err)
+		}
+		defer redisClient.Close()
+	}
+
 	log.Println("Starting HTTP server")
-	if err := apiserver.Start(config.APIServer, places.NewPlacesStoreClient(conn)); err != nil {
+	if err := apiserver.Start(config.APIServer, places.NewPlacesStoreClient(conn), redisClient); err != nil {
 		log.Fatalln(err)
 	}
 }
@@ -3,14 +3,24 @@ module chillit-rest-gateway
 go 1.13
 
 require (
+	github.com/alicebob/miniredis/v2 v2.11.4
+	github.com/blevesearch/bleve v1.0.14
+	github.com/go-redis/redis/v7 v7.4.1
+	github.com/golang-jwt/jwt/v4 v4.5.2
 	github.com/golang/protobuf v1.3.5
 	github.com/gorilla/mux v1.7.4
 	github.com/gorilla/schema v1.1.0
+	github.com/graph-gophers/graphql-go v1.3.0
 	github.com/sirupsen/logrus v1.5.0
 	github.com/stretchr/objx v0.2.0 // indirect
-	github.com/stretchr/testify v1.3.0
+	github.com/stretchr/testify v1.4.0
+	github.com/vektah/gqlparser v1.3.1
+	github.com/vmihailenco/msgpack/v4 v4.3.13
+	go.etcd.io/bbolt v1.3.5
+	golang.org/x/crypto v0.0.0-20200323165209-0ec3e9974c59
 	golang.org/x/net v0.0.0-20200324143707-d3edc9973b7e // indirect
-	google.golang.org/genproto v0.0.0-20200413115906-b5235f65be36 // indirect
+	golang.org/x/text v0.3.2
+	google.golang.org/genproto v0.0.0-20200413115906-b5235f65be36
 	google.golang.org/grpc v1.28.1
 	gopkg.in/yaml.v3 v3.0.0-20200313102051-9f266ea9e77c
 )
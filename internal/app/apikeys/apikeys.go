@@ -0,0 +1,94 @@
+package apikeys
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Header and query parameter carrying API key
+const (
+	Header     = "X-API-Key"
+	QueryParam = "api_key"
+)
+
+// Registry holds known API keys
+type Registry struct {
+	keys map[[sha256.Size]byte]*Key
+}
+
+// NewRegistry loads keys from config and keys file
+func NewRegistry(config *Config) (*Registry, error) {
+	if config == nil {
+		return nil, errors.New("[ NewRegistry ] <nil> config")
+	}
+
+	keys := config.Keys
+	if config.File != "" {
+		data, err := ioutil.ReadFile(config.File)
+		if err != nil {
+			return nil, errors.New("[ NewRegistry ] could not read keys file: " + err.Error())
+		}
+		var fileKeys struct {
+			Keys []*Key `yaml:"keys"`
+		}
+		if err := yaml.Unmarshal(data, &fileKeys); err != nil {
+			return nil, errors.New("[ NewRegistry ] could not parse keys file: " + err.Error())
+		}
+		keys = append(keys, fileKeys.Keys...)
+	}
+
+	registry := &Registry{keys: make(map[[sha256.Size]byte]*Key)}
+	for _, key := range keys {
+		hash, err := keyHash(key)
+		if err != nil {
+			return nil, errors.New("[ NewRegistry ] " + err.Error())
+		}
+		if _, ok := registry.keys[hash]; ok {
+			return nil, fmt.Errorf("[ NewRegistry ] key '%s' is duplicated", key.Name)
+		}
+		registry.keys[hash] = key
+	}
+	return registry, nil
+}
+
+func keyHash(key *Key) ([sha256.Size]byte, error) {
+	var hash [sha256.Size]byte
+	if key.Name == "" {
+		return hash, errors.New("key name is required")
+	}
+	if key.Quota != nil && (key.Quota.Requests == 0 || key.Quota.Period <= 0) {
+		return hash, fmt.Errorf("key '%s': quota requests and period must be positive", key.Name)
+	}
+	switch {
+	case key.Key != "":
+		return sha256.Sum256([]byte(key.Key)), nil
+	case key.KeyHash != "":
+		decoded, err := hex.DecodeString(key.KeyHash)
+		if err != nil || len(decoded) != sha256.Size {
+			return hash, fmt.Errorf("key '%s': key_hash must be hex encoded SHA-256", key.Name)
+		}
+		copy(hash[:], decoded)
+		return hash, nil
+	}
+	return hash, fmt.Errorf("key '%s': key or key_hash is required", key.Name)
+}
+
+// FromRequest returns key value passed in header or query parameter
+func FromRequest(r *http.Request) string {
+	if value := r.Header.Get(Header); value != "" {
+		return value
+	}
+	return r.URL.Query().Get(QueryParam)
+}
+
+// Lookup finds key by its value. Keys are compared by hashes, so lookup time does not depend on key value
+func (r *Registry) Lookup(value string) (*Key, bool) {
+	key, ok := r.keys[sha256.Sum256([]byte(value))]
+	return key, ok
+}
@@ -0,0 +1,26 @@
+package apikeys
+
+import "time"
+
+// Config for API keys
+type Config struct {
+	// File is optional YAML file with list of keys, loaded in addition to Keys
+	File string `yaml:"file"`
+	Keys []*Key `yaml:"keys"`
+}
+
+// Key of partner integration
+type Key struct {
+	Name string `yaml:"name"`
+	// Key is plain key value, KeyHash is hex encoded SHA-256 of key. One of them is required
+	Key     string   `yaml:"key"`
+	KeyHash string   `yaml:"key_hash"`
+	Scopes  []string `yaml:"scopes"`
+	Quota   *Quota   `yaml:"quota"`
+}
+
+// Quota limits requests made with key
+type Quota struct {
+	Requests uint64        `yaml:"requests"`
+	Period   time.Duration `yaml:"period"`
+}
@@ -0,0 +1,186 @@
+package apiserver
+
+import (
+	"chillit-rest-gateway/internal/app/users"
+	"context"
+	"encoding/json"
+	"net/http"
+)
+
+// CSRFHeader carries CSRF token of session for unsafe requests
+const CSRFHeader = "X-CSRF-Token"
+
+// sessionFromContext returns session of request authenticated by cookie
+func sessionFromContext(ctx context.Context) *users.Session {
+	session, _ := ctx.Value(sessionContextKey).(*users.Session)
+	return session
+}
+
+func isSafeMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
+// SessionMiddleware identifies user by session cookie and checks CSRF token of unsafe requests.
+// Requests already authenticated by API key or bearer token are passed as is
+func (s *server) SessionMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.users == nil || principalFromContext(r.Context()) != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		cookie, err := r.Cookie(s.users.Config().CookieName)
+		if err != nil || cookie.Value == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		session, user, err := s.users.Session(cookie.Value)
+		if err == users.ErrSessionNotFound {
+			http.SetCookie(w, s.sessionCookie("", -1))
+			next.ServeHTTP(w, r)
+			return
+		}
+		if err != nil {
+			s.logger.Errorf("could not load session, error: %v", err)
+			s.writeProblem(w, http.StatusInternalServerError, "could not load session")
+			return
+		}
+
+		if !isSafeMethod(r.Method) && r.Header.Get(CSRFHeader) != session.CSRFToken {
+			s.writeProblem(w, http.StatusForbidden, "missing or invalid "+CSRFHeader+" header")
+			return
+		}
+
+		ctx := withPrincipal(r.Context(), &principal{
+			Kind:   principalUser,
+			Name:   user.Username,
+			Scopes: user.Scopes,
+		})
+		next.ServeHTTP(w, r.WithContext(context.WithValue(ctx, sessionContextKey, session)))
+	})
+}
+
+func (s *server) sessionCookie(value string, maxAge int) *http.Cookie {
+	config := s.users.Config()
+	if maxAge == 0 {
+		maxAge = int(config.SessionTTL.Seconds())
+	}
+	return &http.Cookie{
+		Name:     config.CookieName,
+		Value:    value,
+		Path:     "/",
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   config.CookieSecure,
+		SameSite: http.SameSiteLaxMode,
+	}
+}
+
+type credentialsRequest struct {
+	Username string `json:"username" validate:"minlen=3,maxlen=32"`
+	Password string `json:"password" validate:"minlen=8,maxlen=256"`
+}
+
+type accountResponse struct {
+	Username  string   `json:"username"`
+	Scopes    []string `json:"scopes"`
+	CSRFToken string   `json:"csrf_token,omitempty"`
+}
+
+func (s *server) registerHandler() http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var requestValues credentialsRequest
+		if err := json.NewDecoder(r.Body).Decode(&requestValues); err != nil {
+			s.writeProblem(w, http.StatusBadRequest, "could not decode JSON body: "+err.Error())
+			return
+		}
+
+		user, err := s.users.Register(requestValues.Username, requestValues.Password)
+		switch err {
+		case nil:
+		case users.ErrInvalidUsername, users.ErrWeakPassword:
+			s.writeProblem(w, http.StatusBadRequest, err.Error())
+			return
+		case users.ErrUserExists:
+			s.writeProblem(w, http.StatusConflict, err.Error())
+			return
+		default:
+			s.logger.Errorf("could not register user, error: %v", err)
+			s.writeProblem(w, http.StatusInternalServerError, "could not register user")
+			return
+		}
+
+		s.logger.Infof("user '%s' registered", user.Username)
+		s.writeResponse(w, r, http.StatusCreated, &accountResponse{Username: user.Username, Scopes: user.Scopes})
+	})
+}
+
+func (s *server) loginHandler() http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var requestValues credentialsRequest
+		if err := json.NewDecoder(r.Body).Decode(&requestValues); err != nil {
+			s.writeProblem(w, http.StatusBadRequest, "could not decode JSON body: "+err.Error())
+			return
+		}
+
+		user, err := s.users.Authenticate(requestValues.Username, requestValues.Password)
+		if err == users.ErrInvalidCredentials {
+			s.writeProblem(w, http.StatusUnauthorized, err.Error())
+			return
+		}
+		if err != nil {
+			s.logger.Errorf("could not authenticate user, error: %v", err)
+			s.writeProblem(w, http.StatusInternalServerError, "could not log in")
+			return
+		}
+
+		session, err := s.users.CreateSession(user)
+		if err != nil {
+			s.logger.Errorf("could not create session, error: %v", err)
+			s.writeProblem(w, http.StatusInternalServerError, "could not log in")
+			return
+		}
+
+		http.SetCookie(w, s.sessionCookie(session.Token, 0))
+		s.writeResponse(w, r, http.StatusOK, &accountResponse{
+			Username:  user.Username,
+			Scopes:    user.Scopes,
+			CSRFToken: session.CSRFToken,
+		})
+	})
+}
+
+func (s *server) logoutHandler() http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		session := sessionFromContext(r.Context())
+		if session == nil {
+			s.writeProblem(w, http.StatusUnauthorized, "not logged in")
+			return
+		}
+
+		if err := s.users.DeleteSession(session.Token); err != nil {
+			s.logger.Errorf("could not delete session, error: %v", err)
+			s.writeProblem(w, http.StatusInternalServerError, "could not log out")
+			return
+		}
+		http.SetCookie(w, s.sessionCookie("", -1))
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+func (s *server) meHandler() http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p := principalFromContext(r.Context())
+		if p == nil || p.Kind != principalUser {
+			s.writeProblem(w, http.StatusUnauthorized, "not logged in")
+			return
+		}
+
+		resp := &accountResponse{Username: p.Name, Scopes: p.Scopes}
+		if session := sessionFromContext(r.Context()); session != nil {
+			resp.CSRFToken = session.CSRFToken
+		}
+		s.writeResponse(w, r, http.StatusOK, resp)
+	})
+}
@@ -4,13 +4,18 @@ import (
 	"chillit-rest-gateway/internal/app/places"
 	"errors"
 	"net/http"
+
+	"github.com/go-redis/redis/v7"
 )
 
-// Start API web server
-func Start(config *Config, placesStore places.PlacesStoreClient) error {
-	srv := newServer(placesStore, config.AllowedOrigins)
+// Start API web server. redisClient may be nil if Redis is not configured
+func Start(config *Config, placesStore places.PlacesStoreClient, redisClient *redis.Client) error {
 	if config == nil {
 		return errors.New("apiserver could not start error: <nil> config")
 	}
+	srv, err := newServer(config, placesStore, redisClient)
+	if err != nil {
+		return errors.New("apiserver could not start error: " + err.Error())
+	}
 	return http.ListenAndServe(config.Hostname, srv)
 }
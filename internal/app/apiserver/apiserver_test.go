@@ -1,3 +1,1254 @@
 package apiserver
 
-// TODO: ...
+import (
+	"chillit-rest-gateway/internal/app/apikeys"
+	"chillit-rest-gateway/internal/app/audit"
+	"chillit-rest-gateway/internal/app/cache"
+	"chillit-rest-gateway/internal/app/duplicates"
+	"chillit-rest-gateway/internal/app/geo"
+	"chillit-rest-gateway/internal/app/graphqlapi"
+	"chillit-rest-gateway/internal/app/grpcweb"
+	"chillit-rest-gateway/internal/app/history"
+	"chillit-rest-gateway/internal/app/importjobs"
+	"chillit-rest-gateway/internal/app/jwtauth"
+	"chillit-rest-gateway/internal/app/moderation"
+	"chillit-rest-gateway/internal/app/places"
+	"chillit-rest-gateway/internal/app/places/placestest"
+	"chillit-rest-gateway/internal/app/ratelimit"
+	"chillit-rest-gateway/internal/app/search"
+	"chillit-rest-gateway/internal/app/suggest"
+	"chillit-rest-gateway/internal/app/transcoding"
+	"chillit-rest-gateway/internal/app/users"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v7"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/vmihailenco/msgpack/v4"
+)
+
+func newTestServer(t *testing.T, config *Config, redisClient *redis.Client) *server {
+	store := placestest.New(map[uint64][]*places.Place{
+		1: {{Id: 1, Title: "Coffee Bean", Address: "Tverskaya 1", Version: 1}},
+	})
+	store.Cities = []*places.City{{Id: 1, Title: "Moscow"}}
+	store.NextID = 101
+	s, err := newServer(config, store, redisClient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestServer_RateLimit(t *testing.T) {
+	s := newTestServer(t, &Config{
+		RateLimit: &ratelimit.Config{
+			Policies: []*ratelimit.Policy{{Route: "/cities", Requests: 2, Period: time.Minute}},
+		},
+	}, nil)
+
+	for i := 0; i < 2; i++ {
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/cities", nil))
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "2", rec.Header().Get("RateLimit-Limit"))
+	}
+
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/cities", nil))
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "application/problem+json", rec.Header().Get("Content-Type"))
+	assert.Equal(t, "30", rec.Header().Get("Retry-After"))
+
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/places?city_id=1", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Empty(t, rec.Header().Get("RateLimit-Limit"))
+}
+
+func TestServer_RateLimitByAPIKey(t *testing.T) {
+	s := newTestServer(t, &Config{
+		RateLimit: &ratelimit.Config{
+			Policies: []*ratelimit.Policy{{Route: "/cities", KeyBy: ratelimit.KeyByAPIKey, Requests: 2, Period: time.Minute}},
+		},
+		APIKeys: &apikeys.Config{Keys: []*apikeys.Key{
+			{Name: "reader", Key: "reader-key", Scopes: []string{scopeReadPlaces}},
+		}},
+	}, nil)
+
+	get := func(key string) int {
+		r := httptest.NewRequest(http.MethodGet, "/cities", nil)
+		r.Header.Set(apikeys.Header, key)
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, r)
+		return rec.Code
+	}
+
+	// Bogus keys share bucket of client address however often they are rotated
+	assert.Equal(t, http.StatusUnauthorized, get("bogus-1"))
+	assert.Equal(t, http.StatusUnauthorized, get("bogus-2"))
+	assert.Equal(t, http.StatusTooManyRequests, get("bogus-3"))
+
+	assert.Equal(t, http.StatusOK, get("reader-key"))
+	assert.Equal(t, http.StatusOK, get("reader-key"))
+	assert.Equal(t, http.StatusTooManyRequests, get("reader-key"))
+}
+
+func TestServer_RedisBackends(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mr.Close()
+
+	config := &Config{
+		RateLimit: &ratelimit.Config{
+			Backend:  backendRedis,
+			Policies: []*ratelimit.Policy{{Route: "/cities", Requests: 3, Period: time.Minute}},
+		},
+		Cache: &cache.Config{Backend: backendRedis, TTL: time.Minute},
+	}
+	redisClient := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	first := newTestServer(t, config, redisClient)
+	second := newTestServer(t, config, redisClient)
+
+	rec := httptest.NewRecorder()
+	first.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/cities", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "MISS", rec.Header().Get("X-Cache"))
+
+	rec = httptest.NewRecorder()
+	second.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/cities", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "HIT", rec.Header().Get("X-Cache"))
+	assert.Equal(t, "1", rec.Header().Get("RateLimit-Remaining"))
+
+	// Redis goes down, instances keep serving in local mode
+	mr.Close()
+	rec = httptest.NewRecorder()
+	second.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/cities", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "MISS", rec.Header().Get("X-Cache"))
+	assert.Equal(t, "2", rec.Header().Get("RateLimit-Remaining"))
+}
+
+func TestServer_CacheInvalidation(t *testing.T) {
+	s := newTestServer(t, &Config{
+		Cache: &cache.Config{TTL: time.Minute},
+		APIKeys: &apikeys.Config{Keys: []*apikeys.Key{
+			{Name: "editor", Key: "editor-key", Scopes: []string{scopeReadPlaces, scopeEditPlaces}},
+			{Name: "ops", Key: "admin-key", Scopes: []string{scopeAdmin}},
+		}},
+	}, nil)
+
+	do := func(method, path, body, key string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, path, strings.NewReader(body))
+		r.Header.Set(apikeys.Header, key)
+		r.Header.Set("If-Match", "*")
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, r)
+		return rec
+	}
+
+	assert.Equal(t, "MISS", do(http.MethodGet, "/places?city_id=1", "", "editor-key").Header().Get("X-Cache"))
+	rec := do(http.MethodGet, "/places?city_id=1", "", "editor-key")
+	assert.Equal(t, "HIT", rec.Header().Get("X-Cache"))
+	assert.Equal(t, []string{"Accept"}, rec.Header()["Vary"])
+
+	// Update drops cached listings, so clients see new version
+	assert.Equal(t, http.StatusOK, do(http.MethodPatch, "/places/1", `{"title":"Coffee"}`, "editor-key").Code)
+	rec = do(http.MethodGet, "/places?city_id=1", "", "editor-key")
+	assert.Equal(t, "MISS", rec.Header().Get("X-Cache"))
+	assert.Contains(t, rec.Body.String(), `"title":"Coffee"`)
+	assert.Equal(t, "HIT", do(http.MethodGet, "/places?city_id=1", "", "editor-key").Header().Get("X-Cache"))
+
+	assert.Equal(t, "MISS", do(http.MethodGet, "/cities", "", "").Header().Get("X-Cache"))
+	assert.Equal(t, "HIT", do(http.MethodGet, "/cities", "", "").Header().Get("X-Cache"))
+	assert.Equal(t, http.StatusCreated, do(http.MethodPost, "/admin/cities", `{"title":"Kazan"}`, "admin-key").Code)
+	rec = do(http.MethodGet, "/cities", "", "")
+	assert.Equal(t, "MISS", rec.Header().Get("X-Cache"))
+	assert.Contains(t, rec.Body.String(), "Kazan")
+}
+
+func TestServer_APIKeys(t *testing.T) {
+	s := newTestServer(t, &Config{
+		APIKeys: &apikeys.Config{
+			Keys: []*apikeys.Key{
+				{Name: "reader", Key: "reader-key", Scopes: []string{scopeReadPlaces}},
+				{Name: "writer", Key: "writer-key", Scopes: []string{scopeReadPlaces, scopeAddPlaces}},
+			},
+		},
+	}, nil)
+
+	newAddPlaceRequest := func(key string) *http.Request {
+		r := httptest.NewRequest(http.MethodPost, "/places", strings.NewReader(`{"city_name":"Moscow","title":"Rooftop"}`))
+		if key != "" {
+			r.Header.Set(apikeys.Header, key)
+		}
+		return r
+	}
+
+	tests := []struct {
+		name    string
+		request *http.Request
+		want    int
+	}{
+		{"anonymous read", httptest.NewRequest(http.MethodGet, "/cities", nil), http.StatusOK},
+		{"key in query", httptest.NewRequest(http.MethodGet, "/cities?api_key=reader-key", nil), http.StatusOK},
+		{"unknown key", httptest.NewRequest(http.MethodGet, "/cities?api_key=unknown", nil), http.StatusUnauthorized},
+		{"anonymous write", newAddPlaceRequest(""), http.StatusUnauthorized},
+		{"write without scope", newAddPlaceRequest("reader-key"), http.StatusForbidden},
+		{"write", newAddPlaceRequest("writer-key"), http.StatusCreated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			s.ServeHTTP(rec, tt.request)
+			assert.Equal(t, tt.want, rec.Code)
+		})
+	}
+}
+
+func TestServer_JWT(t *testing.T) {
+	s := newTestServer(t, &Config{JWT: &jwtauth.Config{HS256Secret: "secret"}}, nil)
+
+	newAddPlaceRequest := func(scope string) *http.Request {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+			"sub":   "user-1",
+			"exp":   time.Now().Add(time.Hour).Unix(),
+			"scope": scope,
+		}).SignedString([]byte("secret"))
+		assert.NoError(t, err)
+
+		r := httptest.NewRequest(http.MethodPost, "/places", strings.NewReader(`{"city_name":"Moscow","title":"Rooftop"}`))
+		r.Header.Set("Authorization", "Bearer "+token)
+		return r
+	}
+
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, newAddPlaceRequest(scopeReadPlaces))
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Contains(t, rec.Header().Get("WWW-Authenticate"), "insufficient_scope")
+
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, newAddPlaceRequest(scopeReadPlaces+" "+scopeAddPlaces))
+	assert.Equal(t, http.StatusCreated, rec.Code)
+
+	r := httptest.NewRequest(http.MethodGet, "/cities", nil)
+	r.Header.Set("Authorization", "Bearer garbage")
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, r)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestServer_Accounts(t *testing.T) {
+	dir, err := ioutil.TempDir("", "users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	s := newTestServer(t, &Config{
+		Users: &users.Config{
+			DBPath:        filepath.Join(dir, "users.db"),
+			DefaultScopes: []string{scopeReadPlaces, scopeAddPlaces},
+		},
+	}, nil)
+	defer s.users.Close()
+
+	do := func(method, path, body string, cookie *http.Cookie, csrfToken string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, path, strings.NewReader(body))
+		if cookie != nil {
+			r.AddCookie(cookie)
+		}
+		if csrfToken != "" {
+			r.Header.Set(CSRFHeader, csrfToken)
+		}
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, r)
+		return rec
+	}
+
+	credentials := `{"username":"anna","password":"long enough"}`
+	assert.Equal(t, http.StatusCreated, do(http.MethodPost, "/auth/register", credentials, nil, "").Code)
+	assert.Equal(t, http.StatusConflict, do(http.MethodPost, "/auth/register", credentials, nil, "").Code)
+	assert.Equal(t, http.StatusUnauthorized, do(http.MethodPost, "/auth/login", `{"username":"anna","password":"wrong password"}`, nil, "").Code)
+
+	rec := do(http.MethodPost, "/auth/login", credentials, nil, "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var account accountResponse
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&account))
+	cookie := rec.Result().Cookies()[0]
+	assert.True(t, cookie.HttpOnly)
+
+	rec = do(http.MethodGet, "/me", "", cookie, "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"username":"anna"`)
+
+	place := `{"city_name":"Moscow","title":"Rooftop"}`
+	assert.Equal(t, http.StatusUnauthorized, do(http.MethodPost, "/places", place, nil, "").Code)
+	assert.Equal(t, http.StatusForbidden, do(http.MethodPost, "/places", place, cookie, "").Code)
+	assert.Equal(t, http.StatusCreated, do(http.MethodPost, "/places", place, cookie, account.CSRFToken).Code)
+
+	assert.Equal(t, http.StatusNoContent, do(http.MethodPost, "/auth/logout", "", cookie, account.CSRFToken).Code)
+	assert.Equal(t, http.StatusUnauthorized, do(http.MethodGet, "/me", "", cookie, "").Code)
+}
+
+func TestServer_OpenAPI(t *testing.T) {
+	s := newTestServer(t, &Config{}, nil)
+
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var doc struct {
+		Paths map[string]map[string]struct {
+			Parameters []struct {
+				Name string `json:"name"`
+				In   string `json:"in"`
+			} `json:"parameters"`
+		} `json:"paths"`
+		Components struct {
+			Schemas map[string]struct {
+				Properties map[string]interface{} `json:"properties"`
+			} `json:"schemas"`
+		} `json:"components"`
+	}
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&doc))
+
+	for _, rt := range s.routes {
+		assert.Contains(t, doc.Paths[rt.Path], strings.ToLower(rt.Method), rt.Path)
+	}
+	assert.Equal(t, "city_id", doc.Paths["/places"]["get"].Parameters[2].Name)
+	assert.Contains(t, doc.Components.Schemas["ResponsePlace"].Properties, "image_url")
+
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/docs", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "/openapi.json")
+}
+
+func TestServer_Validation(t *testing.T) {
+	s := newTestServer(t, &Config{
+		ValidateResponses: true,
+		APIKeys: &apikeys.Config{
+			Keys: []*apikeys.Key{{Name: "writer", Key: "writer-key", Scopes: []string{scopeAddPlaces}}},
+		},
+	}, nil)
+	newAddPlaceRequest := func(body string) *http.Request {
+		r := httptest.NewRequest(http.MethodPost, "/places", strings.NewReader(body))
+		r.Header.Set(apikeys.Header, "writer-key")
+		return r
+	}
+
+	tests := []struct {
+		name    string
+		request *http.Request
+		want    int
+		field   string
+	}{
+		{"valid", httptest.NewRequest(http.MethodGet, "/places?city_id=1&amount=10", nil), http.StatusOK, ""},
+		{"required", httptest.NewRequest(http.MethodGet, "/places", nil), http.StatusBadRequest, "city_id"},
+		{"out of range", httptest.NewRequest(http.MethodGet, "/places?city_id=1&amount=1000", nil), http.StatusBadRequest, "amount"},
+		{"not a number", httptest.NewRequest(http.MethodGet, "/cities?offset=ten", nil), http.StatusBadRequest, "offset"},
+		{"unknown parameter", httptest.NewRequest(http.MethodGet, "/cities?sort=title", nil), http.StatusBadRequest, "sort"},
+		{"unknown field", newAddPlaceRequest(`{"city_name":"Moscow","title":"Bar","rating":5}`), http.StatusBadRequest, "rating"},
+		{"wrong type", newAddPlaceRequest(`{"city_name":"Moscow","title":1}`), http.StatusBadRequest, "title"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			s.ServeHTTP(rec, tt.request)
+			assert.Equal(t, tt.want, rec.Code)
+			if tt.field != "" {
+				var p problem
+				assert.NoError(t, json.NewDecoder(rec.Body).Decode(&p))
+				if assert.NotEmpty(t, p.Errors) {
+					assert.Equal(t, tt.field, p.Errors[0].Field)
+				}
+			}
+		})
+	}
+}
+
+func TestServer_Versions(t *testing.T) {
+	sunset := time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := newTestServer(t, &Config{
+		ValidateResponses: true,
+		Versions: map[string]*VersionConfig{
+			apiV1: {Deprecation: sunset.AddDate(0, -6, 0), Sunset: sunset, Link: "https://chillit.com/api/migration"},
+		},
+	}, nil)
+
+	for _, path := range []string{"/places?city_id=1", "/v1/places?city_id=1"} {
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "Fri, 01 Jan 2027 00:00:00 GMT", rec.Header().Get("Sunset"))
+		assert.NotEmpty(t, rec.Header().Get("Deprecation"))
+
+		var resp getPlacesResponse
+		assert.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+		assert.Len(t, resp.Places, 1)
+	}
+
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v2/places?city_id=1&amount=1", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Empty(t, rec.Header().Get("Sunset"))
+	var resp getPlacesV2Response
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+	if assert.NotNil(t, resp.Page.NextOffset) {
+		assert.Equal(t, uint64(1), *resp.Page.NextOffset)
+	}
+}
+
+func TestServer_Transcoding(t *testing.T) {
+	s := newTestServer(t, &Config{
+		ValidateResponses: true,
+		APIKeys: &apikeys.Config{Keys: []*apikeys.Key{
+			{Name: "partner", Key: "secret", Scopes: []string{scopeAddPlaces}},
+			{Name: "admin", Key: "admin-key", Scopes: []string{scopeAdmin}},
+		}},
+		Transcoding: &transcoding.Config{Rules: []*transcoding.Rule{
+			{Selector: "PlacesStore.GetPlacesByCityID", Get: "/store/cities/{cityID}/places"},
+			{Selector: "PlacesStore.ArchiveCity", Post: "/store/cities/archive", Body: "*", Scope: scopeAddPlaces},
+		}},
+	}, nil)
+
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/store/cities/1/places?amount=1", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"title":"Coffee Bean"`)
+
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/store/cities/moscow/places", nil))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	body := `{"id": "1", "archived": true}`
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/store/cities/archive", strings.NewReader(body)))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/store/cities/archive", strings.NewReader(body))
+	req.Header.Set(apikeys.Header, "secret")
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	req = httptest.NewRequest(http.MethodPost, "/v1/store/cities/archive", strings.NewReader(body))
+	req.Header.Set(apikeys.Header, "admin-key")
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	assert.Contains(t, rec.Body.String(), `"/v1/store/cities/{cityID}/places"`)
+
+	for _, rpc := range []string{"AddPlace", "UpdatePlace", "DeletePlace", "CreateCity", "RenameCity"} {
+		_, err := newServer(&Config{
+			Transcoding: &transcoding.Config{Rules: []*transcoding.Rule{
+				{Selector: "PlacesStore." + rpc, Post: "/store/places", Body: "*", Scope: scopePublishPlaces},
+			}},
+		}, placestest.New(nil), nil)
+		assert.Error(t, err, rpc)
+	}
+}
+
+func TestServer_GraphQL(t *testing.T) {
+	s := newTestServer(t, &Config{
+		ValidateResponses: true,
+		GraphQL:           &graphqlapi.Config{},
+	}, nil)
+
+	body := `{"query": "query($id: ID!) { city(id: $id) { title places(first: 1) { nodes { title } } } }", "variables": {"id": "1"}}`
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(body)))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"data": {"city": {"title": "Moscow", "places": {"nodes": [{"title": "Coffee Bean"}]}}}}`, rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/graphql", strings.NewReader(`{"query": 1}`)))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestServer_GRPCWeb(t *testing.T) {
+	s := newTestServer(t, &Config{
+		GRPCWeb: &grpcweb.Config{},
+		APIKeys: &apikeys.Config{Keys: []*apikeys.Key{
+			{Name: "partner", Key: "secret", Scopes: []string{scopeAddPlaces}},
+			{Name: "admin", Key: "admin-key", Scopes: []string{scopeAdmin}},
+		}},
+	}, nil)
+
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodOptions, grpcweb.Path("GetCities"), nil))
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Contains(t, rec.Header()["Access-Control-Expose-Headers"], "Grpc-Status, Grpc-Message")
+
+	req := httptest.NewRequest(http.MethodPost, grpcweb.Path("GetCities"), strings.NewReader(`{}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"title":"Moscow"`)
+
+	body := `{"id": "1", "archived": true}`
+	req = httptest.NewRequest(http.MethodPost, grpcweb.Path("ArchiveCity"), strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	req = httptest.NewRequest(http.MethodPost, grpcweb.Path("ArchiveCity"), strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(apikeys.Header, "secret")
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	req = httptest.NewRequest(http.MethodPost, grpcweb.Path("ArchiveCity"), strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(apikeys.Header, "admin-key")
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	// City titles are checked for clashes by REST routes only, so "moscow" can't be created next to "Moscow"
+	for _, rpc := range []string{"AddPlace", "UpdatePlace", "DeletePlace", "CreateCity", "RenameCity"} {
+		req = httptest.NewRequest(http.MethodPost, grpcweb.Path(rpc), strings.NewReader(`{"cityName": "Moscow", "place": {"title": "Tea House"}}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(apikeys.Header, "secret")
+		rec = httptest.NewRecorder()
+		s.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusNotFound, rec.Code, rpc)
+	}
+}
+
+func TestServer_ContentNegotiation(t *testing.T) {
+	s := newTestServer(t, &Config{
+		ValidateResponses: true,
+		Cache:             &cache.Config{TTL: time.Minute},
+	}, nil)
+
+	get := func(path, accept string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Accept", accept)
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := get("/places?city_id=1", "application/x-protobuf")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/x-protobuf", rec.Header().Get("Content-Type"))
+	var msg places.GetPlacesByCityIDResponse
+	assert.NoError(t, proto.Unmarshal(rec.Body.Bytes(), &msg))
+	if assert.Len(t, msg.Places, 1) {
+		assert.Equal(t, "Coffee Bean", msg.Places[0].Title)
+	}
+
+	rec = get("/places?city_id=1", "application/json")
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	assert.Equal(t, "MISS", rec.Header().Get("X-Cache"))
+
+	rec = get("/v2/cities?format=csv", "application/json")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "id,title\n1,Moscow\n", rec.Body.String())
+
+	rec = get("/v2/cities", "application/msgpack")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var cities getCitiesV2Response
+	assert.NoError(t, msgpack.NewDecoder(rec.Body).UseJSONTag(true).Decode(&cities))
+	assert.Len(t, cities.Cities, 1)
+
+	rec = get("/v2/cities", "application/x-protobuf")
+	assert.Equal(t, http.StatusNotAcceptable, rec.Code)
+	assert.Contains(t, rec.Body.String(), "text/csv")
+
+	rec = get("/cities?format=yaml", "")
+	assert.Equal(t, http.StatusNotAcceptable, rec.Code)
+
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	assert.Contains(t, rec.Body.String(), `"text/csv": {}`)
+}
+
+func TestServer_ExportPlaces(t *testing.T) {
+	s := newTestServer(t, &Config{ValidateResponses: true}, nil)
+	stub := s.placesStore.(*placestest.Store)
+	for i := 0; i < exportPageSize+20; i++ {
+		stub.Places[2] = append(stub.Places[2], &places.Place{Id: uint64(i + 1), Title: "Place " + strconv.Itoa(i+1)})
+	}
+
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v2/cities/2/places/export", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/x-ndjson", rec.Header().Get("Content-Type"))
+	assert.True(t, rec.Flushed)
+	lines := strings.Split(strings.TrimSpace(rec.Body.String()), "\n")
+	if assert.Len(t, lines, exportPageSize+20) {
+		var place responsePlace
+		assert.NoError(t, json.Unmarshal([]byte(lines[len(lines)-1]), &place))
+		assert.Equal(t, uint64(exportPageSize+20), place.ID)
+	}
+
+	stub.Places[1] = append(stub.Places[1], &places.Place{Id: 900, Title: "Pier", Location: &places.Location{Latitude: 55.7575, Longitude: 37.6125}})
+	req := httptest.NewRequest(http.MethodGet, "/cities/1/places/export", nil)
+	req.Header.Set("Accept", "text/csv")
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	assert.Equal(t, "id,title,address,description,image_url,latitude,longitude\n1,Coffee Bean,Tverskaya 1,,,,\n900,Pier,,,,55.7575,37.6125\n", rec.Body.String())
+	assert.Contains(t, rec.Header().Get("Content-Disposition"), "city-1-places.csv")
+
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/cities/1/places/export?format=xml", nil))
+	assert.Equal(t, http.StatusNotAcceptable, rec.Code)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/cities/2/places/export", nil).WithContext(ctx))
+	assert.Equal(t, exportPageSize, strings.Count(rec.Body.String(), "\n"))
+}
+
+func TestServer_Imports(t *testing.T) {
+	dir, err := ioutil.TempDir("", "imports")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	s := newTestServer(t, &Config{
+		Imports: &importjobs.Config{DBPath: filepath.Join(dir, "imports.db"), Concurrency: 1, MaxFileSize: 1024},
+		History: &history.Config{DBPath: filepath.Join(dir, "history.db")},
+		APIKeys: &apikeys.Config{Keys: []*apikeys.Key{
+			{Name: "writer", Key: "writer-key", Scopes: []string{scopeAddPlaces}},
+			{Name: "ops", Key: "admin-key", Scopes: []string{scopeAdmin}},
+		}},
+	}, nil)
+	defer s.imports.Close()
+
+	do := func(method, path, contentType, body, key string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, path, strings.NewReader(body))
+		r.Header.Set("Content-Type", contentType)
+		r.Header.Set(apikeys.Header, key)
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, r)
+		return rec
+	}
+
+	file := "city_name,title\nMoscow,Tea House\nKazan,\n"
+	assert.Equal(t, http.StatusForbidden, do(http.MethodPost, "/admin/imports", "text/csv", file, "writer-key").Code)
+	assert.Equal(t, http.StatusUnsupportedMediaType, do(http.MethodPost, "/admin/imports", "text/plain", file, "admin-key").Code)
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodPost, "/admin/imports", "text/csv", "name\n", "admin-key").Code)
+	large := file + strings.Repeat("Moscow,Tea House\n", 100)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, do(http.MethodPost, "/admin/imports", "text/csv", large, "admin-key").Code)
+
+	// Malformed form and form without file are rejected as bad requests, not by size or media type
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodPost, "/admin/imports", "multipart/form-data", file, "admin-key").Code)
+	noFile := "--b\r\nContent-Disposition: form-data; name=\"title\"\r\n\r\nplaces\r\n--b--\r\n"
+	rec := do(http.MethodPost, "/admin/imports", "multipart/form-data; boundary=b", noFile, "admin-key")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), "no file field")
+
+	body := "--b\r\nContent-Disposition: form-data; name=\"file\"; filename=\"places.csv\"\r\n\r\n" + file + "\r\n--b--\r\n"
+	rec = do(http.MethodPost, "/v1/admin/imports", "multipart/form-data; boundary=b", body, "admin-key")
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+	var job importjobs.Job
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&job))
+	assert.Equal(t, 2, job.Total)
+	assert.Equal(t, "ops", job.Owner)
+	location := rec.Header().Get("Location")
+	assert.Equal(t, "/v1/admin/imports/"+strconv.FormatUint(job.ID, 10), location)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for job.Status != importjobs.StatusCompleted && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+		rec = do(http.MethodGet, location, "", "", "admin-key")
+		assert.NoError(t, json.NewDecoder(rec.Body).Decode(&job))
+	}
+	assert.Equal(t, importjobs.StatusCompleted, job.Status)
+	assert.Equal(t, 1, job.Created)
+	assert.Equal(t, 1, job.Rejected)
+	assert.Equal(t, http.StatusNotFound, do(http.MethodGet, "/admin/imports/100", "", "", "admin-key").Code)
+
+	revisions, err := s.history.List(101)
+	assert.NoError(t, err)
+	if assert.Len(t, revisions, 1) {
+		assert.Equal(t, history.ActionCreate, revisions[0].Action)
+		assert.Equal(t, "import:"+strconv.FormatUint(job.ID, 10), revisions[0].Author)
+	}
+}
+
+func TestServer_Idempotency(t *testing.T) {
+	s := newTestServer(t, &Config{
+		Idempotency: &cache.Config{},
+		APIKeys: &apikeys.Config{Keys: []*apikeys.Key{
+			{Name: "writer", Key: "writer-key", Scopes: []string{scopeAddPlaces, scopeEditPlaces}},
+			{Name: "other", Key: "other-key", Scopes: []string{scopeAddPlaces}},
+		}},
+	}, nil)
+
+	do := func(body, key, apiKey string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/places", strings.NewReader(body))
+		r.Header.Set(apikeys.Header, apiKey)
+		if key != "" {
+			r.Header.Set(IdempotencyKeyHeader, key)
+		}
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, r)
+		return rec
+	}
+
+	place := `{"city_name":"Moscow","title":"Rooftop"}`
+	first := do(place, "key-1", "writer-key")
+	assert.Equal(t, http.StatusCreated, first.Code)
+	assert.Empty(t, first.Header().Get(IdempotentReplayedHeader))
+
+	rec := do(place, "key-1", "writer-key")
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, "true", rec.Header().Get(IdempotentReplayedHeader))
+	assert.Equal(t, first.Body.String(), rec.Body.String())
+
+	assert.Equal(t, http.StatusUnprocessableEntity, do(`{"city_name":"Moscow","title":"Tea House"}`, "key-1", "writer-key").Code)
+	// Keys of different callers are independent, requests without key are not deduplicated
+	assert.Empty(t, do(place, "key-1", "other-key").Header().Get(IdempotentReplayedHeader))
+	assert.Equal(t, http.StatusCreated, do(place, "", "writer-key").Code)
+	assert.Len(t, s.placesStore.(*placestest.Store).Places[1], 4)
+
+	long := strings.Repeat("k", maxIdempotencyKeyLength+1)
+	assert.Equal(t, http.StatusBadRequest, do(place, long, "writer-key").Code)
+
+	send := func(method, path, body, key string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, path, strings.NewReader(body))
+		r.Header.Set(apikeys.Header, "writer-key")
+		r.Header.Set(IdempotencyKeyHeader, key)
+		r.Header.Set("If-Match", "*")
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, r)
+		return rec
+	}
+	// Query is part of request, ETag is replayed with stored response
+	assert.Equal(t, http.StatusUnprocessableEntity, send(http.MethodPost, "/places?v=2", place, "key-1").Code)
+	first = send(http.MethodPatch, "/places/1", `{"title":"Coffee"}`, "key-2")
+	assert.Equal(t, http.StatusOK, first.Code)
+	rec = send(http.MethodPatch, "/places/1", `{"title":"Coffee"}`, "key-2")
+	assert.Equal(t, "true", rec.Header().Get(IdempotentReplayedHeader))
+	assert.Equal(t, `"2"`, rec.Header().Get("ETag"))
+	assert.Equal(t, first.Header().Get("ETag"), rec.Header().Get("ETag"))
+}
+
+func TestServer_Duplicates(t *testing.T) {
+	s := newTestServer(t, &Config{
+		Duplicates: &duplicates.Config{},
+		APIKeys: &apikeys.Config{Keys: []*apikeys.Key{
+			{Name: "writer", Key: "writer-key", Scopes: []string{scopeAddPlaces}},
+		}},
+	}, nil)
+
+	do := func(path, body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		r.Header.Set(apikeys.Header, "writer-key")
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, r)
+		return rec
+	}
+
+	place := `{"city_name":"Moscow","title":"Cofee Bean!","address":"Tverskaya, 1"}`
+	rec := do("/places", place)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	var p problem
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&p))
+	if assert.Len(t, p.Duplicates, 1) {
+		assert.Equal(t, uint64(1), p.Duplicates[0].ID)
+		assert.True(t, p.Duplicates[0].Score >= 0.8)
+	}
+
+	assert.Equal(t, http.StatusCreated, do("/places?force=true", place).Code)
+	assert.Equal(t, http.StatusCreated, do("/places", `{"city_name":"Moscow","title":"Tea House"}`).Code)
+}
+
+func TestServer_Moderation(t *testing.T) {
+	dir, err := ioutil.TempDir("", "moderation")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	s := newTestServer(t, &Config{
+		Moderation: &moderation.Config{DBPath: filepath.Join(dir, "moderation.db")},
+		APIKeys: &apikeys.Config{Keys: []*apikeys.Key{
+			{Name: "app", Key: "app-key", Scopes: []string{scopeAddPlaces}},
+			{Name: "partner", Key: "partner-key", Scopes: []string{scopeAddPlaces, scopePublishPlaces}},
+			{Name: "ops", Key: "admin-key", Scopes: []string{scopeAdmin}},
+		}},
+	}, nil)
+	defer s.moderation.Close()
+
+	do := func(method, path, body, key string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, path, strings.NewReader(body))
+		r.Header.Set(apikeys.Header, key)
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, r)
+		return rec
+	}
+
+	assert.Equal(t, http.StatusCreated, do(http.MethodPost, "/places", `{"city_name":"Moscow","title":"Tea House"}`, "partner-key").Code)
+
+	rec := do(http.MethodPost, "/places", `{"city_name":"Moscow","title":"Rooftp"}`, "app-key")
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+	var submission moderation.Submission
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&submission))
+	assert.Equal(t, moderation.StatusPending, submission.Status)
+	assert.Len(t, s.placesStore.(*placestest.Store).Places[1], 2)
+	if op := s.apiDoc.Operation(http.MethodPost, "/places"); assert.NotNil(t, op) {
+		assert.Contains(t, op.Responses, "202")
+	}
+
+	// Format is refused before place is queued, so retry does not queue it twice
+	r := httptest.NewRequest(http.MethodPost, "/places", strings.NewReader(`{"city_name":"Moscow","title":"Bar"}`))
+	r.Header.Set(apikeys.Header, "app-key")
+	r.Header.Set("Accept", "application/x-protobuf")
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, r)
+	assert.Equal(t, http.StatusNotAcceptable, rec.Code)
+
+	path := "/admin/submissions/" + strconv.FormatUint(submission.ID, 10)
+	assert.Equal(t, http.StatusForbidden, do(http.MethodGet, "/admin/submissions", "", "app-key").Code)
+	rec = do(http.MethodGet, "/admin/submissions?status=pending", "", "admin-key")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"title":"Rooftp"`)
+	assert.NotContains(t, rec.Body.String(), `"title":"Bar"`)
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodPatch, path, `{"title":""}`, "admin-key").Code)
+	assert.Equal(t, http.StatusOK, do(http.MethodPatch, path, `{"title":"Rooftop"}`, "admin-key").Code)
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodPatch, path, `{"longitude":37.6125}`, "admin-key").Code)
+	assert.Equal(t, http.StatusOK, do(http.MethodPatch, path, `{"latitude":55.7575,"longitude":37.6125}`, "admin-key").Code)
+
+	rec = do(http.MethodPost, path+"/approve", "", "admin-key")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"place_id":`)
+	assert.Equal(t, http.StatusConflict, do(http.MethodPost, path+"/reject", `{"reason":"late"}`, "admin-key").Code)
+	places := s.placesStore.(*placestest.Store).Places[1]
+	assert.Equal(t, "Rooftop", places[len(places)-1].Title)
+	assert.Equal(t, 55.7575, places[len(places)-1].GetLocation().GetLatitude())
+
+	rec = do(http.MethodGet, "/me/submissions", "", "app-key")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"status":"approved"`)
+	assert.Equal(t, http.StatusUnauthorized, do(http.MethodGet, "/me/submissions", "", "").Code)
+}
+
+func TestServer_UpdatePlace(t *testing.T) {
+	s := newTestServer(t, &Config{
+		APIKeys: &apikeys.Config{Keys: []*apikeys.Key{
+			{Name: "writer", Key: "writer-key", Scopes: []string{scopeAddPlaces}},
+			{Name: "editor", Key: "editor-key", Scopes: []string{scopeEditPlaces, scopeDeletePlaces}},
+		}},
+	}, nil)
+
+	do := func(method, path, body, key, ifMatch string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, path, strings.NewReader(body))
+		r.Header.Set(apikeys.Header, key)
+		if ifMatch != "" {
+			r.Header.Set("If-Match", ifMatch)
+		}
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, r)
+		return rec
+	}
+
+	// Browser asks before cross-origin PATCH, preflight needs no credentials
+	rec := do(http.MethodOptions, "/v2/places/1", "", "", "")
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Contains(t, rec.Header().Get("Access-Control-Allow-Methods"), "PATCH")
+	assert.Contains(t, rec.Header().Get("Access-Control-Allow-Headers"), "If-Match")
+	assert.Equal(t, http.StatusNoContent, do(http.MethodOptions, "/places", "", "", "").Code)
+
+	fix := `{"address":"Tverskaya 10"}`
+	assert.Equal(t, http.StatusForbidden, do(http.MethodPatch, "/places/1", fix, "writer-key", `"1"`).Code)
+	assert.Equal(t, http.StatusPreconditionRequired, do(http.MethodPatch, "/places/1", fix, "editor-key", "").Code)
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodPatch, "/places/1", `{}`, "editor-key", `"1"`).Code)
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodPatch, "/places/1", `{"title":""}`, "editor-key", `"1"`).Code)
+
+	rec = do(http.MethodPatch, "/v2/places/1", fix, "editor-key", `"1"`)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, `"2"`, rec.Header().Get("ETag"))
+	assert.Contains(t, rec.Body.String(), `"address":"Tverskaya 10"`)
+	assert.Contains(t, rec.Body.String(), `"title":"Coffee Bean"`)
+
+	// Stale ETag loses concurrent update
+	assert.Equal(t, http.StatusPreconditionFailed, do(http.MethodPatch, "/places/1", `{"title":"Coffee"}`, "editor-key", `"1"`).Code)
+	assert.Equal(t, http.StatusNotFound, do(http.MethodPatch, "/places/7", fix, "editor-key", "*").Code)
+
+	// Coordinates are moved together
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodPatch, "/places/1", `{"latitude":55.7575}`, "editor-key", `"2"`).Code)
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodPatch, "/places/1", `{"latitude":95,"longitude":37.6125}`, "editor-key", `"2"`).Code)
+	rec = do(http.MethodPatch, "/places/1", `{"latitude":55.7575,"longitude":37.6125}`, "editor-key", `"2"`)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"latitude":55.7575,"longitude":37.6125`)
+
+	assert.Equal(t, http.StatusPreconditionFailed, do(http.MethodDelete, "/places/1", "", "editor-key", `"1"`).Code)
+	assert.Equal(t, http.StatusNoContent, do(http.MethodDelete, "/places/1", "", "editor-key", `"3"`).Code)
+	assert.Equal(t, http.StatusNotFound, do(http.MethodDelete, "/places/1", "", "editor-key", "*").Code)
+}
+
+func TestServer_History(t *testing.T) {
+	dir, err := ioutil.TempDir("", "history")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	s := newTestServer(t, &Config{
+		APIKeys: &apikeys.Config{Keys: []*apikeys.Key{
+			{Name: "writer", Key: "writer-key", Scopes: []string{scopeAddPlaces}},
+			{Name: "editor", Key: "editor-key", Scopes: []string{scopeEditPlaces}},
+			{Name: "ops", Key: "admin-key", Scopes: []string{scopeAdmin}},
+		}},
+		History: &history.Config{DBPath: filepath.Join(dir, "history.db")},
+	}, nil)
+
+	do := func(method, path, body, key, ifMatch string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, path, strings.NewReader(body))
+		r.Header.Set(apikeys.Header, key)
+		if ifMatch != "" {
+			r.Header.Set("If-Match", ifMatch)
+		}
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, r)
+		return rec
+	}
+
+	rec := do(http.MethodPost, "/places", `{"city_id":1,"title":"Tea House","address":"Arbat 5"}`, "writer-key", "")
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"id":101`)
+	assert.Equal(t, http.StatusOK, do(http.MethodPatch, "/places/101", `{"title":"Tea Room"}`, "editor-key", "*").Code)
+
+	assert.Equal(t, http.StatusForbidden, do(http.MethodGet, "/places/101/history", "", "writer-key", "").Code)
+	assert.Equal(t, http.StatusNotFound, do(http.MethodGet, "/places/1/history", "", "editor-key", "").Code)
+	rec = do(http.MethodGet, "/places/101/history", "", "editor-key", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var resp historyResponse
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	if assert.Len(t, resp.Revisions, 2) {
+		assert.Equal(t, history.ActionCreate, resp.Revisions[0].Action)
+		assert.Equal(t, "api_key:writer", resp.Revisions[0].Author)
+		assert.Equal(t, "api_key:editor", resp.Revisions[1].Author)
+		assert.Equal(t, "Tea House", *resp.Revisions[1].Changes[0].From)
+		assert.Equal(t, "Tea Room", resp.Revisions[1].Changes[0].To)
+	}
+
+	rollback := `{"revision":1}`
+	assert.Equal(t, http.StatusForbidden, do(http.MethodPost, "/places/101/rollback", rollback, "editor-key", "*").Code)
+	assert.Equal(t, http.StatusNotFound, do(http.MethodPost, "/places/101/rollback", `{"revision":5}`, "admin-key", "*").Code)
+	assert.Equal(t, http.StatusPreconditionRequired, do(http.MethodPost, "/places/101/rollback", rollback, "admin-key", "").Code)
+	rec = do(http.MethodPost, "/places/101/rollback", rollback, "admin-key", "*")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"title":"Tea House"`)
+	assert.Equal(t, http.StatusConflict, do(http.MethodPost, "/places/101/rollback", rollback, "admin-key", "*").Code)
+
+	rec = do(http.MethodGet, "/places/101/history", "", "editor-key", "")
+	assert.Contains(t, rec.Body.String(), `"action":"rollback"`)
+	assert.Contains(t, rec.Body.String(), `"rolled_back_to":1`)
+
+	// Change made bypassing gateway is still rolled back, current state is read from store
+	stub := s.placesStore.(*placestest.Store)
+	stub.Place(101).Title = "Tea Bar"
+	rec = do(http.MethodPost, "/places/101/rollback", rollback, "admin-key", "*")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"title":"Tea House"`)
+	assert.Equal(t, http.StatusNotFound, do(http.MethodPost, "/places/1/rollback", rollback, "admin-key", "*").Code)
+
+	// Coordinates are tracked and restored together
+	assert.Equal(t, http.StatusOK, do(http.MethodPatch, "/places/101", `{"latitude":55.75,"longitude":37.61}`, "editor-key", "*").Code)
+	assert.Equal(t, http.StatusOK, do(http.MethodPatch, "/places/101", `{"latitude":55.76,"longitude":37.62}`, "editor-key", "*").Code)
+	rec = do(http.MethodGet, "/places/101/history", "", "editor-key", "")
+	resp = historyResponse{}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	if assert.Len(t, resp.Revisions, 6) {
+		changes := resp.Revisions[4].Changes
+		if assert.Len(t, changes, 2) {
+			assert.Equal(t, "latitude", changes[0].Field)
+			assert.Equal(t, "", *changes[0].From)
+			assert.Equal(t, "55.75", changes[0].To)
+			assert.Equal(t, "longitude", changes[1].Field)
+		}
+		changes = resp.Revisions[5].Changes
+		if assert.Len(t, changes, 2) {
+			assert.Equal(t, "55.75", *changes[0].From)
+			assert.Equal(t, "55.76", changes[0].To)
+		}
+	}
+	rec = do(http.MethodPost, "/places/101/rollback", `{"revision":5}`, "admin-key", "*")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"latitude":55.75,"longitude":37.61`)
+	assert.Equal(t, 37.61, stub.Place(101).GetLocation().GetLongitude())
+}
+
+func TestServer_Audit(t *testing.T) {
+	dir, err := ioutil.TempDir("", "audit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	s := newTestServer(t, &Config{
+		APIKeys: &apikeys.Config{Keys: []*apikeys.Key{
+			{Name: "writer", Key: "writer-key", Scopes: []string{scopeReadPlaces, scopeAddPlaces, scopeEditPlaces}},
+			{Name: "ops", Key: "admin-key", Scopes: []string{scopeAdmin}},
+		}},
+		Audit: &audit.Config{Path: filepath.Join(dir, "audit.jsonl")},
+		RateLimit: &ratelimit.Config{
+			Policies: []*ratelimit.Policy{{Route: "/places/{id}", Requests: 2, Period: time.Minute}},
+		},
+	}, nil)
+
+	do := func(method, path, body, key string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, path, strings.NewReader(body))
+		r.Header.Set(apikeys.Header, key)
+		r.Header.Set("Content-Type", "application/json")
+		r.Header.Set("If-Match", `"7"`)
+		r.RemoteAddr = "192.0.2.1:5000"
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, r)
+		return rec
+	}
+
+	assert.Equal(t, http.StatusCreated, do(http.MethodPost, "/v2/places", `{"city_id":1,"title":"Tea House"}`, "writer-key").Code)
+	assert.Equal(t, http.StatusPreconditionFailed, do(http.MethodPatch, "/places/1", `{"title":"Coffee"}`, "writer-key").Code)
+	assert.Equal(t, http.StatusOK, do(http.MethodGet, "/places?city_id=1", "", "writer-key").Code)
+	assert.Equal(t, http.StatusForbidden, do(http.MethodGet, "/admin/audit", "", "writer-key").Code)
+
+	rec := do(http.MethodGet, "/admin/audit?principal=api_key:writer", "", "admin-key")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var resp auditResponse
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	if assert.Len(t, resp.Entries, 2) {
+		patch := resp.Entries[0]
+		assert.Equal(t, "/places/{id}", patch.Route)
+		assert.Equal(t, http.StatusPreconditionFailed, patch.Status)
+		assert.Contains(t, patch.Result, "ETag")
+		post := resp.Entries[1]
+		assert.Equal(t, "/places", post.Route)
+		assert.Equal(t, "/v2/places", post.Path)
+		assert.Equal(t, "192.0.2.1", post.IP)
+		assert.Equal(t, http.StatusCreated, post.Status)
+		assert.Equal(t, map[string]interface{}{"city_id": float64(1), "title": "Tea House"}, post.Body)
+	}
+
+	rec = do(http.MethodGet, "/admin/audit?route=/places&from=2000-01-01T00:00:00Z", "", "admin-key")
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Len(t, resp.Entries, 1)
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodGet, "/admin/audit?to=yesterday", "", "admin-key").Code)
+
+	// Requests rejected by authentication, scopes and rate limit are recorded too
+	assert.Equal(t, http.StatusUnauthorized, do(http.MethodPost, "/places", `{"city_id":1,"title":"Tea"}`, "bogus-key").Code)
+	assert.Equal(t, http.StatusForbidden, do(http.MethodDelete, "/places/1", "", "writer-key").Code)
+	assert.Equal(t, http.StatusTooManyRequests, do(http.MethodDelete, "/places/1", "", "writer-key").Code)
+	rec = do(http.MethodGet, "/admin/audit?limit=3", "", "admin-key")
+	var rejected auditResponse
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &rejected))
+	if assert.Len(t, rejected.Entries, 3) {
+		assert.Equal(t, http.StatusTooManyRequests, rejected.Entries[0].Status)
+		assert.Empty(t, rejected.Entries[0].Principal)
+		assert.Equal(t, http.StatusForbidden, rejected.Entries[1].Status)
+		assert.Equal(t, "api_key:writer", rejected.Entries[1].Principal)
+		assert.Equal(t, http.StatusUnauthorized, rejected.Entries[2].Status)
+		assert.Equal(t, "/places", rejected.Entries[2].Route)
+	}
+}
+
+func TestServer_Search(t *testing.T) {
+	s := newTestServer(t, &Config{
+		APIKeys: &apikeys.Config{Keys: []*apikeys.Key{
+			{Name: "editor", Key: "editor-key", Scopes: []string{scopeReadPlaces, scopeAddPlaces, scopeEditPlaces, scopeDeletePlaces}},
+		}},
+		Search: &search.Config{},
+	}, nil)
+	defer s.syncer.Close()
+	defer s.search.Close()
+	assert.NoError(t, s.syncer.Sync(context.Background()))
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, path, strings.NewReader(body))
+		r.Header.Set(apikeys.Header, "editor-key")
+		r.Header.Set("If-Match", "*")
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, r)
+		return rec
+	}
+	find := func(query string) searchPlacesResponse {
+		rec := do(http.MethodGet, "/v2/places/search?"+query, "")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		var resp searchPlacesResponse
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		return resp
+	}
+
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodGet, "/places/search?city_id=1", "").Code)
+
+	resp := find("q=beans&city_id=1")
+	assert.Equal(t, uint64(1), resp.Total)
+	if assert.Len(t, resp.Places, 1) {
+		assert.Equal(t, uint64(1), resp.Places[0].ID)
+		assert.Equal(t, uint64(1), resp.Places[0].CityID)
+		assert.Equal(t, []string{"Coffee <mark>Bean</mark>"}, resp.Places[0].Highlights["title"])
+	}
+	assert.Equal(t, uint64(0), find("q=beans&city_id=2").Total)
+
+	// Places written through gateway are searchable at once
+	assert.Equal(t, http.StatusCreated, do(http.MethodPost, "/places", `{"city_id":1,"title":"Rooftop Bar","description":"Coffee and cocktails"}`).Code)
+	assert.Equal(t, http.StatusOK, do(http.MethodPatch, "/places/1", `{"title":"Bean Bar"}`).Code)
+	resp = find("q=coffee&amount=1")
+	assert.Equal(t, uint64(1), resp.Total)
+	assert.Nil(t, resp.Page.NextOffset)
+	resp = find("q=bar&amount=1")
+	assert.Equal(t, uint64(2), resp.Total)
+	if assert.NotNil(t, resp.Page.NextOffset) {
+		assert.Equal(t, uint64(1), *resp.Page.NextOffset)
+	}
+
+	assert.Equal(t, http.StatusNoContent, do(http.MethodDelete, "/places/1", "").Code)
+	assert.Equal(t, uint64(1), find("q=bar").Total)
+}
+
+func TestServer_Suggest(t *testing.T) {
+	s := newTestServer(t, &Config{Suggest: &suggest.Config{}}, nil)
+	defer s.syncer.Close()
+	assert.NoError(t, s.syncer.Sync(context.Background()))
+
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/suggest?q=%D0%BC%D0%BE%D1%81", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"suggestions":[{"type":"city","id":1,"title":"Moscow"}]}`, rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v2/suggest?q=cofee+bea&limit=5", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"suggestions":[{"type":"place","id":1,"title":"Coffee Bean","city_id":1}]}`, rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/suggest?limit=5", nil))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	// Limit is capped by config instead of being cut silently
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/suggest?q=mos&limit=11", nil))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), "at most 10")
+}
+
+func TestServer_Nearby(t *testing.T) {
+	s := newTestServer(t, &Config{
+		APIKeys: &apikeys.Config{Keys: []*apikeys.Key{
+			{Name: "editor", Key: "editor-key", Scopes: []string{scopeReadPlaces, scopeAddPlaces, scopeDeletePlaces}},
+		}},
+		Geo: &geo.Config{MaxRadius: 10000},
+	}, nil)
+	defer s.syncer.Close()
+	assert.NoError(t, s.syncer.Sync(context.Background()))
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, path, strings.NewReader(body))
+		r.Header.Set(apikeys.Header, "editor-key")
+		r.Header.Set("If-Match", "*")
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, r)
+		return rec
+	}
+
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodPost, "/places", `{"city_id":1,"title":"Kremlin","latitude":55.752}`).Code)
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodPost, "/places", `{"city_id":1,"title":"Kremlin","latitude":95,"longitude":37.6}`).Code)
+	assert.Equal(t, http.StatusCreated, do(http.MethodPost, "/places", `{"city_id":1,"title":"Kremlin","latitude":55.7520,"longitude":37.6175}`).Code)
+	assert.Equal(t, http.StatusCreated, do(http.MethodPost, "/places", `{"city_id":1,"title":"Gorky Park","latitude":55.7298,"longitude":37.6036}`).Code)
+
+	rec := do(http.MethodGet, "/places?city_id=1", "")
+	assert.Contains(t, rec.Body.String(), `"latitude":55.752,"longitude":37.6175`)
+
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodGet, "/places/nearby?lat=55.75", "").Code)
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodGet, "/places/nearby?lat=55.75&lon=37.62&radius=20000", "").Code)
+
+	rec = do(http.MethodGet, "/v2/places/nearby?lat=55.7525&lon=37.6180&radius=5000", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var resp nearbyPlacesResponse
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	if assert.Len(t, resp.Places, 2) {
+		assert.Equal(t, "Kremlin", resp.Places[0].Title)
+		assert.Equal(t, 64.0, resp.Places[0].Distance)
+		assert.Equal(t, uint64(1), resp.Places[0].CityID)
+		assert.Equal(t, "Gorky Park", resp.Places[1].Title)
+	}
+
+	assert.Equal(t, http.StatusNoContent, do(http.MethodDelete, "/places/101", "").Code)
+	resp = nearbyPlacesResponse{}
+	assert.NoError(t, json.Unmarshal(do(http.MethodGet, "/places/nearby?lat=55.7525&lon=37.6180", "").Body.Bytes(), &resp))
+	assert.Empty(t, resp.Places)
+}
+
+func TestServer_Cities(t *testing.T) {
+	s := newTestServer(t, &Config{
+		APIKeys: &apikeys.Config{Keys: []*apikeys.Key{
+			{Name: "writer", Key: "writer-key", Scopes: []string{scopeAddPlaces}},
+			{Name: "ops", Key: "admin-key", Scopes: []string{scopeAdmin}},
+		}},
+	}, nil)
+	stub := s.placesStore.(*placestest.Store)
+
+	do := func(method, path, body, key string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, path, strings.NewReader(body))
+		r.Header.Set(apikeys.Header, key)
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, r)
+		return rec
+	}
+
+	assert.Equal(t, http.StatusForbidden, do(http.MethodPost, "/admin/cities", `{"title":"Kazan"}`, "writer-key").Code)
+	assert.Equal(t, http.StatusConflict, do(http.MethodPost, "/admin/cities", `{"title":"moscow"}`, "admin-key").Code)
+	rec := do(http.MethodPost, "/admin/cities", `{"title":"Kazn"}`, "admin-key")
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"id":2`)
+	rec = do(http.MethodPatch, "/admin/cities/2", `{"title":"Kazan"}`, "admin-key")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"title":"Kazan"`)
+	assert.Equal(t, http.StatusNotFound, do(http.MethodPatch, "/admin/cities/9", `{"title":"Sochi"}`, "admin-key").Code)
+	// Names resolving to other active city are rejected before reaching store
+	assert.Equal(t, http.StatusConflict, do(http.MethodPost, "/admin/cities", `{"title":" Moscow!"}`, "admin-key").Code)
+	assert.Equal(t, http.StatusConflict, do(http.MethodPatch, "/admin/cities/2", `{"title":"MOSCOW."}`, "admin-key").Code)
+	assert.Equal(t, http.StatusOK, do(http.MethodPatch, "/admin/cities/2", `{"title":"Kazan"}`, "admin-key").Code)
+
+	// Places are added to canonical city whatever spelling of its name is
+	assert.Equal(t, http.StatusCreated, do(http.MethodPost, "/places", `{"city_name":" MOSCOW","title":"Tea House"}`, "writer-key").Code)
+	assert.Equal(t, http.StatusCreated, do(http.MethodPost, "/places", `{"city_id":2,"title":"Chak-chak"}`, "writer-key").Code)
+	assert.Equal(t, http.StatusUnprocessableEntity, do(http.MethodPost, "/places", `{"city_name":"Atlantis","title":"Reef"}`, "writer-key").Code)
+	// City created bypassing this gateway is found after kept index misses it
+	stub.Cities = append(stub.Cities, &places.City{Id: 3, Title: "Sochi"})
+	assert.Equal(t, http.StatusCreated, do(http.MethodPost, "/places", `{"city_name":"sochi","title":"Pier"}`, "writer-key").Code)
+	stub.Cities = stub.Cities[:2]
+	assert.Len(t, stub.Cities, 2)
+	assert.Len(t, stub.Places[1], 2)
+
+	rec = do(http.MethodPost, "/admin/cities/2/archive", "", "admin-key")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"archived":true`)
+	assert.Equal(t, http.StatusUnprocessableEntity, do(http.MethodPost, "/places", `{"city_id":2,"title":"Kazan Kremlin"}`, "writer-key").Code)
+	assert.Equal(t, http.StatusOK, do(http.MethodPost, "/admin/cities/2/restore", "", "admin-key").Code)
+
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodPost, "/admin/cities/2/merge", `{"target_id":2}`, "admin-key").Code)
+	assert.Equal(t, http.StatusOK, do(http.MethodPost, "/admin/cities/2/merge", `{"target_id":1}`, "admin-key").Code)
+	assert.Len(t, stub.Cities, 1)
+	assert.Len(t, stub.Places[1], 3)
+}
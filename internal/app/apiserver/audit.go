@@ -0,0 +1,143 @@
+package apiserver
+
+import (
+	"bytes"
+	"chillit-rest-gateway/internal/app/audit"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/gorilla/schema"
+)
+
+type auditRequest struct {
+	Principal string `schema:"principal"`
+	Route     string `schema:"route"`
+	From      string `schema:"from"`
+	To        string `schema:"to"`
+	Limit     int    `schema:"limit" validate:"min=0,max=1000"`
+}
+
+type auditResponse struct {
+	Entries []*audit.Entry `json:"entries"`
+}
+
+// clientIP returns address of client, resolved behind trusted proxies when rate limiter is configured
+func (s *server) clientIP(r *http.Request) string {
+	if s.limiter != nil {
+		return s.limiter.ClientIP(r)
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// AuditMiddleware records every request except safe ones with caller, sanitized body and result.
+// It runs before rate limiting and authentication, so their rejections are recorded too
+func (s *server) AuditMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.audit == nil || r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodOptions {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// Only head of body is kept, rest is streamed to handler as is
+		maxBodySize := s.audit.Config().MaxBodySize
+		head, err := ioutil.ReadAll(io.LimitReader(r.Body, int64(maxBodySize)+1))
+		if err != nil {
+			s.writeProblem(w, http.StatusBadRequest, "could not read body")
+			return
+		}
+		truncated := len(head) > maxBodySize
+		r.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(head), r.Body), r.Body}
+
+		entry := &audit.Entry{
+			Time:   time.Now().UTC(),
+			IP:     s.clientIP(r),
+			Method: r.Method,
+			Path:   r.URL.Path,
+			Body:   s.audit.Body(r.Header.Get("Content-Type"), head, truncated),
+		}
+		if route := mux.CurrentRoute(r); route != nil {
+			template, _ := route.GetPathTemplate()
+			entry.Route = unversionedPath(template)
+		}
+
+		rec := &responseRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r.WithContext(context.WithValue(r.Context(), auditContextKey, entry)))
+
+		entry.LatencyMs = float64(time.Since(entry.Time).Microseconds()) / 1000
+		entry.Status = rec.status
+		if entry.Status == 0 {
+			entry.Status = http.StatusOK
+		}
+		if entry.Status >= 400 {
+			var details problem
+			if err := json.Unmarshal(rec.body.Bytes(), &details); err == nil {
+				entry.Result = details.Detail
+			}
+		}
+		if err := s.audit.Record(entry); err != nil {
+			s.logger.Errorf("could not record audit entry, error: %v", err)
+		}
+	})
+}
+
+func (s *server) auditHandler() http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var requestValues auditRequest
+		decoder := schema.NewDecoder()
+		decoder.IgnoreUnknownKeys(true)
+		if err := decoder.Decode(&requestValues, r.URL.Query()); err != nil {
+			s.writeProblem(w, http.StatusBadRequest, "invalid query: "+err.Error())
+			return
+		}
+		if requestValues.Limit == 0 {
+			requestValues.Limit = 100
+		}
+		filter := &audit.Filter{
+			Principal: requestValues.Principal,
+			Route:     requestValues.Route,
+			Limit:     requestValues.Limit,
+		}
+		for _, bound := range []struct {
+			value  string
+			target *time.Time
+		}{
+			{requestValues.From, &filter.From},
+			{requestValues.To, &filter.To},
+		} {
+			if bound.value == "" {
+				continue
+			}
+			t, err := time.Parse(time.RFC3339, bound.value)
+			if err != nil {
+				s.writeProblem(w, http.StatusBadRequest, "from and to must be RFC 3339 times")
+				return
+			}
+			*bound.target = t
+		}
+
+		entries, err := s.audit.Query(filter)
+		if err == audit.ErrNotQueryable {
+			s.writeProblem(w, http.StatusNotImplemented, err.Error())
+			return
+		}
+		if err != nil {
+			s.logger.Errorf("could not query audit log, error: %v", err)
+			s.writeProblem(w, http.StatusInternalServerError, "could not query audit log")
+			return
+		}
+		s.writeResponse(w, r, http.StatusOK, &auditResponse{Entries: entries})
+	})
+}
@@ -0,0 +1,171 @@
+package apiserver
+
+import (
+	"chillit-rest-gateway/internal/app/apikeys"
+	"chillit-rest-gateway/internal/app/audit"
+	"chillit-rest-gateway/internal/app/metrics"
+	"chillit-rest-gateway/internal/app/ratelimit"
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Scopes required by routes, granted to API keys and user tokens
+const (
+	scopeReadPlaces = "places:read"
+	scopeAddPlaces  = "places:write"
+	// scopePublishPlaces lets places bypass moderation queue
+	scopePublishPlaces = "places:publish"
+	scopeEditPlaces    = "places:edit"
+	scopeDeletePlaces  = "places:delete"
+	scopeAdmin         = "admin"
+)
+
+// Kinds of authenticated callers
+const (
+	principalAPIKey = "api_key"
+	principalUser   = "user"
+)
+
+// principal is authenticated caller
+type principal struct {
+	Kind   string
+	Name   string
+	Scopes []string
+}
+
+func (p *principal) hasScope(scope string) bool {
+	for _, s := range p.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+type contextKey int
+
+const (
+	principalContextKey contextKey = iota
+	sessionContextKey
+	auditContextKey
+)
+
+// withPrincipal also names caller in audit entry of request, audit middleware runs before authentication
+func withPrincipal(ctx context.Context, p *principal) context.Context {
+	if entry, ok := ctx.Value(auditContextKey).(*audit.Entry); ok {
+		entry.Principal = submitterOf(p)
+	}
+	return context.WithValue(ctx, principalContextKey, p)
+}
+
+// principalFromContext returns caller or nil for anonymous requests
+func principalFromContext(ctx context.Context) *principal {
+	p, _ := ctx.Value(principalContextKey).(*principal)
+	return p
+}
+
+// APIKeyMiddleware identifies partner by API key and applies key quota.
+// Requests without key are passed as anonymous
+func (s *server) APIKeyMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		value := apikeys.FromRequest(r)
+		if s.apiKeys == nil || value == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		key, ok := s.apiKeys.Lookup(value)
+		if !ok {
+			s.logger.Warnf("unknown API key used for %s %s", r.Method, r.URL.Path)
+			s.writeProblem(w, http.StatusUnauthorized, "unknown API key")
+			return
+		}
+
+		logger := s.logger.WithField("api_key", key.Name)
+		metrics.RequestsByAPIKey.Add(key.Name, 1)
+
+		if key.Quota != nil {
+			result, err := ratelimit.TakeToken(s.buckets, "apikey|"+key.Name, key.Quota.Requests, key.Quota.Requests, key.Quota.Period, time.Now())
+			if err != nil {
+				logger.Errorf("could not apply API key quota, error: %v", err)
+			} else if !result.Allowed {
+				logger.Infof("API key quota exceeded for %s %s", r.Method, r.URL.Path)
+				metrics.RejectedByAPIKey.Add(key.Name, 1)
+				w.Header().Set("Retry-After", strconv.FormatInt(ceilSeconds(result.RetryAfter), 10))
+				s.writeProblem(w, http.StatusTooManyRequests, "API key quota exceeded")
+				return
+			}
+		}
+
+		logger.Infof("%s %s", r.Method, r.URL.Path)
+		next.ServeHTTP(w, r.WithContext(withPrincipal(r.Context(), &principal{
+			Kind:   principalAPIKey,
+			Name:   key.Name,
+			Scopes: key.Scopes,
+		})))
+	})
+}
+
+// JWTMiddleware identifies user by bearer token. Requests without token are passed as is
+func (s *server) JWTMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization := r.Header.Get("Authorization")
+		if s.jwtValidator == nil || authorization == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		const bearerPrefix = "bearer "
+		if len(authorization) <= len(bearerPrefix) || strings.ToLower(authorization[:len(bearerPrefix)]) != bearerPrefix {
+			w.Header().Set("WWW-Authenticate", `Bearer error="invalid_request"`)
+			s.writeProblem(w, http.StatusUnauthorized, "authorization header must contain bearer token")
+			return
+		}
+
+		claims, err := s.jwtValidator.Validate(strings.TrimSpace(authorization[len(bearerPrefix):]))
+		if err != nil {
+			s.logger.Infof("invalid bearer token for %s %s, error: %v", r.Method, r.URL.Path, err)
+			w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+			s.writeProblem(w, http.StatusUnauthorized, "invalid bearer token")
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(withPrincipal(r.Context(), &principal{
+			Kind:   principalUser,
+			Name:   claims.Subject,
+			Scopes: claims.Scopes,
+		})))
+	})
+}
+
+// ScopeMiddleware rejects callers which are not granted scope.
+// Anonymous callers pass only if allowAnonymous is set
+func (s *server) ScopeMiddleware(scope string, allowAnonymous bool, next http.HandlerFunc) http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p := principalFromContext(r.Context())
+		if p == nil {
+			if allowAnonymous {
+				next.ServeHTTP(w, r)
+				return
+			}
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			s.writeProblem(w, http.StatusUnauthorized, "authentication is required")
+			return
+		}
+
+		if !p.hasScope(scope) {
+			switch p.Kind {
+			case principalAPIKey:
+				metrics.RejectedByAPIKey.Add(p.Name, 1)
+			case principalUser:
+				w.Header().Set("WWW-Authenticate", `Bearer error="insufficient_scope", scope="`+scope+`"`)
+			}
+			s.writeProblem(w, http.StatusForbidden, "scope '"+scope+"' is required")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
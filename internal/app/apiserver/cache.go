@@ -0,0 +1,139 @@
+package apiserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Cache scopes, write to places store drops every cached response of its scope
+const (
+	cacheScopeCities = "cities"
+	cacheScopePlaces = "places"
+)
+
+// cachedResponse is successful response stored in cache
+type cachedResponse struct {
+	ContentType string   `json:"content_type"`
+	Vary        []string `json:"vary,omitempty"`
+	Body        []byte   `json:"body"`
+}
+
+// responseRecorder copies response written by handler
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (rec *responseRecorder) WriteHeader(status int) {
+	rec.status = status
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *responseRecorder) Write(b []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	rec.body.Write(b)
+	return rec.ResponseWriter.Write(b)
+}
+
+func generationKey(scope string) string {
+	return "generation|" + scope
+}
+
+// cacheGeneration returns current generation of scope, starting one if there is none
+func (s *server) cacheGeneration(scope string) (string, error) {
+	data, ok, err := s.cache.Get(generationKey(scope))
+	if err != nil || ok {
+		return string(data), err
+	}
+	generation := []byte(strconv.FormatInt(time.Now().UnixNano(), 36))
+	added, err := s.cache.Add(generationKey(scope), generation, s.cacheTTL)
+	if err != nil || added {
+		return string(generation), err
+	}
+	data, _, err = s.cache.Get(generationKey(scope))
+	return string(data), err
+}
+
+// invalidateCache starts new generation of scopes, so responses cached before are not served anymore.
+// Generations are kept in cache store, so every gateway sharing it sees them
+func (s *server) invalidateCache(scopes ...string) {
+	if s.cache == nil {
+		return
+	}
+	generation := []byte(strconv.FormatInt(time.Now().UnixNano(), 36))
+	for _, scope := range scopes {
+		if err := s.cache.Set(generationKey(scope), generation, s.cacheTTL); err != nil {
+			s.logger.Errorf("could not invalidate cache, error: %v", err)
+		}
+	}
+}
+
+// CacheMiddleware serves successful GET responses from cache until write to places store
+// invalidates scope of response
+func (s *server) CacheMiddleware(scope string, next http.HandlerFunc) http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.cache == nil || r.Method != http.MethodGet {
+			next.ServeHTTP(w, r)
+			return
+		}
+		generation, err := s.cacheGeneration(scope)
+		if err != nil {
+			s.logger.Errorf("could not read cache, error: %v", err)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// Responses are negotiated, so representations requested by different Accept headers are kept apart
+		key := scope + "|" + generation + "|" + r.URL.Path + "?" + r.URL.Query().Encode() + "|" + r.Header.Get("Accept")
+		if data, ok, err := s.cache.Get(key); err != nil {
+			s.logger.Errorf("could not read cache, error: %v", err)
+		} else if ok {
+			var cached cachedResponse
+			if err := json.Unmarshal(data, &cached); err == nil {
+				if cached.ContentType != "" {
+					w.Header().Set("Content-Type", cached.ContentType)
+				}
+				for _, value := range cached.Vary {
+					w.Header().Add("Vary", value)
+				}
+				w.Header().Set("X-Cache", "HIT")
+				w.Write(cached.Body)
+				return
+			}
+			s.logger.Errorf("could not decode cached response, error: %v", err)
+		}
+
+		w.Header().Set("X-Cache", "MISS")
+		rec := &responseRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r)
+		if rec.status != http.StatusOK {
+			return
+		}
+
+		data, err := json.Marshal(&cachedResponse{
+			ContentType: w.Header().Get("Content-Type"),
+			Vary:        w.Header()["Vary"],
+			Body:        rec.body.Bytes(),
+		})
+		if err != nil {
+			s.logger.Errorf("could not encode cached response, error: %v", err)
+			return
+		}
+		if err := s.cache.Set(key, data, s.cacheTTL); err != nil {
+			s.logger.Errorf("could not write cache, error: %v", err)
+		}
+	})
+}
+
+// Flush sends buffered data of streamed responses to client
+func (rec *responseRecorder) Flush() {
+	if flusher, ok := rec.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
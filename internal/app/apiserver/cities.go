@@ -0,0 +1,169 @@
+package apiserver
+
+import (
+	"chillit-rest-gateway/internal/app/cities"
+	"chillit-rest-gateway/internal/app/places"
+	"chillit-rest-gateway/internal/app/transcoding"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"google.golang.org/grpc/status"
+)
+
+// cityIndexTTL is how long index of cities is kept, cities changed through gateway invalidate it at once
+const cityIndexTTL = time.Minute
+
+type cityTitleRequest struct {
+	Title string `json:"title" validate:"minlen=1,maxlen=100"`
+}
+
+type mergeCitiesRequest struct {
+	// TargetID is city receiving places of merged city
+	TargetID uint64 `json:"target_id" validate:"min=1"`
+}
+
+type adminCityResponse struct {
+	ID       uint64 `json:"id"`
+	Title    string `json:"title"`
+	Archived bool   `json:"archived"`
+}
+
+func newAdminCityResponse(city *places.City) *adminCityResponse {
+	return &adminCityResponse{ID: city.GetId(), Title: city.GetTitle(), Archived: city.GetArchived()}
+}
+
+// lookupCity finds city by ID or, if ID is zero, by name
+func lookupCity(index cities.Index, id uint64, name string) *places.City {
+	if id != 0 {
+		return index.ByID(id)
+	}
+	return index.Lookup(name)
+}
+
+// citiesChanged drops index of cities and cached responses and resyncs place indexes, places
+// are affected too as merge moves them and archive hides them
+func (s *server) citiesChanged() {
+	s.cities.Invalidate()
+	s.invalidateCache(cacheScopeCities, cacheScopePlaces)
+	s.syncer.Trigger()
+}
+
+// writeCityResponse writes city returned by places store or maps error of store call
+func (s *server) writeCityResponse(w http.ResponseWriter, r *http.Request, status int, city *places.City, err error) {
+	if err != nil {
+		s.writeStoreError(w, err)
+		return
+	}
+	s.citiesChanged()
+	s.writeResponse(w, r, status, newAdminCityResponse(city))
+}
+
+// writeStoreError maps gRPC status of places store call to problem, details of server errors are logged only
+func (s *server) writeStoreError(w http.ResponseWriter, err error) {
+	st, _ := status.FromError(err)
+	code := transcoding.HTTPStatus(st.Code())
+	if code >= http.StatusInternalServerError {
+		s.logger.Errorf("places store call failed, error: %v", err)
+		s.writeProblem(w, http.StatusBadGateway, "places store call failed")
+		return
+	}
+	s.writeProblem(w, code, st.Message())
+}
+
+func (s *server) cityID(w http.ResponseWriter, r *http.Request) (uint64, bool) {
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil || id == 0 {
+		s.writeProblem(w, http.StatusBadRequest, "invalid city id")
+		return 0, false
+	}
+	return id, true
+}
+
+// checkCityTitle rejects title which resolves to other active city, so names do not become ambiguous
+func (s *server) checkCityTitle(w http.ResponseWriter, r *http.Request, id uint64, title string) bool {
+	index, err := s.cities.Index(r.Context())
+	if err != nil {
+		s.logger.Errorf("could not get cities, error: %v", err)
+		s.writeProblem(w, http.StatusBadGateway, "places store call failed")
+		return false
+	}
+	if city := index.Lookup(title); city != nil && city.GetId() != id {
+		s.writeProblem(w, http.StatusConflict, "city '"+city.GetTitle()+"' has the same name")
+		return false
+	}
+	return true
+}
+
+func (s *server) decodeCityTitle(w http.ResponseWriter, r *http.Request) (string, bool) {
+	var requestValues cityTitleRequest
+	if err := json.NewDecoder(r.Body).Decode(&requestValues); err != nil {
+		s.writeProblem(w, http.StatusBadRequest, "could not decode JSON body: "+err.Error())
+		return "", false
+	}
+	if requestValues.Title == "" {
+		s.writeProblem(w, http.StatusBadRequest, "title is required")
+		return "", false
+	}
+	return requestValues.Title, true
+}
+
+func (s *server) createCityHandler() http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		title, ok := s.decodeCityTitle(w, r)
+		if !ok || !s.checkCityTitle(w, r, 0, title) {
+			return
+		}
+		resp, err := s.placesStore.CreateCity(r.Context(), &places.CreateCityRequest{Title: title})
+		s.writeCityResponse(w, r, http.StatusCreated, resp.GetCity(), err)
+	})
+}
+
+func (s *server) renameCityHandler() http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, ok := s.cityID(w, r)
+		if !ok {
+			return
+		}
+		title, ok := s.decodeCityTitle(w, r)
+		if !ok || !s.checkCityTitle(w, r, id, title) {
+			return
+		}
+		resp, err := s.placesStore.RenameCity(r.Context(), &places.RenameCityRequest{Id: id, Title: title})
+		s.writeCityResponse(w, r, http.StatusOK, resp.GetCity(), err)
+	})
+}
+
+func (s *server) mergeCitiesHandler() http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, ok := s.cityID(w, r)
+		if !ok {
+			return
+		}
+		var requestValues mergeCitiesRequest
+		if err := json.NewDecoder(r.Body).Decode(&requestValues); err != nil {
+			s.writeProblem(w, http.StatusBadRequest, "could not decode JSON body: "+err.Error())
+			return
+		}
+		if requestValues.TargetID == 0 || requestValues.TargetID == id {
+			s.writeProblem(w, http.StatusBadRequest, "target_id should be id of other city")
+			return
+		}
+		resp, err := s.placesStore.MergeCities(r.Context(), &places.MergeCitiesRequest{SourceID: id, TargetID: requestValues.TargetID})
+		s.writeCityResponse(w, r, http.StatusOK, resp.GetCity(), err)
+	})
+}
+
+// archiveCityHandler archives city or restores archived one
+func (s *server) archiveCityHandler(archived bool) http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, ok := s.cityID(w, r)
+		if !ok {
+			return
+		}
+		resp, err := s.placesStore.ArchiveCity(r.Context(), &places.ArchiveCityRequest{Id: id, Archived: archived})
+		s.writeCityResponse(w, r, http.StatusOK, resp.GetCity(), err)
+	})
+}
@@ -1,7 +1,80 @@
 package apiserver
 
+import (
+	"chillit-rest-gateway/internal/app/apikeys"
+	"chillit-rest-gateway/internal/app/audit"
+	"chillit-rest-gateway/internal/app/cache"
+	"chillit-rest-gateway/internal/app/duplicates"
+	"chillit-rest-gateway/internal/app/geo"
+	"chillit-rest-gateway/internal/app/graphqlapi"
+	"chillit-rest-gateway/internal/app/grpcweb"
+	"chillit-rest-gateway/internal/app/history"
+	"chillit-rest-gateway/internal/app/importjobs"
+	"chillit-rest-gateway/internal/app/jwtauth"
+	"chillit-rest-gateway/internal/app/moderation"
+	"chillit-rest-gateway/internal/app/ratelimit"
+	"chillit-rest-gateway/internal/app/search"
+	"chillit-rest-gateway/internal/app/suggest"
+	"chillit-rest-gateway/internal/app/transcoding"
+	"chillit-rest-gateway/internal/app/users"
+	"time"
+)
+
+// Backends of rate limiter and cache
+const (
+	backendLocal = "local"
+	backendRedis = "redis"
+)
+
 // Config for API server
 type Config struct {
-	Hostname       string `yaml:"hostname"`
-	AllowedOrigins string `yaml:"allowed_origins"`
+	Hostname       string            `yaml:"hostname"`
+	AllowedOrigins string            `yaml:"allowed_origins"`
+	RateLimit      *ratelimit.Config `yaml:"rate_limit"`
+	Cache          *cache.Config     `yaml:"cache"`
+	APIKeys        *apikeys.Config   `yaml:"api_keys"`
+	JWT            *jwtauth.Config   `yaml:"jwt"`
+	Users          *users.Config     `yaml:"users"`
+	// Idempotency keeps responses of write requests with Idempotency-Key header,
+	// TTL is replay window, 24h by default
+	Idempotency *cache.Config `yaml:"idempotency"`
+	// Duplicates enables rejection of places similar to existing ones on submission
+	Duplicates *duplicates.Config `yaml:"duplicates"`
+	// Moderation holds places submitted by callers without places:publish scope for review
+	Moderation *moderation.Config `yaml:"moderation"`
+	// History records revisions of places changed through gateway
+	History *history.Config `yaml:"history"`
+	// Audit records every request except GET to rotating JSONL file
+	Audit *audit.Config `yaml:"audit"`
+	// Search enables full-text search over places kept in memory index
+	Search *search.Config `yaml:"search"`
+	// Suggest enables autocomplete of city and place names
+	Suggest *suggest.Config `yaml:"suggest"`
+	// Geo enables search of places near point
+	Geo *geo.Config `yaml:"geo"`
+	// MetricsPath enables metrics endpoint, e.g. "/metrics"
+	MetricsPath string `yaml:"metrics_path"`
+	// ValidateResponses enables debug mode, in which responses are checked
+	// against API document and contract violations are logged
+	ValidateResponses bool `yaml:"validate_responses"`
+	// Versions configures lifecycle of API versions by name, e.g. "v1"
+	Versions map[string]*VersionConfig `yaml:"versions"`
+	// Transcoding exposes places store RPCs as REST routes
+	Transcoding *transcoding.Config `yaml:"transcoding"`
+	// GraphQL enables /graphql endpoint
+	GraphQL *graphqlapi.Config `yaml:"graphql"`
+	// GRPCWeb enables gRPC-Web and Connect proxy of places store
+	GRPCWeb *grpcweb.Config `yaml:"grpc_web"`
+	// Imports enables asynchronous bulk import API under /admin/imports
+	Imports *importjobs.Config `yaml:"imports"`
+}
+
+// VersionConfig announces retirement of API version
+type VersionConfig struct {
+	// Deprecation is when version was or will be deprecated
+	Deprecation time.Time `yaml:"deprecation"`
+	// Sunset is when version stops responding
+	Sunset time.Time `yaml:"sunset"`
+	// Link to migration guide
+	Link string `yaml:"link"`
 }
@@ -0,0 +1,31 @@
+package apiserver
+
+import (
+	"chillit-rest-gateway/internal/app/duplicates"
+	"math"
+	"net/http"
+)
+
+// duplicatePlace is existing place similar to submitted one
+type duplicatePlace struct {
+	responsePlace
+	// Score is similarity from 0 to 1
+	Score float64 `json:"score"`
+}
+
+// writeDuplicates rejects submitted place listing its likely duplicates
+func (s *server) writeDuplicates(w http.ResponseWriter, matches []*duplicates.Match) {
+	p := &problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(http.StatusConflict),
+		Status: http.StatusConflict,
+		Detail: "place looks like duplicate of existing one, repeat request with force=true to add it anyway",
+	}
+	for _, match := range matches {
+		p.Duplicates = append(p.Duplicates, &duplicatePlace{
+			responsePlace: *newResponsePlace(match.Place),
+			Score:         math.Round(match.Score*100) / 100,
+		})
+	}
+	s.writeProblemDetails(w, p)
+}
@@ -0,0 +1,112 @@
+package apiserver
+
+import (
+	"chillit-rest-gateway/internal/app/places"
+	"chillit-rest-gateway/internal/app/render"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// exportPageSize is amount of places requested from places store at once,
+// export keeps only one page in memory
+const exportPageSize = 100
+
+// Formats of export
+const (
+	exportNDJSON = "application/x-ndjson"
+	exportCSV    = "text/csv"
+)
+
+var exportFormats = map[string]string{
+	"ndjson": exportNDJSON,
+	"csv":    exportCSV,
+}
+
+// exportPlacesHandler streams all places of city page by page, flushing every page to client
+func (s *server) exportPlacesHandler() http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cityID, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+		if err != nil || cityID == 0 {
+			s.writeProblem(w, http.StatusBadRequest, "invalid city id")
+			return
+		}
+
+		mediaType, ok := exportFormats[r.URL.Query().Get(formatParam)]
+		if r.URL.Query().Get(formatParam) == "" {
+			mediaType, ok = render.Preferred(r.Header.Get("Accept"), []string{exportNDJSON, exportCSV})
+		}
+		if !ok {
+			s.writeProblem(w, http.StatusNotAcceptable, "export is available as "+exportNDJSON+", "+exportCSV)
+			return
+		}
+
+		ctx := r.Context()
+		flusher, _ := w.(http.Flusher)
+		var csvWriter *csv.Writer
+		var offset uint64
+		for {
+			resp, err := s.placesStore.GetPlacesByCityID(ctx, &places.GetPlacesByCityIDRequest{
+				CityID: cityID,
+				Offset: offset,
+				Amount: exportPageSize,
+			})
+			if err != nil {
+				if offset == 0 {
+					s.logger.Errorf("could not get data from places store, error: %v", err)
+					s.writeProblem(w, http.StatusBadGateway, "could not get places")
+					return
+				}
+				if ctx.Err() == nil {
+					s.logger.Errorf("could not export places of city %d after %d places, error: %v", cityID, offset, err)
+				}
+				// Aborted connection tells client that export is incomplete
+				panic(http.ErrAbortHandler)
+			}
+
+			if offset == 0 {
+				extension := "ndjson"
+				if mediaType == exportCSV {
+					extension = "csv"
+				}
+				w.Header().Set("Content-Type", mediaType)
+				w.Header().Set("Content-Disposition", `attachment; filename="city-`+strconv.FormatUint(cityID, 10)+`-places.`+extension+`"`)
+				if mediaType == exportCSV {
+					csvWriter = csv.NewWriter(w)
+					csvWriter.Write(placesCSVHeader)
+				}
+			}
+
+			page := make([]*responsePlace, len(resp.Places))
+			for i, pbPlace := range resp.Places {
+				page[i] = newResponsePlace(pbPlace)
+			}
+			if csvWriter != nil {
+				csvWriter.WriteAll(placesCSVRows(page))
+				err = csvWriter.Error()
+			} else {
+				encoder := json.NewEncoder(w)
+				for _, place := range page {
+					if err = encoder.Encode(place); err != nil {
+						break
+					}
+				}
+			}
+			if err != nil {
+				// Client went away
+				return
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+
+			offset += uint64(len(resp.Places))
+			if len(resp.Places) < exportPageSize || ctx.Err() != nil {
+				return
+			}
+		}
+	})
+}
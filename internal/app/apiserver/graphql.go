@@ -0,0 +1,24 @@
+package apiserver
+
+import (
+	"chillit-rest-gateway/internal/app/graphqlapi"
+	"encoding/json"
+	"net/http"
+)
+
+// graphqlHandler executes query, errors of query are reported in response body with 200 status
+func (s *server) graphqlHandler() http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req graphqlapi.Request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			s.writeProblem(w, http.StatusBadRequest, "could not decode request body")
+			return
+		}
+		if req.Query == "" {
+			s.writeProblem(w, http.StatusBadRequest, "query is required")
+			return
+		}
+
+		s.writeJSON(w, http.StatusOK, s.graphql.Exec(r.Context(), &req))
+	})
+}
@@ -0,0 +1,51 @@
+package apiserver
+
+import (
+	"chillit-rest-gateway/internal/app/grpcweb"
+	"net/http"
+)
+
+// Headers of gRPC-Web and Connect protocols allowed for browser clients
+const (
+	grpcWebAllowHeaders  = "X-Grpc-Web, X-User-Agent, Grpc-Timeout, Connect-Protocol-Version, Connect-Timeout-Ms"
+	grpcWebExposeHeaders = "Grpc-Status, Grpc-Message"
+)
+
+// configureGRPCWeb registers proxied RPCs at /places.PlacesStore/<RPC>. Calls pass the same middleware
+// as REST routes, reading RPCs are open for anonymous callers, city management RPCs require admin scope.
+// Place writes, creation and renaming of cities are not proxied
+func (s *server) configureGRPCWeb() {
+	if s.grpcWeb == nil {
+		return
+	}
+
+	for _, rpc := range s.grpcWeb.Methods() {
+		if restOnlyRPCs[rpc] {
+			continue
+		}
+		handler := s.ScopeMiddleware(scopeAdmin, false, func(w http.ResponseWriter, r *http.Request) {
+			// Status of call is in trailers, cities are invalidated whether call succeeded or not
+			s.grpcWeb.ServeHTTP(w, r)
+			s.citiesChanged()
+		})
+		if isReadRPC(rpc) {
+			handler = s.ScopeMiddleware(scopeReadPlaces, true, s.grpcWeb.ServeHTTP)
+		}
+		path := grpcweb.Path(rpc)
+		s.router.HandleFunc(path, s.grpcWebCorsMiddleware(s.commonMiddleware(handler))).Methods(http.MethodPost)
+		s.router.HandleFunc(path, s.grpcWebCorsMiddleware(s.CorsMiddleware(preflightHandler))).Methods(http.MethodOptions)
+	}
+}
+
+// grpcWebCorsMiddleware lets browsers send protocol headers and read status headers
+func (s *server) grpcWebCorsMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Access-Control-Allow-Headers", grpcWebAllowHeaders)
+		w.Header().Add("Access-Control-Expose-Headers", grpcWebExposeHeaders)
+		next.ServeHTTP(w, r)
+	})
+}
+
+func preflightHandler(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusNoContent)
+}
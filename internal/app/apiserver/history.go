@@ -0,0 +1,160 @@
+package apiserver
+
+import (
+	"chillit-rest-gateway/internal/app/history"
+	"chillit-rest-gateway/internal/app/places"
+	"encoding/json"
+	"net/http"
+	"strconv"
+)
+
+type historyResponse struct {
+	Revisions []*history.Revision `json:"revisions"`
+}
+
+type rollbackRequest struct {
+	Revision int `json:"revision" validate:"min=1"`
+}
+
+// changedFields returns fields of revisions present in update request
+func (req *updatePlaceRequest) changedFields() []string {
+	changed := []string{}
+	for field, value := range req.fields() {
+		if *value != nil {
+			changed = append(changed, field)
+		}
+	}
+	if req.Latitude != nil && req.Longitude != nil {
+		changed = append(changed, "latitude", "longitude")
+	}
+	return changed
+}
+
+// restoreLocation sets coordinates of target revision to restore request if they differ from
+// current ones. Coordinates are restored together, one unknown at target revision keeps its current value
+func (req *updatePlaceRequest) restoreLocation(target, current map[string]string) {
+	lat, lon := current["latitude"], current["longitude"]
+	if old, ok := target["latitude"]; ok && old != "" {
+		lat = old
+	}
+	if old, ok := target["longitude"]; ok && old != "" {
+		lon = old
+	}
+	if lat == current["latitude"] && lon == current["longitude"] {
+		return
+	}
+	latitude, latErr := strconv.ParseFloat(lat, 64)
+	longitude, lonErr := strconv.ParseFloat(lon, 64)
+	if latErr == nil && lonErr == nil {
+		req.Latitude, req.Longitude = &latitude, &longitude
+	}
+}
+
+// fields returns pointers to fields of request by names of revision fields
+func (req *updatePlaceRequest) fields() map[string]**string {
+	return map[string]**string{
+		"title":       &req.Title,
+		"address":     &req.Address,
+		"description": &req.Description,
+		"image_url":   &req.ImgURL,
+	}
+}
+
+// recordRevision appends revision of place made by caller, change is already done,
+// so failure is only logged
+func (s *server) recordRevision(action, author string, place *places.Place, changed []string) {
+	if s.history == nil {
+		return
+	}
+	if _, err := s.history.Record(action, author, place, changed); err != nil {
+		s.logger.Errorf("could not record revision of place %d, error: %v", place.GetId(), err)
+	}
+}
+
+func (s *server) placeHistoryHandler() http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, ok := s.placeID(w, r)
+		if !ok {
+			return
+		}
+		revisions, err := s.history.List(id)
+		if err != nil {
+			s.logger.Errorf("could not read history of place %d, error: %v", id, err)
+			s.writeProblem(w, http.StatusInternalServerError, "could not read history of place")
+			return
+		}
+		if len(revisions) == 0 {
+			s.writeProblem(w, http.StatusNotFound, "place has no recorded history")
+			return
+		}
+		s.writeResponse(w, r, http.StatusOK, &historyResponse{Revisions: revisions})
+	})
+}
+
+func (s *server) rollbackPlaceHandler() http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, ok := s.placeID(w, r)
+		if !ok {
+			return
+		}
+		var requestValues rollbackRequest
+		if err := json.NewDecoder(r.Body).Decode(&requestValues); err != nil {
+			s.writeProblem(w, http.StatusBadRequest, "could not decode JSON body: "+err.Error())
+			return
+		}
+		target, err := s.history.Snapshot(id, requestValues.Revision)
+		if err == history.ErrNotFound {
+			s.writeProblem(w, http.StatusNotFound, "revision not found")
+			return
+		}
+		if err != nil {
+			s.logger.Errorf("could not read history of place %d, error: %v", id, err)
+			s.writeProblem(w, http.StatusInternalServerError, "could not read history of place")
+			return
+		}
+		stored, err := s.placesStore.GetPlace(r.Context(), &places.GetPlaceRequest{Id: id})
+		if err != nil {
+			s.writePlaceError(w, err)
+			return
+		}
+		current := history.Fields(stored.GetPlace())
+
+		// Only fields differing from place in store are written,
+		// fields unknown at target revision are left as they are
+		var restore updatePlaceRequest
+		for field, value := range restore.fields() {
+			if old, ok := target[field]; ok && old != current[field] {
+				old := old
+				*value = &old
+			}
+		}
+		restore.restoreLocation(target, current)
+		place, mask := restore.toProto(id)
+		if len(mask.Paths) == 0 {
+			s.writeProblem(w, http.StatusConflict, "place already matches revision")
+			return
+		}
+		version, ok := s.ifMatchVersion(w, r)
+		if !ok {
+			return
+		}
+
+		resp, err := s.placesStore.UpdatePlace(r.Context(), &places.UpdatePlaceRequest{
+			Place:      place,
+			UpdateMask: mask,
+			Version:    version,
+		})
+		if err != nil {
+			s.writePlaceError(w, err)
+			return
+		}
+		author := submitterOf(principalFromContext(r.Context()))
+		if _, err := s.history.Rollback(author, resp.GetPlace(), restore.changedFields(), requestValues.Revision); err != nil {
+			s.logger.Errorf("could not record revision of place %d, error: %v", id, err)
+		}
+		s.reindexPlace(resp.GetPlace())
+
+		w.Header().Set("ETag", placeETag(resp.GetPlace().GetVersion()))
+		s.writeResponse(w, r, http.StatusOK, newResponsePlace(resp.GetPlace()))
+	})
+}
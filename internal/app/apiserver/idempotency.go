@@ -0,0 +1,148 @@
+package apiserver
+
+import (
+	"bytes"
+	"chillit-rest-gateway/internal/app/openapi"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+// Idempotency headers
+const (
+	IdempotencyKeyHeader     = "Idempotency-Key"
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+)
+
+const (
+	maxIdempotencyKeyLength = 255
+	// idempotencyPendingTimeout releases key of request interrupted by gateway crash
+	idempotencyPendingTimeout = time.Minute
+)
+
+var idempotencyKeyParameter = &openapi.Parameter{
+	Name:        IdempotencyKeyHeader,
+	In:          "header",
+	Description: "Unique key of request up to 255 characters, repeated request with the same key gets stored response",
+	Schema:      &openapi.Schema{Type: "string"},
+}
+
+// idempotentResponse is response stored by idempotency key. Pending response
+// marks request which is being handled
+type idempotentResponse struct {
+	Fingerprint string `json:"fingerprint"`
+	Pending     bool   `json:"pending,omitempty"`
+	Status      int    `json:"status,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+	Location    string `json:"location,omitempty"`
+	ETag        string `json:"etag,omitempty"`
+	Body        []byte `json:"body,omitempty"`
+}
+
+// IdempotencyMiddleware handles request with Idempotency-Key header once per replay window.
+// Repeated request gets stored response, request reusing key with different method, path, query or body
+// is rejected. Responses of failed requests (5xx) are not stored, so they may be retried
+func (s *server) IdempotencyMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(IdempotencyKeyHeader)
+		if key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			s.writeProblem(w, http.StatusBadRequest, IdempotencyKeyHeader+" is too long")
+			return
+		}
+
+		body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+		if err != nil {
+			s.writeProblem(w, http.StatusRequestEntityTooLarge, "request body is too large")
+			return
+		}
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+		hash := sha256.New()
+		hash.Write([]byte(r.Method + " " + r.URL.Path + "?" + r.URL.RawQuery + "\n"))
+		hash.Write(body)
+		fingerprint := hex.EncodeToString(hash.Sum(nil))
+
+		// Keys of different callers do not collide
+		storeKey := key
+		if p := principalFromContext(r.Context()); p != nil {
+			storeKey = p.Kind + ":" + p.Name + ":" + key
+		}
+
+		pending, _ := json.Marshal(&idempotentResponse{Fingerprint: fingerprint, Pending: true})
+		added, err := s.idempotency.Add(storeKey, pending, idempotencyPendingTimeout)
+		if err != nil {
+			s.logger.Errorf("could not store idempotency key, error: %v", err)
+			next.ServeHTTP(w, r)
+			return
+		}
+		if !added {
+			s.replayIdempotent(w, storeKey, fingerprint)
+			return
+		}
+
+		rec := &responseRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r)
+		if rec.status == 0 || rec.status >= http.StatusInternalServerError {
+			if err := s.idempotency.Delete(storeKey); err != nil {
+				s.logger.Errorf("could not delete idempotency key, error: %v", err)
+			}
+			return
+		}
+
+		data, err := json.Marshal(&idempotentResponse{
+			Fingerprint: fingerprint,
+			Status:      rec.status,
+			ContentType: w.Header().Get("Content-Type"),
+			Location:    w.Header().Get("Location"),
+			ETag:        w.Header().Get("ETag"),
+			Body:        rec.body.Bytes(),
+		})
+		if err == nil {
+			err = s.idempotency.Set(storeKey, data, s.idempotencyTTL)
+		}
+		if err != nil {
+			s.logger.Errorf("could not store idempotent response, error: %v", err)
+		}
+	})
+}
+
+// replayIdempotent writes response stored by key
+func (s *server) replayIdempotent(w http.ResponseWriter, storeKey, fingerprint string) {
+	data, ok, err := s.idempotency.Get(storeKey)
+	if err != nil {
+		s.logger.Errorf("could not read idempotency key, error: %v", err)
+		s.writeProblem(w, http.StatusInternalServerError, "could not read idempotency key")
+		return
+	}
+	var stored idempotentResponse
+	if ok {
+		err = json.Unmarshal(data, &stored)
+	}
+	if ok && err == nil && stored.Fingerprint != fingerprint {
+		s.writeProblem(w, http.StatusUnprocessableEntity, IdempotencyKeyHeader+" was already used for different request")
+		return
+	}
+	if !ok || err != nil || stored.Pending {
+		s.writeProblem(w, http.StatusConflict, "request with the same "+IdempotencyKeyHeader+" is in progress")
+		return
+	}
+
+	if stored.ContentType != "" {
+		w.Header().Set("Content-Type", stored.ContentType)
+	}
+	if stored.Location != "" {
+		w.Header().Set("Location", stored.Location)
+	}
+	if stored.ETag != "" {
+		w.Header().Set("ETag", stored.ETag)
+	}
+	w.Header().Set(IdempotentReplayedHeader, "true")
+	w.WriteHeader(stored.Status)
+	w.Write(stored.Body)
+}
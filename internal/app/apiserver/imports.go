@@ -0,0 +1,141 @@
+package apiserver
+
+import (
+	"chillit-rest-gateway/internal/app/history"
+	"chillit-rest-gateway/internal/app/importer"
+	"chillit-rest-gateway/internal/app/importjobs"
+	"chillit-rest-gateway/internal/app/places"
+	"errors"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// importedPlace records and indexes place added by import job, author of revision is the job
+func (s *server) importedPlace(job *importjobs.Job, cityID uint64, place *places.Place) {
+	s.recordRevision(history.ActionCreate, "import:"+strconv.FormatUint(job.ID, 10), place, nil)
+	s.indexPlace(cityID, place)
+}
+
+// importFormats are formats of uploaded import files by media type
+var importFormats = map[string]string{
+	"text/csv":             importer.FormatCSV,
+	"application/json":     importer.FormatJSON,
+	"application/x-ndjson": importer.FormatNDJSON,
+}
+
+// errNoFilePart is returned for multipart form without "file" field
+var errNoFilePart = errors.New("multipart form has no file field, it is required")
+
+// countingBody counts bytes read from request body, so reading past size limit can be told from malformed body
+type countingBody struct {
+	io.ReadCloser
+	read int64
+}
+
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.read += int64(n)
+	return n, err
+}
+
+// importFile reads uploaded file either from raw body or from "file" field of multipart form
+func importFile(r *http.Request) (format string, data []byte, err error) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "multipart/form-data" {
+		data, err = ioutil.ReadAll(r.Body)
+		return importFormats[mediaType], data, err
+	}
+
+	reader, err := r.MultipartReader()
+	if err != nil {
+		return "", nil, err
+	}
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return "", nil, errNoFilePart
+		}
+		if err != nil {
+			return "", nil, err
+		}
+		if part.FormName() != "file" {
+			continue
+		}
+		partType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+		format, ok := importFormats[partType]
+		if !ok && part.FileName() != "" {
+			format = importer.FormatOf(part.FileName())
+		}
+		data, err = ioutil.ReadAll(part)
+		return format, data, err
+	}
+}
+
+// submitImportHandler queues import of uploaded file
+func (s *server) submitImportHandler() http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		maxSize := s.imports.Config().MaxFileSize
+		body := &countingBody{ReadCloser: http.MaxBytesReader(w, r.Body, maxSize)}
+		r.Body = body
+		format, data, err := importFile(r)
+		if err != nil && body.read >= maxSize {
+			s.writeProblem(w, http.StatusRequestEntityTooLarge, "file is larger than "+strconv.FormatInt(maxSize, 10)+" bytes")
+			return
+		}
+		if err != nil {
+			s.writeProblem(w, http.StatusBadRequest, "could not read uploaded file: "+err.Error())
+			return
+		}
+		if format == "" {
+			s.writeProblem(w, http.StatusUnsupportedMediaType, "file should be text/csv, application/json or application/x-ndjson")
+			return
+		}
+
+		var owner string
+		if p := principalFromContext(r.Context()); p != nil {
+			owner = p.Name
+		}
+		job, err := s.imports.Submit(format, data, owner)
+		if err != nil {
+			if _, ok := err.(*importjobs.FileError); ok {
+				s.writeProblem(w, http.StatusBadRequest, err.Error())
+				return
+			}
+			s.logger.Errorf("could not submit import, error: %v", err)
+			s.writeProblem(w, http.StatusInternalServerError, "could not submit import")
+			return
+		}
+
+		w.Header().Set("Location", strings.TrimSuffix(r.URL.Path, "/")+"/"+strconv.FormatUint(job.ID, 10))
+		s.writeResponse(w, r, http.StatusAccepted, job)
+	})
+}
+
+// getImportHandler reports progress of import job
+func (s *server) getImportHandler() http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+		if err != nil {
+			s.writeProblem(w, http.StatusBadRequest, "invalid import id")
+			return
+		}
+
+		job, err := s.imports.Job(id)
+		if err != nil {
+			s.logger.Errorf("could not get import, error: %v", err)
+			s.writeProblem(w, http.StatusInternalServerError, "could not get import")
+			return
+		}
+		if job == nil {
+			s.writeProblem(w, http.StatusNotFound, "import not found")
+			return
+		}
+		s.writeResponse(w, r, http.StatusOK, job)
+	})
+}
@@ -0,0 +1,44 @@
+package apiserver
+
+import "chillit-rest-gateway/internal/app/places"
+
+// indexPlace adds place written through gateway to search and geo indexes, sync picks it up on failure.
+// Cached listings of places are invalidated too
+func (s *server) indexPlace(cityID uint64, place *places.Place) {
+	s.invalidateCache(cacheScopePlaces)
+	s.syncer.Touch(place.GetId())
+	if s.search != nil {
+		if err := s.search.Put(cityID, place); err != nil {
+			s.logger.Errorf("could not index place %d, error: %v", place.GetId(), err)
+		}
+	}
+	if s.geo != nil {
+		s.geo.Put(cityID, place)
+	}
+}
+
+func (s *server) reindexPlace(place *places.Place) {
+	s.invalidateCache(cacheScopePlaces)
+	s.syncer.Touch(place.GetId())
+	if s.search != nil {
+		if err := s.search.Update(place); err != nil {
+			s.logger.Errorf("could not index place %d, error: %v", place.GetId(), err)
+		}
+	}
+	if s.geo != nil {
+		s.geo.Update(place)
+	}
+}
+
+func (s *server) unindexPlace(id uint64) {
+	s.invalidateCache(cacheScopePlaces)
+	s.syncer.Touch(id)
+	if s.search != nil {
+		if err := s.search.Delete(id); err != nil {
+			s.logger.Errorf("could not remove place %d from search index, error: %v", id, err)
+		}
+	}
+	if s.geo != nil {
+		s.geo.Delete(id)
+	}
+}
@@ -0,0 +1,184 @@
+package apiserver
+
+import (
+	"chillit-rest-gateway/internal/app/cities"
+	"chillit-rest-gateway/internal/app/history"
+	"chillit-rest-gateway/internal/app/moderation"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/gorilla/schema"
+)
+
+type listSubmissionsRequest struct {
+	Status string `schema:"status" validate:"enum=pending|approved|rejected"`
+	Offset int    `schema:"offset" validate:"min=0"`
+	Amount int    `schema:"amount" validate:"min=0,max=100"`
+}
+
+type listSubmissionsResponse struct {
+	Submissions []*moderation.Submission `json:"submissions"`
+}
+
+type rejectSubmissionRequest struct {
+	Reason string `json:"reason" validate:"minlen=1,maxlen=1000"`
+}
+
+// submitterOf identifies caller in submissions, e.g. "user:anna"
+func submitterOf(p *principal) string {
+	if p == nil {
+		return ""
+	}
+	return p.Kind + ":" + p.Name
+}
+
+// needsModeration tells if places of caller go to moderation queue instead of places store
+func (s *server) needsModeration(p *principal) bool {
+	return s.moderation != nil && (p == nil || !p.hasScope(scopePublishPlaces) && !p.hasScope(scopeAdmin))
+}
+
+func (s *server) writeModerationError(w http.ResponseWriter, err error) {
+	switch err {
+	case moderation.ErrNotFound:
+		s.writeProblem(w, http.StatusNotFound, err.Error())
+	case moderation.ErrNotPending:
+		s.writeProblem(w, http.StatusConflict, err.Error())
+	case cities.ErrUnknownCity:
+		s.writeProblem(w, http.StatusUnprocessableEntity, "unknown city, edit city_name of submission")
+	default:
+		s.logger.Errorf("could not moderate submission, error: %v", err)
+		s.writeProblem(w, http.StatusBadGateway, "could not moderate submission")
+	}
+}
+
+func (s *server) submissionID(w http.ResponseWriter, r *http.Request) (uint64, bool) {
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		s.writeProblem(w, http.StatusBadRequest, "invalid submission id")
+		return 0, false
+	}
+	return id, true
+}
+
+func (s *server) listSubmissions(w http.ResponseWriter, r *http.Request, submitter string) {
+	var requestValues listSubmissionsRequest
+	queryDecoder := schema.NewDecoder()
+	queryDecoder.IgnoreUnknownKeys(true)
+	if err := queryDecoder.Decode(&requestValues, r.URL.Query()); err != nil {
+		s.writeProblem(w, http.StatusBadRequest, "could not decode query: "+err.Error())
+		return
+	}
+	if requestValues.Amount == 0 {
+		requestValues.Amount = 100
+	}
+
+	list, err := s.moderation.List(requestValues.Status, submitter, requestValues.Offset, requestValues.Amount)
+	if err != nil {
+		s.logger.Errorf("could not list submissions, error: %v", err)
+		s.writeProblem(w, http.StatusInternalServerError, "could not list submissions")
+		return
+	}
+	s.writeResponse(w, r, http.StatusOK, &listSubmissionsResponse{Submissions: list})
+}
+
+// listSubmissionsHandler lists submissions of all callers for moderators
+func (s *server) listSubmissionsHandler() http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.listSubmissions(w, r, "")
+	})
+}
+
+// mySubmissionsHandler lists submissions of caller, so submitter may follow outcome of moderation
+func (s *server) mySubmissionsHandler() http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p := principalFromContext(r.Context())
+		if p == nil {
+			s.writeProblem(w, http.StatusUnauthorized, "authentication is required")
+			return
+		}
+		s.listSubmissions(w, r, submitterOf(p))
+	})
+}
+
+func (s *server) getSubmissionHandler() http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, ok := s.submissionID(w, r)
+		if !ok {
+			return
+		}
+		submission, err := s.moderation.Get(id)
+		if err != nil {
+			s.writeModerationError(w, err)
+			return
+		}
+		s.writeResponse(w, r, http.StatusOK, submission)
+	})
+}
+
+func (s *server) editSubmissionHandler() http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, ok := s.submissionID(w, r)
+		if !ok {
+			return
+		}
+		var edit moderation.Edit
+		if err := json.NewDecoder(r.Body).Decode(&edit); err != nil {
+			s.writeProblem(w, http.StatusBadRequest, "could not decode JSON body: "+err.Error())
+			return
+		}
+		if (edit.Latitude == nil) != (edit.Longitude == nil) {
+			s.writeProblem(w, http.StatusBadRequest, "latitude and longitude must be given together")
+			return
+		}
+		submission, err := s.moderation.Edit(id, &edit)
+		if err != nil {
+			s.writeModerationError(w, err)
+			return
+		}
+		s.writeResponse(w, r, http.StatusOK, submission)
+	})
+}
+
+func (s *server) approveSubmissionHandler() http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, ok := s.submissionID(w, r)
+		if !ok {
+			return
+		}
+		submission, err := s.moderation.Approve(r.Context(), id, submitterOf(principalFromContext(r.Context())))
+		if err != nil {
+			s.writeModerationError(w, err)
+			return
+		}
+		place := submission.Place()
+		s.recordRevision(history.ActionCreate, submission.Submitter, place, nil)
+		s.indexPlace(submission.CityID, place)
+		s.writeResponse(w, r, http.StatusOK, submission)
+	})
+}
+
+func (s *server) rejectSubmissionHandler() http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, ok := s.submissionID(w, r)
+		if !ok {
+			return
+		}
+		var requestValues rejectSubmissionRequest
+		if err := json.NewDecoder(r.Body).Decode(&requestValues); err != nil {
+			s.writeProblem(w, http.StatusBadRequest, "could not decode JSON body: "+err.Error())
+			return
+		}
+		if requestValues.Reason == "" {
+			s.writeProblem(w, http.StatusBadRequest, "reason is required")
+			return
+		}
+		submission, err := s.moderation.Reject(id, submitterOf(principalFromContext(r.Context())), requestValues.Reason)
+		if err != nil {
+			s.writeModerationError(w, err)
+			return
+		}
+		s.writeResponse(w, r, http.StatusOK, submission)
+	})
+}
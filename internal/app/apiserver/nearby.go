@@ -0,0 +1,70 @@
+package apiserver
+
+import (
+	"chillit-rest-gateway/internal/app/geo"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/schema"
+)
+
+type nearbyPlacesRequest struct {
+	Latitude  float64 `schema:"lat,required" validate:"min=-90,max=90"`
+	Longitude float64 `schema:"lon,required" validate:"min=-180,max=180"`
+	// Radius in meters, 1000 by default
+	Radius float64 `schema:"radius" validate:"min=0"`
+	CityID uint64  `schema:"city_id"`
+	Amount int     `schema:"amount" validate:"min=0,max=100"`
+}
+
+// nearbyPlace is place within radius
+type nearbyPlace struct {
+	responsePlace
+	CityID uint64 `json:"city_id"`
+	// Distance to place in meters
+	Distance float64 `json:"distance"`
+}
+
+type nearbyPlacesResponse struct {
+	Places []*nearbyPlace `json:"places"`
+}
+
+func (s *server) nearbyPlacesHandler() http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var requestValues nearbyPlacesRequest
+		queryDecoder := schema.NewDecoder()
+		queryDecoder.IgnoreUnknownKeys(true)
+		if err := queryDecoder.Decode(&requestValues, r.URL.Query()); err != nil {
+			s.writeProblem(w, http.StatusBadRequest, "could not decode query: "+err.Error())
+			return
+		}
+		if requestValues.Radius == 0 {
+			requestValues.Radius = 1000
+		}
+		if maxRadius := s.geo.Config().MaxRadius; requestValues.Radius > maxRadius {
+			s.writeProblem(w, http.StatusBadRequest, "radius must not exceed "+strconv.FormatFloat(maxRadius, 'f', -1, 64)+" meters")
+			return
+		}
+		if requestValues.Amount == 0 {
+			requestValues.Amount = 20
+		}
+
+		hits := s.geo.Nearby(&geo.Request{
+			Latitude:  requestValues.Latitude,
+			Longitude: requestValues.Longitude,
+			Radius:    requestValues.Radius,
+			CityID:    requestValues.CityID,
+			Limit:     requestValues.Amount,
+		})
+		resp := nearbyPlacesResponse{Places: make([]*nearbyPlace, len(hits))}
+		for i, hit := range hits {
+			resp.Places[i] = &nearbyPlace{
+				responsePlace: *newResponsePlace(hit.Place),
+				CityID:        hit.CityID,
+				Distance:      math.Round(hit.Distance),
+			}
+		}
+		s.writeResponse(w, r, http.StatusOK, &resp)
+	})
+}
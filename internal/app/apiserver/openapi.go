@@ -0,0 +1,89 @@
+package apiserver
+
+import (
+	"chillit-rest-gateway/internal/app/apikeys"
+	"chillit-rest-gateway/internal/app/openapi"
+	"strings"
+)
+
+// Security schemes of OpenAPI document
+const (
+	securityAPIKey  = "apiKey"
+	securityBearer  = "bearerAuth"
+	securitySession = "sessionCookie"
+)
+
+// openAPIDocument documents routes registered in configureRouter
+func (s *server) openAPIDocument() *openapi.Document {
+	g := openapi.NewGenerator("Chillit API", "1.0.0")
+
+	var security []string
+	if s.apiKeys != nil {
+		g.AddSecurityScheme(securityAPIKey, &openapi.SecurityScheme{
+			Type: "apiKey",
+			In:   "header",
+			Name: apikeys.Header,
+		})
+		security = append(security, securityAPIKey)
+	}
+	if s.jwtValidator != nil {
+		g.AddSecurityScheme(securityBearer, &openapi.SecurityScheme{
+			Type:         "http",
+			Scheme:       "bearer",
+			BearerFormat: "JWT",
+		})
+		security = append(security, securityBearer)
+	}
+	if s.users != nil {
+		g.AddSecurityScheme(securitySession, &openapi.SecurityScheme{
+			Type:        "apiKey",
+			In:          "cookie",
+			Name:        s.users.Config().CookieName,
+			Description: "Unsafe requests also require " + CSRFHeader + " header",
+		})
+		security = append(security, securitySession)
+	}
+
+	for _, rt := range s.routes {
+		spec := &openapi.Spec{
+			Method:     rt.Method,
+			Path:       rt.Path,
+			Summary:    rt.Summary,
+			Tags:       rt.Tags,
+			Query:      rt.Query,
+			Body:       rt.Body,
+			Status:     rt.Status,
+			Response:   rt.Response,
+			Parameters: rt.Parameters,
+		}
+		if rt.MediaTypes != nil {
+			spec.MediaTypes = rt.MediaTypes
+		} else if rt.Response != nil {
+			spec.MediaTypes = s.renderer.MediaTypes(rt.Response)
+			spec.Parameters = append(spec.Parameters, &openapi.Parameter{
+				Name:        formatParam,
+				In:          "query",
+				Description: "Response format, overrides Accept header: " + strings.Join(s.renderer.Formats(), ", "),
+				Schema:      &openapi.Schema{Type: "string"},
+			})
+		}
+		for status, response := range rt.Alternatives {
+			if spec.Alternatives == nil {
+				spec.Alternatives = make(map[int]*openapi.Alternative)
+			}
+			spec.Alternatives[status] = &openapi.Alternative{Response: response, MediaTypes: s.renderer.MediaTypes(response)}
+		}
+		if config, ok := s.versions[rt.Version]; ok && !config.Deprecation.IsZero() {
+			spec.Deprecated = true
+		}
+		switch {
+		case rt.Scope != "" && rt.AllowAnonymous:
+			spec.Description = "Open for anonymous callers, authenticated callers require `" + rt.Scope + "` scope"
+		case rt.Scope != "":
+			spec.Description = "Requires `" + rt.Scope + "` scope"
+			spec.Security = security
+		}
+		g.Add(spec)
+	}
+	return g.Document()
+}
@@ -0,0 +1,44 @@
+package apiserver
+
+import (
+	"chillit-rest-gateway/internal/app/openapi"
+	"encoding/json"
+	"net/http"
+)
+
+// problem is RFC 7807 problem details object
+type problem struct {
+	Type   string `json:"type,omitempty"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	Detail string `json:"detail,omitempty"`
+	// Errors lists invalid fields of request
+	Errors []openapi.FieldError `json:"errors,omitempty"`
+	// Duplicates lists existing places similar to submitted one
+	Duplicates []*duplicatePlace `json:"duplicates,omitempty"`
+}
+
+func (s *server) writeProblem(w http.ResponseWriter, status int, detail string) {
+	s.writeProblemDetails(w, &problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+	})
+}
+
+func (s *server) writeProblemDetails(w http.ResponseWriter, p *problem) {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(p.Status)
+	if err := json.NewEncoder(w).Encode(p); err != nil {
+		s.logger.Errorf("could not encode problem, error: %v", err)
+	}
+}
+
+func (s *server) writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		s.logger.Errorf("could not encode response, error: %v", err)
+	}
+}
@@ -0,0 +1,45 @@
+package apiserver
+
+import (
+	"bytes"
+	"chillit-rest-gateway/internal/app/render"
+	"net/http"
+	"strings"
+)
+
+// formatParam selects response format overriding Accept header, e.g. ?format=csv
+const formatParam = "format"
+
+// writeResponse encodes v in format requested by client, 406 if none of requested formats fits v
+func (s *server) writeResponse(w http.ResponseWriter, r *http.Request, status int, v interface{}) {
+	encoder, ok := s.negotiate(w, r, v)
+	if !ok {
+		return
+	}
+	s.writeEncoded(w, encoder, status, v)
+}
+
+// negotiate picks encoder of v requested by client or writes 406, it lets handler refuse request
+// before doing work whose result could not be written
+func (s *server) negotiate(w http.ResponseWriter, r *http.Request, v interface{}) (render.Encoder, bool) {
+	w.Header().Add("Vary", "Accept")
+	encoder, err := s.renderer.Negotiate(r.URL.Query().Get(formatParam), r.Header.Get("Accept"), v)
+	if err != nil {
+		s.writeProblem(w, http.StatusNotAcceptable, "response is available as "+strings.Join(s.renderer.MediaTypes(v), ", "))
+		return nil, false
+	}
+	return encoder, true
+}
+
+// writeEncoded writes v encoded by negotiated encoder
+func (s *server) writeEncoded(w http.ResponseWriter, encoder render.Encoder, status int, v interface{}) {
+	var buf bytes.Buffer
+	if err := encoder.Encode(&buf, v); err != nil {
+		s.logger.Errorf("could not encode response, error: %v", err)
+		s.writeProblem(w, http.StatusInternalServerError, "could not encode response")
+		return
+	}
+	w.Header().Set("Content-Type", encoder.MediaTypes()[0])
+	w.WriteHeader(status)
+	w.Write(buf.Bytes())
+}
@@ -0,0 +1,75 @@
+package apiserver
+
+import (
+	"chillit-rest-gateway/internal/app/search"
+	"net/http"
+
+	"github.com/gorilla/schema"
+)
+
+type searchPlacesRequest struct {
+	Query  string `schema:"q,required" validate:"minlen=1,maxlen=200"`
+	CityID uint64 `schema:"city_id"`
+	Offset uint64 `schema:"offset"`
+	Amount uint64 `schema:"amount" validate:"max=100"`
+}
+
+// foundPlace is place matching search query
+type foundPlace struct {
+	responsePlace
+	CityID uint64  `json:"city_id"`
+	Score  float64 `json:"score"`
+	// Highlights are fragments of title, address and description with matches in <mark> tags
+	Highlights map[string][]string `json:"highlights,omitempty"`
+}
+
+type searchPlacesResponse struct {
+	Total  uint64        `json:"total"`
+	Places []*foundPlace `json:"places"`
+	Page   *pageInfo     `json:"page"`
+}
+
+func (s *server) searchPlacesHandler() http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var requestValues searchPlacesRequest
+		queryDecoder := schema.NewDecoder()
+		queryDecoder.IgnoreUnknownKeys(true)
+		if err := queryDecoder.Decode(&requestValues, r.URL.Query()); err != nil {
+			s.writeProblem(w, http.StatusBadRequest, "could not decode query: "+err.Error())
+			return
+		}
+		if requestValues.Amount == 0 {
+			requestValues.Amount = 20
+		}
+
+		result, err := s.search.Search(r.Context(), &search.Request{
+			Query:  requestValues.Query,
+			CityID: requestValues.CityID,
+			Offset: int(requestValues.Offset),
+			Amount: int(requestValues.Amount),
+		})
+		if err != nil {
+			s.logger.Errorf("could not search places, error: %v", err)
+			s.writeProblem(w, http.StatusInternalServerError, "could not search places")
+			return
+		}
+
+		resp := searchPlacesResponse{
+			Total:  result.Total,
+			Places: make([]*foundPlace, len(result.Hits)),
+			Page:   &pageInfo{Offset: requestValues.Offset, Amount: requestValues.Amount},
+		}
+		if next := requestValues.Offset + uint64(len(result.Hits)); next < result.Total {
+			resp.Page.NextOffset = &next
+		}
+		for i, hit := range result.Hits {
+			resp.Places[i] = &foundPlace{
+				responsePlace: *newResponsePlace(hit.Place),
+				CityID:        hit.CityID,
+				Score:         hit.Score,
+				Highlights:    hit.Highlights,
+			}
+		}
+		s.writeResponse(w, r, http.StatusOK, &resp)
+	})
+}
@@ -1,11 +1,39 @@
 package apiserver
 
 import (
+	"chillit-rest-gateway/internal/app/apikeys"
+	"chillit-rest-gateway/internal/app/audit"
+	"chillit-rest-gateway/internal/app/cache"
+	"chillit-rest-gateway/internal/app/cities"
+	"chillit-rest-gateway/internal/app/duplicates"
+	"chillit-rest-gateway/internal/app/geo"
+	"chillit-rest-gateway/internal/app/graphqlapi"
+	"chillit-rest-gateway/internal/app/grpcweb"
+	"chillit-rest-gateway/internal/app/history"
+	"chillit-rest-gateway/internal/app/importjobs"
+	"chillit-rest-gateway/internal/app/jwtauth"
+	"chillit-rest-gateway/internal/app/metrics"
+	"chillit-rest-gateway/internal/app/moderation"
+	"chillit-rest-gateway/internal/app/openapi"
 	"chillit-rest-gateway/internal/app/places"
+	"chillit-rest-gateway/internal/app/placesync"
+	"chillit-rest-gateway/internal/app/ratelimit"
+	"chillit-rest-gateway/internal/app/render"
+	"chillit-rest-gateway/internal/app/search"
+	"chillit-rest-gateway/internal/app/suggest"
+	"chillit-rest-gateway/internal/app/transcoding"
+	"chillit-rest-gateway/internal/app/users"
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
 	"net/http"
+	"strconv"
+	"time"
 
+	"github.com/go-redis/redis/v7"
+	"github.com/golang/protobuf/proto"
 	"github.com/gorilla/mux"
 	"github.com/gorilla/schema"
 	"github.com/sirupsen/logrus"
@@ -16,22 +44,688 @@ type server struct {
 	placesStore    places.PlacesStoreClient
 	router         *mux.Router
 	allowedOrigins string
+	limiter        *ratelimit.Limiter
+	buckets        ratelimit.Store
+	apiKeys        *apikeys.Registry
+	jwtValidator   *jwtauth.Validator
+	users          *users.Store
+	cities         *cities.Resolver
+	duplicates     *duplicates.Detector
+	moderation     *moderation.Queue
+	history        *history.Store
+	audit          *audit.Auditor
+	search         *search.Index
+	suggest        *suggest.Index
+	geo            *geo.Index
+	// syncer reloads search, suggest and geo indexes from places store
+	syncer      *placesync.Syncer
+	renderer    *render.Registry
+	metricsPath string
+	routes      []*route
+	// preflightPaths are paths of routes with registered CORS preflight
+	preflightPaths map[string]bool
+	apiDoc         *openapi.Document
+	// validateResponses enables checking of responses against API document
+	validateResponses bool
+	versions          map[string]*VersionConfig
+	transcoded        []*transcoding.Endpoint
+	graphql           *graphqlapi.Executor
+	grpcWeb           *grpcweb.Proxy
+	imports           *importjobs.Manager
+	cache             cache.Store
+	cacheTTL          time.Duration
+	idempotency       cache.Store
+	idempotencyTTL    time.Duration
 }
 
-func newServer(placesStore places.PlacesStoreClient, allowedOrigins string) *server {
+// storeRetryDelay is how long Redis backed stores work locally after Redis failure
+const storeRetryDelay = 10 * time.Second
+
+func newServer(config *Config, placesStore places.PlacesStoreClient, redisClient *redis.Client) (*server, error) {
 	s := &server{
 		logger:         logrus.New(),
 		router:         mux.NewRouter(),
 		placesStore:    placesStore,
-		allowedOrigins: allowedOrigins,
+		allowedOrigins: config.AllowedOrigins,
+		renderer:       render.NewRegistry(),
+		cities:         cities.NewCachedResolver(placesStore, cityIndexTTL),
+	}
+
+	// Buckets of rate limiter and API key quotas share backend
+	bucketsBackend := backendLocal
+	if config.RateLimit != nil && config.RateLimit.Backend != "" {
+		bucketsBackend = config.RateLimit.Backend
+	}
+	switch bucketsBackend {
+	case backendLocal:
+		s.buckets = ratelimit.NewMemoryStore()
+	case backendRedis:
+		if redisClient == nil {
+			return nil, errors.New("rate limit backend is redis, but redis is not configured")
+		}
+		s.buckets = ratelimit.NewFallbackStore(ratelimit.NewRedisStore(redisClient), ratelimit.NewMemoryStore(), storeRetryDelay, s.logger)
+	default:
+		return nil, fmt.Errorf("unknown rate limit backend '%s'", bucketsBackend)
+	}
+
+	if config.RateLimit != nil {
+		limiter, err := ratelimit.NewLimiter(config.RateLimit, s.buckets)
+		if err != nil {
+			return nil, err
+		}
+		s.limiter = limiter
+	}
+
+	if config.APIKeys != nil {
+		registry, err := apikeys.NewRegistry(config.APIKeys)
+		if err != nil {
+			return nil, err
+		}
+		s.apiKeys = registry
+		if s.limiter != nil {
+			s.limiter.KnownKeys(func(key string) bool {
+				_, ok := registry.Lookup(key)
+				return ok
+			})
+		}
+	}
+
+	if config.JWT != nil {
+		validator, err := jwtauth.NewValidator(config.JWT)
+		if err != nil {
+			return nil, err
+		}
+		s.jwtValidator = validator
 	}
+
+	if config.Users != nil {
+		store, err := users.NewStore(config.Users)
+		if err != nil {
+			return nil, err
+		}
+		if err := store.DeleteExpiredSessions(); err != nil {
+			s.logger.Warnf("could not delete expired sessions, error: %v", err)
+		}
+		s.users = store
+	}
+
+	if config.Duplicates != nil {
+		detector, err := duplicates.NewDetector(config.Duplicates, placesStore)
+		if err != nil {
+			return nil, err
+		}
+		s.duplicates = detector
+	}
+
+	if config.Moderation != nil {
+		queue, err := moderation.NewQueue(config.Moderation, placesStore, s.logger)
+		if err != nil {
+			return nil, err
+		}
+		s.moderation = queue
+	}
+
+	if config.History != nil {
+		store, err := history.NewStore(config.History)
+		if err != nil {
+			return nil, err
+		}
+		s.history = store
+	}
+
+	if config.Audit != nil {
+		auditor, err := audit.NewAuditor(config.Audit, nil)
+		if err != nil {
+			return nil, err
+		}
+		s.audit = auditor
+	}
+
+	s.syncer = placesync.NewSyncer(placesStore, s.logger)
+	if config.Search != nil {
+		index, err := search.NewIndex(config.Search)
+		if err != nil {
+			return nil, err
+		}
+		s.search = index
+		s.syncer.Add(index, config.Search.SyncInterval)
+	}
+
+	if config.Suggest != nil {
+		index, err := suggest.NewIndex(config.Suggest)
+		if err != nil {
+			return nil, err
+		}
+		s.suggest = index
+		s.syncer.Add(index, config.Suggest.RefreshInterval)
+	}
+
+	if config.Geo != nil {
+		index, err := geo.NewIndex(config.Geo)
+		if err != nil {
+			return nil, err
+		}
+		s.geo = index
+		s.syncer.Add(index, config.Geo.SyncInterval)
+	}
+	s.syncer.Start()
+
+	if config.Transcoding != nil {
+		endpoints, err := transcoding.NewEndpoints(config.Transcoding, placesStore)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkTranscoding(endpoints); err != nil {
+			return nil, err
+		}
+		s.transcoded = endpoints
+	}
+	if config.GraphQL != nil {
+		executor, err := graphqlapi.NewExecutor(config.GraphQL, placesStore)
+		if err != nil {
+			return nil, err
+		}
+		s.graphql = executor
+	}
+	if config.GRPCWeb != nil {
+		proxy, err := grpcweb.NewProxy(config.GRPCWeb, placesStore, s.logger)
+		if err != nil {
+			return nil, err
+		}
+		s.grpcWeb = proxy
+	}
+	if config.Imports != nil {
+		manager, err := importjobs.NewManager(config.Imports, placesStore, s.importedPlace, s.logger)
+		if err != nil {
+			return nil, err
+		}
+		s.imports = manager
+	}
+	s.metricsPath = config.MetricsPath
+	s.validateResponses = config.ValidateResponses
+	s.versions = config.Versions
+
+	if config.Cache != nil {
+		store, err := s.newCacheStore(config.Cache, "chillit:cache:", redisClient)
+		if err != nil {
+			return nil, err
+		}
+		s.cache = store
+		s.cacheTTL = config.Cache.TTL
+		if s.cacheTTL <= 0 {
+			s.cacheTTL = 30 * time.Second
+		}
+	}
+	if config.Idempotency != nil {
+		store, err := s.newCacheStore(config.Idempotency, "chillit:idempotency:", redisClient)
+		if err != nil {
+			return nil, err
+		}
+		s.idempotency = store
+		s.idempotencyTTL = config.Idempotency.TTL
+		if s.idempotencyTTL <= 0 {
+			s.idempotencyTTL = 24 * time.Hour
+		}
+	}
+
 	s.configureRouter()
-	return s
+	return s, nil
+}
+
+// newCacheStore creates store of configured backend, Redis keys are prefixed with prefix
+func (s *server) newCacheStore(config *cache.Config, prefix string, redisClient *redis.Client) (cache.Store, error) {
+	switch config.Backend {
+	case "", backendLocal:
+		return cache.NewMemoryStore(config.MaxEntries), nil
+	case backendRedis:
+		if redisClient == nil {
+			return nil, errors.New("cache backend is redis, but redis is not configured")
+		}
+		return cache.NewFallbackStore(cache.NewRedisStore(redisClient, prefix), cache.NewMemoryStore(config.MaxEntries), storeRetryDelay, s.logger), nil
+	}
+	return nil, fmt.Errorf("unknown cache backend '%s'", config.Backend)
 }
 
 func (s *server) configureRouter() {
-	s.router.HandleFunc("/places", s.CorsMiddleware(s.getPlacesHandler())).Methods(http.MethodGet)
-	s.router.HandleFunc("/cities", s.CorsMiddleware(s.getCitiesHandler())).Methods(http.MethodGet)
+	s.handle(&route{
+		Versions:       []string{apiV1},
+		Method:         http.MethodGet,
+		Path:           "/places",
+		Summary:        "List places of city",
+		Tags:           []string{"places"},
+		Scope:          scopeReadPlaces,
+		AllowAnonymous: true,
+		Query:          getPlacesRequest{},
+		Response:       getPlacesResponse{},
+	}, s.CacheMiddleware(cacheScopePlaces, s.getPlacesHandler()))
+	s.handle(&route{
+		Versions:       []string{apiV2},
+		Method:         http.MethodGet,
+		Path:           "/places",
+		Summary:        "List places of city with pagination",
+		Tags:           []string{"places"},
+		Scope:          scopeReadPlaces,
+		AllowAnonymous: true,
+		Query:          getPlacesRequest{},
+		Response:       getPlacesV2Response{},
+	}, s.CacheMiddleware(cacheScopePlaces, s.getPlacesV2Handler()))
+	if s.search != nil {
+		s.handle(&route{
+			Method:         http.MethodGet,
+			Path:           "/places/search",
+			Summary:        "Search places by words of title, address and description, most relevant first",
+			Tags:           []string{"places"},
+			Scope:          scopeReadPlaces,
+			AllowAnonymous: true,
+			Query:          searchPlacesRequest{},
+			Response:       searchPlacesResponse{},
+		}, s.searchPlacesHandler())
+	}
+	if s.geo != nil {
+		s.handle(&route{
+			Method:         http.MethodGet,
+			Path:           "/places/nearby",
+			Summary:        "Places within radius of point, nearest first",
+			Tags:           []string{"places"},
+			Scope:          scopeReadPlaces,
+			AllowAnonymous: true,
+			Query:          nearbyPlacesRequest{},
+			Response:       nearbyPlacesResponse{},
+		}, s.nearbyPlacesHandler())
+	}
+	if s.suggest != nil {
+		s.handle(&route{
+			Method:         http.MethodGet,
+			Path:           "/suggest",
+			Summary:        "Suggest names of cities and places starting with query, tolerating typos",
+			Tags:           []string{"places", "cities"},
+			Scope:          scopeReadPlaces,
+			AllowAnonymous: true,
+			Query:          suggestRequest{},
+			Response:       suggestResponse{},
+		}, s.suggestHandler())
+	}
+	addPlaceRoute := &route{
+		Method:     http.MethodPost,
+		Path:       "/places",
+		Summary:    "Add place",
+		Tags:       []string{"places"},
+		Scope:      scopeAddPlaces,
+		Idempotent: true,
+		Query:      addPlaceQuery{},
+		Body:       addPlaceRequest{},
+		Status:     http.StatusCreated,
+		Response:   addPlaceResponse{},
+	}
+	if s.moderation != nil {
+		// Places of callers without trusted scope are queued for review
+		addPlaceRoute.Alternatives = map[int]interface{}{http.StatusAccepted: moderation.Submission{}}
+	}
+	s.handle(addPlaceRoute, s.addPlaceHandler())
+	s.handle(&route{
+		Method:     http.MethodPatch,
+		Path:       "/places/{id}",
+		Summary:    "Update fields of place, requires If-Match header with ETag of place",
+		Tags:       []string{"places"},
+		Scope:      scopeEditPlaces,
+		Idempotent: true,
+		Body:       updatePlaceRequest{},
+		Response:   responsePlace{},
+		Parameters: []*openapi.Parameter{ifMatchParameter},
+	}, s.updatePlaceHandler())
+	s.handle(&route{
+		Method:     http.MethodDelete,
+		Path:       "/places/{id}",
+		Summary:    "Delete place, requires If-Match header with ETag of place",
+		Tags:       []string{"places"},
+		Scope:      scopeDeletePlaces,
+		Status:     http.StatusNoContent,
+		Parameters: []*openapi.Parameter{ifMatchParameter},
+	}, s.deletePlaceHandler())
+	if s.history != nil {
+		s.handle(&route{
+			Method:   http.MethodGet,
+			Path:     "/places/{id}/history",
+			Summary:  "Revisions of place made through gateway, oldest first",
+			Tags:     []string{"places"},
+			Scope:    scopeEditPlaces,
+			Response: historyResponse{},
+		}, s.placeHistoryHandler())
+		s.handle(&route{
+			Method:     http.MethodPost,
+			Path:       "/places/{id}/rollback",
+			Summary:    "Restore fields of place to revision, requires If-Match header with ETag of place",
+			Tags:       []string{"places"},
+			Scope:      scopeAdmin,
+			Idempotent: true,
+			Body:       rollbackRequest{},
+			Response:   responsePlace{},
+			Parameters: []*openapi.Parameter{ifMatchParameter},
+		}, s.rollbackPlaceHandler())
+	}
+
+	s.handle(&route{
+		Versions:       []string{apiV1},
+		Method:         http.MethodGet,
+		Path:           "/cities",
+		Summary:        "List cities",
+		Tags:           []string{"cities"},
+		Scope:          scopeReadPlaces,
+		AllowAnonymous: true,
+		Query:          getCitiesRequest{},
+		Response:       getCitiesResponse{},
+	}, s.CacheMiddleware(cacheScopeCities, s.getCitiesHandler()))
+	s.handle(&route{
+		Versions:       []string{apiV2},
+		Method:         http.MethodGet,
+		Path:           "/cities",
+		Summary:        "List cities with pagination",
+		Tags:           []string{"cities"},
+		Scope:          scopeReadPlaces,
+		AllowAnonymous: true,
+		Query:          getCitiesRequest{},
+		Response:       getCitiesV2Response{},
+	}, s.CacheMiddleware(cacheScopeCities, s.getCitiesV2Handler()))
+
+	s.handle(&route{
+		Method:         http.MethodGet,
+		Path:           "/cities/{id}/places/export",
+		Summary:        "Export all places of city",
+		Tags:           []string{"places"},
+		Scope:          scopeReadPlaces,
+		AllowAnonymous: true,
+		MediaTypes:     []string{exportNDJSON, exportCSV},
+		Parameters: []*openapi.Parameter{{
+			Name:        formatParam,
+			In:          "query",
+			Description: "Export format, overrides Accept header: ndjson, csv",
+			Schema:      &openapi.Schema{Type: "string"},
+		}},
+	}, s.exportPlacesHandler())
+
+	if s.users != nil {
+		s.handle(&route{
+			Method:   http.MethodPost,
+			Path:     "/auth/register",
+			Summary:  "Register user",
+			Tags:     []string{"account"},
+			Body:     credentialsRequest{},
+			Status:   http.StatusCreated,
+			Response: accountResponse{},
+		}, s.registerHandler())
+		s.handle(&route{
+			Method:   http.MethodPost,
+			Path:     "/auth/login",
+			Summary:  "Log in and start session",
+			Tags:     []string{"account"},
+			Body:     credentialsRequest{},
+			Response: accountResponse{},
+		}, s.loginHandler())
+		s.handle(&route{
+			Method:  http.MethodPost,
+			Path:    "/auth/logout",
+			Summary: "End session",
+			Tags:    []string{"account"},
+			Status:  http.StatusNoContent,
+		}, s.logoutHandler())
+	}
+	s.handle(&route{
+		Method:   http.MethodGet,
+		Path:     "/me",
+		Summary:  "Current user",
+		Tags:     []string{"account"},
+		Response: accountResponse{},
+	}, s.meHandler())
+
+	s.configureTranscoding()
+
+	if s.graphql != nil {
+		s.handle(&route{
+			Versions:       []string{apiV1},
+			Method:         http.MethodPost,
+			Path:           "/graphql",
+			Summary:        "GraphQL query over cities and places",
+			Tags:           []string{"graphql"},
+			Scope:          scopeReadPlaces,
+			AllowAnonymous: true,
+			Body:           graphqlapi.Request{},
+		}, s.graphqlHandler())
+	}
+
+	s.configureGRPCWeb()
+
+	s.handle(&route{
+		Versions: []string{apiV1},
+		Method:   http.MethodPost,
+		Path:     "/admin/cities",
+		Summary:  "Create city",
+		Tags:     []string{"admin"},
+		Scope:    scopeAdmin,
+		Body:     cityTitleRequest{},
+		Status:   http.StatusCreated,
+		Response: adminCityResponse{},
+	}, s.createCityHandler())
+	s.handle(&route{
+		Versions: []string{apiV1},
+		Method:   http.MethodPatch,
+		Path:     "/admin/cities/{id}",
+		Summary:  "Rename city",
+		Tags:     []string{"admin"},
+		Scope:    scopeAdmin,
+		Body:     cityTitleRequest{},
+		Response: adminCityResponse{},
+	}, s.renameCityHandler())
+	s.handle(&route{
+		Versions: []string{apiV1},
+		Method:   http.MethodPost,
+		Path:     "/admin/cities/{id}/merge",
+		Summary:  "Move places of city to target city and delete city",
+		Tags:     []string{"admin"},
+		Scope:    scopeAdmin,
+		Body:     mergeCitiesRequest{},
+		Response: adminCityResponse{},
+	}, s.mergeCitiesHandler())
+	s.handle(&route{
+		Versions: []string{apiV1},
+		Method:   http.MethodPost,
+		Path:     "/admin/cities/{id}/archive",
+		Summary:  "Archive city, it is hidden and does not accept new places",
+		Tags:     []string{"admin"},
+		Scope:    scopeAdmin,
+		Response: adminCityResponse{},
+	}, s.archiveCityHandler(true))
+	s.handle(&route{
+		Versions: []string{apiV1},
+		Method:   http.MethodPost,
+		Path:     "/admin/cities/{id}/restore",
+		Summary:  "Restore archived city",
+		Tags:     []string{"admin"},
+		Scope:    scopeAdmin,
+		Response: adminCityResponse{},
+	}, s.archiveCityHandler(false))
+
+	if s.moderation != nil {
+		s.handle(&route{
+			Versions: []string{apiV1},
+			Method:   http.MethodGet,
+			Path:     "/admin/submissions",
+			Summary:  "List places submitted for moderation",
+			Tags:     []string{"admin"},
+			Scope:    scopeAdmin,
+			Query:    listSubmissionsRequest{},
+			Response: listSubmissionsResponse{},
+		}, s.listSubmissionsHandler())
+		s.handle(&route{
+			Versions: []string{apiV1},
+			Method:   http.MethodGet,
+			Path:     "/admin/submissions/{id}",
+			Summary:  "Submitted place",
+			Tags:     []string{"admin"},
+			Scope:    scopeAdmin,
+			Response: moderation.Submission{},
+		}, s.getSubmissionHandler())
+		s.handle(&route{
+			Versions: []string{apiV1},
+			Method:   http.MethodPatch,
+			Path:     "/admin/submissions/{id}",
+			Summary:  "Edit pending place",
+			Tags:     []string{"admin"},
+			Scope:    scopeAdmin,
+			Body:     moderation.Edit{},
+			Response: moderation.Submission{},
+		}, s.editSubmissionHandler())
+		s.handle(&route{
+			Versions: []string{apiV1},
+			Method:   http.MethodPost,
+			Path:     "/admin/submissions/{id}/approve",
+			Summary:  "Approve pending place and add it to places store",
+			Tags:     []string{"admin"},
+			Scope:    scopeAdmin,
+			Response: moderation.Submission{},
+		}, s.approveSubmissionHandler())
+		s.handle(&route{
+			Versions: []string{apiV1},
+			Method:   http.MethodPost,
+			Path:     "/admin/submissions/{id}/reject",
+			Summary:  "Reject pending place with reason",
+			Tags:     []string{"admin"},
+			Scope:    scopeAdmin,
+			Body:     rejectSubmissionRequest{},
+			Response: moderation.Submission{},
+		}, s.rejectSubmissionHandler())
+		s.handle(&route{
+			Versions: []string{apiV1},
+			Method:   http.MethodGet,
+			Path:     "/me/submissions",
+			Summary:  "Places submitted by caller and outcome of their moderation",
+			Tags:     []string{"account"},
+			Query:    listSubmissionsRequest{},
+			Response: listSubmissionsResponse{},
+		}, s.mySubmissionsHandler())
+	}
+
+	if s.imports != nil {
+		s.handle(&route{
+			Versions: []string{apiV1},
+			Method:   http.MethodPost,
+			Path:     "/admin/imports",
+			Summary:  "Submit bulk import of places from CSV, JSON or NDJSON file",
+			Tags:     []string{"admin"},
+			Scope:    scopeAdmin,
+			Status:   http.StatusAccepted,
+			Response: importjobs.Job{},
+		}, s.submitImportHandler())
+		s.handle(&route{
+			Versions: []string{apiV1},
+			Method:   http.MethodGet,
+			Path:     "/admin/imports/{id}",
+			Summary:  "Progress and errors of bulk import",
+			Tags:     []string{"admin"},
+			Scope:    scopeAdmin,
+			Response: importjobs.Job{},
+		}, s.getImportHandler())
+	}
+	if s.audit != nil {
+		s.handle(&route{
+			Versions: []string{apiV1},
+			Method:   http.MethodGet,
+			Path:     "/admin/audit",
+			Summary:  "Audit log of requests changing data, newest first",
+			Tags:     []string{"admin"},
+			Scope:    scopeAdmin,
+			Query:    auditRequest{},
+			Response: auditResponse{},
+		}, s.auditHandler())
+	}
+
+	if s.metricsPath != "" {
+		s.router.Handle(s.metricsPath, metrics.Handler()).Methods(http.MethodGet)
+	}
+
+	s.apiDoc = s.openAPIDocument()
+	s.router.Handle("/openapi.json", openapi.Handler(s.apiDoc)).Methods(http.MethodGet)
+	s.router.Handle("/docs", openapi.SwaggerUIHandler("Chillit API", "/openapi.json")).Methods(http.MethodGet)
+}
+
+// route describes API route, its access requirements and shapes of request and response
+type route struct {
+	// Versions of API serving route, all versions if empty
+	Versions []string
+	// Version and Alias are set on registration, alias is unversioned path of route
+	Version string
+	Alias   bool
+	Method  string
+	Path    string
+	Summary string
+	Tags    []string
+	// Scope required from caller, empty for routes open to everyone
+	Scope          string
+	AllowAnonymous bool
+	// Idempotent routes replay stored response of request repeated with Idempotency-Key header
+	Idempotent bool
+	// Query, Body and Response are zero values of structs decoded from query,
+	// decoded from JSON body and encoded to JSON response
+	Query    interface{}
+	Body     interface{}
+	Status   int
+	Response interface{}
+	// Parameters are documented in addition to fields of Query
+	Parameters []*openapi.Parameter
+	// MediaTypes of response written by handler itself, negotiated formats of Response by default
+	MediaTypes []string
+	// Alternatives are zero values of other successful responses by status, written in negotiated formats
+	Alternatives map[int]interface{}
+}
+
+// handle registers route in each of its API versions with common middlewares,
+// scope requirement and validation. Routes of v1 are also registered at unversioned paths
+func (s *server) handle(rt *route, handler http.HandlerFunc) {
+	handler = s.ValidationMiddleware(handler)
+	if rt.Idempotent && s.idempotency != nil {
+		handler = s.IdempotencyMiddleware(handler)
+		rt.Parameters = append(rt.Parameters[:len(rt.Parameters):len(rt.Parameters)], idempotencyKeyParameter)
+	}
+	if rt.Scope != "" {
+		handler = s.ScopeMiddleware(rt.Scope, rt.AllowAnonymous, handler)
+	}
+
+	versions := rt.Versions
+	if len(versions) == 0 {
+		versions = apiVersions
+	}
+	for _, version := range versions {
+		versioned := *rt
+		versioned.Version = version
+		versioned.Path = "/" + version + rt.Path
+		s.register(&versioned, handler)
+
+		if version == apiV1 {
+			alias := *rt
+			alias.Version = version
+			alias.Alias = true
+			s.register(&alias, handler)
+		}
+	}
+}
+
+func (s *server) register(rt *route, handler http.HandlerFunc) {
+	s.routes = append(s.routes, rt)
+	s.router.HandleFunc(rt.Path, s.VersionMiddleware(rt.Version, rt.Alias, s.commonMiddleware(handler))).Methods(rt.Method)
+
+	// Browsers send preflight before cross-origin PATCH, DELETE and requests with credentials headers,
+	// it is answered once per path whatever methods are registered there
+	if s.preflightPaths == nil {
+		s.preflightPaths = make(map[string]bool)
+	}
+	if !s.preflightPaths[rt.Path] {
+		s.preflightPaths[rt.Path] = true
+		s.router.HandleFunc(rt.Path, s.CorsMiddleware(preflightHandler)).Methods(http.MethodOptions)
+	}
+}
+
+// commonMiddleware wraps handler with middlewares shared by all API routes
+func (s *server) commonMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return s.CorsMiddleware(s.AuditMiddleware(s.RateLimitMiddleware(s.APIKeyMiddleware(s.JWTMiddleware(s.SessionMiddleware(next))))))
 }
 
 func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
@@ -41,35 +735,149 @@ func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 func (s *server) CorsMiddleware(next http.HandlerFunc) http.HandlerFunc {
 	// TODO: separate origin url in a config
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Add("Access-Control-Allow-Headers", "Content-Type, Origin")
+		w.Header().Add("Access-Control-Allow-Headers", "Content-Type, Origin, Authorization, "+apikeys.Header+", "+CSRFHeader+", "+IdempotencyKeyHeader+", If-Match")
+		w.Header().Add("Access-Control-Expose-Headers", "ETag, Location")
 		w.Header().Add("Access-Control-Allow-Origin", s.allowedOrigins)
 		w.Header().Add("Access-Control-Allow-Credentials", "true")
-		w.Header().Add("Access-Control-Allow-Methods", "POST, GET")
+		w.Header().Add("Access-Control-Allow-Methods", "POST, GET, PATCH, DELETE")
 		next.ServeHTTP(w, r)
 	})
 }
 
-func (s *server) getPlacesHandler() http.HandlerFunc {
-	type request struct {
-		Offset uint64 `schema:"offset"`
-		Amount uint64 `schema:"amount"`
-		CityID uint64 `schema:"city_id"`
+// RateLimitMiddleware applies rate limit policy of matched route
+func (s *server) RateLimitMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.limiter == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		var routeTemplate string
+		if route := mux.CurrentRoute(r); route != nil {
+			routeTemplate, _ = route.GetPathTemplate()
+		}
+		policy := s.limiter.Policy(unversionedPath(routeTemplate))
+		if policy == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		result, err := s.limiter.Take(policy, r)
+		if err != nil {
+			s.logger.Errorf("could not apply rate limit, error: %v", err)
+			next.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Set("RateLimit-Limit", strconv.FormatUint(result.Limit, 10))
+		w.Header().Set("RateLimit-Remaining", strconv.FormatUint(result.Remaining, 10))
+		w.Header().Set("RateLimit-Reset", strconv.FormatInt(ceilSeconds(result.Reset), 10))
+		w.Header().Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d;burst=%d", policy.Requests, ceilSeconds(policy.Period), policy.Burst))
+
+		if !result.Allowed {
+			w.Header().Set("Retry-After", strconv.FormatInt(ceilSeconds(result.RetryAfter), 10))
+			s.writeProblem(w, http.StatusTooManyRequests, "rate limit exceeded, retry later")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func ceilSeconds(d time.Duration) int64 {
+	return int64(math.Ceil(d.Seconds()))
+}
+
+type getPlacesRequest struct {
+	Offset uint64 `schema:"offset"`
+	Amount uint64 `schema:"amount" validate:"max=100"`
+	CityID uint64 `schema:"city_id,required" validate:"min=1"`
+}
+
+type responsePlace struct {
+	ID          uint64 `json:"id"`
+	Title       string `json:"title"`
+	Address     string `json:"address"`
+	Description string `json:"description"`
+	ImgURL      string `json:"image_url"`
+	// Version changes on every update, it is ETag of place for If-Match header
+	Version uint64 `json:"version"`
+	// Latitude and Longitude are omitted for places without known coordinates
+	Latitude  *float64 `json:"latitude,omitempty"`
+	Longitude *float64 `json:"longitude,omitempty"`
+}
+
+func newResponsePlace(pbPlace *places.Place) *responsePlace {
+	p := &responsePlace{
+		ID:          pbPlace.GetId(),
+		Title:       pbPlace.GetTitle(),
+		Address:     pbPlace.GetAddress(),
+		Description: pbPlace.GetDescription(),
+		ImgURL:      pbPlace.GetImgURL(),
+		Version:     pbPlace.GetVersion(),
+	}
+	if location := pbPlace.GetLocation(); location != nil {
+		lat, lon := location.GetLatitude(), location.GetLongitude()
+		p.Latitude, p.Longitude = &lat, &lon
+	}
+	return p
+}
+
+func (p *responsePlace) toProto() *places.Place {
+	return &places.Place{
+		Id:          p.ID,
+		Title:       p.Title,
+		Address:     p.Address,
+		Description: p.Description,
+		ImgURL:      p.ImgURL,
+		Version:     p.Version,
+		Location:    newLocation(p.Latitude, p.Longitude),
+	}
+}
+
+// newLocation returns location of place, nil unless both coordinates are set
+func newLocation(lat, lon *float64) *places.Location {
+	if lat == nil || lon == nil {
+		return nil
 	}
+	return &places.Location{Latitude: *lat, Longitude: *lon}
+}
 
-	type responsePlace struct {
-		ID          uint64 `json:"id"`
-		Title       string `json:"title"`
-		Address     string `json:"address"`
-		Description string `json:"description"`
-		ImgURL      string `json:"image_url"`
+var placesCSVHeader = []string{"id", "title", "address", "description", "image_url", "latitude", "longitude"}
+
+func placesCSVRows(list []*responsePlace) [][]string {
+	rows := make([][]string, len(list))
+	for i, p := range list {
+		rows[i] = []string{strconv.FormatUint(p.ID, 10), p.Title, p.Address, p.Description, p.ImgURL, csvCoordinate(p.Latitude), csvCoordinate(p.Longitude)}
 	}
+	return rows
+}
 
-	type response struct {
-		Places []*responsePlace `json:"places"`
+// csvCoordinate formats coordinate, empty for places without location
+func csvCoordinate(value *float64) string {
+	if value == nil {
+		return ""
 	}
+	return strconv.FormatFloat(*value, 'f', -1, 64)
+}
+
+type getPlacesResponse struct {
+	Places []*responsePlace `json:"places"`
+}
 
+func (resp getPlacesResponse) ToProto() proto.Message {
+	msg := &places.GetPlacesByCityIDResponse{Places: make([]*places.Place, len(resp.Places))}
+	for i, p := range resp.Places {
+		msg.Places[i] = p.toProto()
+	}
+	return msg
+}
+
+func (resp getPlacesResponse) CSV() ([]string, [][]string) {
+	return placesCSVHeader, placesCSVRows(resp.Places)
+}
+
+func (s *server) getPlacesHandler() http.HandlerFunc {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		var requestValues request
+		var requestValues getPlacesRequest
 		queryDecoder := schema.NewDecoder()
 		queryDecoder.IgnoreUnknownKeys(true)
 		if err := queryDecoder.Decode(&requestValues, r.URL.Query()); err != nil {
@@ -91,44 +899,246 @@ func (s *server) getPlacesHandler() http.HandlerFunc {
 		}
 
 		// Converting PB to JSON
-		jsonFormattableResponse := response{
+		jsonFormattableResponse := getPlacesResponse{
+			Places: make([]*responsePlace, len(placesStoreResp.Places)),
+		}
+		for i, pbPlace := range placesStoreResp.Places {
+			jsonFormattableResponse.Places[i] = newResponsePlace(pbPlace)
+		}
+		s.writeResponse(w, r, http.StatusOK, &jsonFormattableResponse)
+	})
+}
+
+type pageInfo struct {
+	Offset uint64 `json:"offset"`
+	Amount uint64 `json:"amount"`
+	// NextOffset is null on last page
+	NextOffset *uint64 `json:"next_offset"`
+}
+
+func newPageInfo(offset, amount uint64, count int) *pageInfo {
+	page := &pageInfo{Offset: offset, Amount: amount}
+	if amount > 0 && uint64(count) == amount {
+		next := offset + amount
+		page.NextOffset = &next
+	}
+	return page
+}
+
+type getPlacesV2Response struct {
+	Places []*responsePlace `json:"places"`
+	Page   *pageInfo        `json:"page"`
+}
+
+func (resp getPlacesV2Response) CSV() ([]string, [][]string) {
+	return placesCSVHeader, placesCSVRows(resp.Places)
+}
+
+func (s *server) getPlacesV2Handler() http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var requestValues getPlacesRequest
+		queryDecoder := schema.NewDecoder()
+		queryDecoder.IgnoreUnknownKeys(true)
+		if err := queryDecoder.Decode(&requestValues, r.URL.Query()); err != nil {
+			s.writeProblem(w, http.StatusBadRequest, "could not decode query: "+err.Error())
+			return
+		}
+
+		placesStoreResp, err := s.placesStore.GetPlacesByCityID(r.Context(), &places.GetPlacesByCityIDRequest{
+			CityID: requestValues.CityID,
+			Amount: requestValues.Amount,
+			Offset: requestValues.Offset,
+		})
+		if err != nil {
+			s.logger.Errorf("could not get data from places store, error: %v", err)
+			s.writeProblem(w, http.StatusBadGateway, "could not get places")
+			return
+		}
+
+		resp := getPlacesV2Response{
 			Places: make([]*responsePlace, len(placesStoreResp.Places)),
+			Page:   newPageInfo(requestValues.Offset, requestValues.Amount, len(placesStoreResp.Places)),
 		}
 		for i, pbPlace := range placesStoreResp.Places {
-			jsonFormattableResponse.Places[i] = &responsePlace{
-				ID:          pbPlace.GetId(),
-				Title:       pbPlace.GetTitle(),
-				Address:     pbPlace.GetAddress(),
-				Description: pbPlace.GetDescription(),
-				ImgURL:      pbPlace.GetImgURL(),
+			resp.Places[i] = newResponsePlace(pbPlace)
+		}
+		s.writeResponse(w, r, http.StatusOK, &resp)
+	})
+}
+
+// addPlaceRequest identifies city by city_id or, for older clients, by city_name
+type addPlaceRequest struct {
+	CityID      uint64 `json:"city_id,omitempty"`
+	CityName    string `json:"city_name,omitempty" validate:"maxlen=100"`
+	Title       string `json:"title" validate:"minlen=1,maxlen=200"`
+	Address     string `json:"address,omitempty" validate:"maxlen=300"`
+	Description string `json:"description,omitempty" validate:"maxlen=5000"`
+	ImgURL      string `json:"image_url,omitempty" validate:"maxlen=2000"`
+	// Latitude and Longitude are given together or not at all
+	Latitude  *float64 `json:"latitude,omitempty" validate:"min=-90,max=90"`
+	Longitude *float64 `json:"longitude,omitempty" validate:"min=-180,max=180"`
+}
+
+type addPlaceQuery struct {
+	// Force adds place even if it looks like duplicate of existing one
+	Force bool `schema:"force"`
+}
+
+type addPlaceResponse struct {
+	ID uint64 `json:"id"`
+}
+
+func (resp addPlaceResponse) ToProto() proto.Message {
+	return &places.AddPlaceResponse{Id: resp.ID}
+}
+
+func (s *server) addPlaceHandler() http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var requestValues addPlaceRequest
+		if err := json.NewDecoder(r.Body).Decode(&requestValues); err != nil {
+			s.writeProblem(w, http.StatusBadRequest, "could not decode JSON body: "+err.Error())
+			return
+		}
+		if requestValues.CityID == 0 && requestValues.CityName == "" || requestValues.Title == "" {
+			s.writeProblem(w, http.StatusBadRequest, "city_id or city_name and title are required")
+			return
+		}
+		var queryValues addPlaceQuery
+		queryDecoder := schema.NewDecoder()
+		queryDecoder.IgnoreUnknownKeys(true)
+		if err := queryDecoder.Decode(&queryValues, r.URL.Query()); err != nil {
+			s.writeProblem(w, http.StatusBadRequest, "could not decode query: "+err.Error())
+			return
+		}
+
+		// Places are added to canonical cities, so spelling of name does not create new city
+		index, err := s.cities.Index(r.Context())
+		if err != nil {
+			s.logger.Errorf("could not get cities, error: %v", err)
+			s.writeProblem(w, http.StatusBadGateway, "could not add place")
+			return
+		}
+		city := lookupCity(index, requestValues.CityID, requestValues.CityName)
+		if city == nil {
+			// City may be created by another gateway since index was read
+			s.cities.Invalidate()
+			if index, err = s.cities.Index(r.Context()); err == nil {
+				city = lookupCity(index, requestValues.CityID, requestValues.CityName)
 			}
 		}
+		if city == nil {
+			s.writeProblem(w, http.StatusUnprocessableEntity, "unknown city, cities are created by administrators")
+			return
+		}
 
-		if err := json.NewEncoder(w).Encode(&jsonFormattableResponse); err != nil {
-			s.logger.Errorf("could not encode response, error: %v", err)
-			w.WriteHeader(http.StatusInternalServerError)
+		if (requestValues.Latitude == nil) != (requestValues.Longitude == nil) {
+			s.writeProblem(w, http.StatusBadRequest, "latitude and longitude must be given together")
 			return
 		}
+		place := &places.Place{
+			Title:       requestValues.Title,
+			Address:     requestValues.Address,
+			Description: requestValues.Description,
+			ImgURL:      requestValues.ImgURL,
+			Location:    newLocation(requestValues.Latitude, requestValues.Longitude),
+		}
+		if s.duplicates != nil && !queryValues.Force {
+			matches, err := s.duplicates.Find(r.Context(), city.GetId(), place)
+			if err != nil {
+				s.logger.Errorf("could not check duplicates, error: %v", err)
+				s.writeProblem(w, http.StatusBadGateway, "could not check duplicates")
+				return
+			}
+			if len(matches) > 0 {
+				s.writeDuplicates(w, matches)
+				return
+			}
+		}
+
+		if p := principalFromContext(r.Context()); s.needsModeration(p) {
+			submission := &moderation.Submission{
+				CityID:      city.GetId(),
+				CityName:    city.GetTitle(),
+				Title:       place.Title,
+				Address:     place.Address,
+				Description: place.Description,
+				ImgURL:      place.ImgURL,
+				Latitude:    requestValues.Latitude,
+				Longitude:   requestValues.Longitude,
+				Submitter:   submitterOf(p),
+			}
+			// Format is negotiated before submission is queued, so retry after 406 does not queue it twice
+			encoder, ok := s.negotiate(w, r, submission)
+			if !ok {
+				return
+			}
+			if err := s.moderation.Submit(submission); err != nil {
+				s.logger.Errorf("could not queue place for moderation, error: %v", err)
+				s.writeProblem(w, http.StatusInternalServerError, "could not add place")
+				return
+			}
+			s.writeEncoded(w, encoder, http.StatusAccepted, submission)
+			return
+		}
+
+		addPlaceResp, err := s.placesStore.AddPlace(r.Context(), &places.AddPlaceRequest{
+			CityID:   city.GetId(),
+			CityName: city.GetTitle(),
+			Place:    place,
+		})
+		if err != nil {
+			s.logger.Errorf("could not add place to places store, error: %v", err)
+			s.writeProblem(w, http.StatusBadGateway, "could not add place")
+			return
+		}
+
+		place.Id = addPlaceResp.GetId()
+		s.recordRevision(history.ActionCreate, submitterOf(principalFromContext(r.Context())), place, nil)
+		s.indexPlace(city.GetId(), place)
+
+		s.writeResponse(w, r, http.StatusCreated, &addPlaceResponse{ID: addPlaceResp.GetId()})
 	})
 }
 
-func (s *server) getCitiesHandler() http.HandlerFunc {
-	type request struct {
-		Offset uint64 `schema:"offset"`
-		Amount uint64 `schema:"amount"`
-	}
+type getCitiesRequest struct {
+	Offset uint64 `schema:"offset"`
+	Amount uint64 `schema:"amount" validate:"max=100"`
+}
+
+type responseCity struct {
+	ID    uint64 `json:"id"`
+	Title string `json:"title"`
+}
 
-	type responseCity struct {
-		ID    uint64 `json:"id"`
-		Title string `json:"title"`
+var citiesCSVHeader = []string{"id", "title"}
+
+func citiesCSVRows(list []*responseCity) [][]string {
+	rows := make([][]string, len(list))
+	for i, c := range list {
+		rows[i] = []string{strconv.FormatUint(c.ID, 10), c.Title}
 	}
+	return rows
+}
 
-	type response struct {
-		Cities []*responseCity `json:"cities"`
+type getCitiesResponse struct {
+	Cities []*responseCity `json:"cities"`
+}
+
+func (resp getCitiesResponse) ToProto() proto.Message {
+	msg := &places.GetCitiesResponse{Cities: make([]*places.City, len(resp.Cities))}
+	for i, c := range resp.Cities {
+		msg.Cities[i] = &places.City{Id: c.ID, Title: c.Title}
 	}
+	return msg
+}
 
+func (resp getCitiesResponse) CSV() ([]string, [][]string) {
+	return citiesCSVHeader, citiesCSVRows(resp.Cities)
+}
+
+func (s *server) getCitiesHandler() http.HandlerFunc {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		var requestValues request
+		var requestValues getCitiesRequest
 		queryDecoder := schema.NewDecoder()
 		queryDecoder.IgnoreUnknownKeys(true)
 		if err := queryDecoder.Decode(&requestValues, r.URL.Query()); err != nil {
@@ -148,7 +1158,7 @@ func (s *server) getCitiesHandler() http.HandlerFunc {
 		}
 
 		// Converting PB to JSON
-		jsonFormattableResponse := response{
+		jsonFormattableResponse := getCitiesResponse{
 			Cities: make([]*responseCity, len(citiesStoreResp.Cities)),
 		}
 		for i, pbCity := range citiesStoreResp.Cities {
@@ -157,12 +1167,50 @@ func (s *server) getCitiesHandler() http.HandlerFunc {
 				Title: pbCity.GetTitle(),
 			}
 		}
+		s.writeResponse(w, r, http.StatusOK, &jsonFormattableResponse)
+	})
+}
 
-		if err := json.NewEncoder(w).Encode(&jsonFormattableResponse); err != nil {
-			s.logger.Errorf("could not encode response, error: %v", err)
-			w.WriteHeader(http.StatusInternalServerError)
+type getCitiesV2Response struct {
+	Cities []*responseCity `json:"cities"`
+	Page   *pageInfo       `json:"page"`
+}
+
+func (resp getCitiesV2Response) CSV() ([]string, [][]string) {
+	return citiesCSVHeader, citiesCSVRows(resp.Cities)
+}
+
+func (s *server) getCitiesV2Handler() http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var requestValues getCitiesRequest
+		queryDecoder := schema.NewDecoder()
+		queryDecoder.IgnoreUnknownKeys(true)
+		if err := queryDecoder.Decode(&requestValues, r.URL.Query()); err != nil {
+			s.writeProblem(w, http.StatusBadRequest, "could not decode query: "+err.Error())
+			return
+		}
+
+		citiesStoreResp, err := s.placesStore.GetCities(r.Context(), &places.GetCitiesRequest{
+			Amount: requestValues.Amount,
+			Offset: requestValues.Offset,
+		})
+		if err != nil {
+			s.logger.Errorf("could not get data from places store, error: %v", err)
+			s.writeProblem(w, http.StatusBadGateway, "could not get cities")
 			return
 		}
+
+		resp := getCitiesV2Response{
+			Cities: make([]*responseCity, len(citiesStoreResp.Cities)),
+			Page:   newPageInfo(requestValues.Offset, requestValues.Amount, len(citiesStoreResp.Cities)),
+		}
+		for i, pbCity := range citiesStoreResp.Cities {
+			resp.Cities[i] = &responseCity{
+				ID:    pbCity.GetId(),
+				Title: pbCity.GetTitle(),
+			}
+		}
+		s.writeResponse(w, r, http.StatusOK, &resp)
 	})
 }
 
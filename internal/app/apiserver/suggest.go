@@ -0,0 +1,37 @@
+package apiserver
+
+import (
+	"chillit-rest-gateway/internal/app/suggest"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/schema"
+)
+
+type suggestRequest struct {
+	Query string `schema:"q,required" validate:"minlen=1,maxlen=100"`
+	Limit int    `schema:"limit" validate:"min=0" doc:"Number of suggestions, up to max_results of suggest config which is also the default"`
+}
+
+type suggestResponse struct {
+	Suggestions []*suggest.Suggestion `json:"suggestions"`
+}
+
+func (s *server) suggestHandler() http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var requestValues suggestRequest
+		queryDecoder := schema.NewDecoder()
+		queryDecoder.IgnoreUnknownKeys(true)
+		if err := queryDecoder.Decode(&requestValues, r.URL.Query()); err != nil {
+			s.writeProblem(w, http.StatusBadRequest, "could not decode query: "+err.Error())
+			return
+		}
+		if max := s.suggest.Config().MaxResults; requestValues.Limit > max {
+			s.writeProblem(w, http.StatusBadRequest, "limit should be at most "+strconv.Itoa(max))
+			return
+		}
+		s.writeResponse(w, r, http.StatusOK, &suggestResponse{
+			Suggestions: s.suggest.Suggest(requestValues.Query, requestValues.Limit),
+		})
+	})
+}
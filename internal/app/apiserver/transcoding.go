@@ -0,0 +1,114 @@
+package apiserver
+
+import (
+	"chillit-rest-gateway/internal/app/openapi"
+	"chillit-rest-gateway/internal/app/transcoding"
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"google.golang.org/grpc/status"
+)
+
+// restOnlyRPCs are served only by REST routes: proxies would skip moderation, preconditions,
+// duplicate check, city canonicalization, history and search indexes of place writes and
+// check of city titles of city writes
+var restOnlyRPCs = map[string]bool{
+	"AddPlace":    true,
+	"UpdatePlace": true,
+	"DeletePlace": true,
+	"CreateCity":  true,
+	"RenameCity":  true,
+}
+
+// isReadRPC tells if RPC does not change places store
+func isReadRPC(rpc string) bool {
+	return strings.HasPrefix(rpc, "Get")
+}
+
+// checkTranscoding rejects rules of RPCs which must not be proxied
+func checkTranscoding(endpoints []*transcoding.Endpoint) error {
+	for _, endpoint := range endpoints {
+		if restOnlyRPCs[endpoint.RPC] {
+			return errors.New("transcoding rule '" + endpoint.Rule.Selector + "' is not allowed, " + endpoint.RPC + " is served by REST routes only")
+		}
+	}
+	return nil
+}
+
+// configureTranscoding exposes places store RPCs listed in transcoding rules as REST routes
+func (s *server) configureTranscoding() {
+	for _, endpoint := range s.transcoded {
+		rt := &route{
+			Versions:       []string{apiV1},
+			Method:         endpoint.Method,
+			Path:           endpoint.Path,
+			Summary:        "Call " + endpoint.Rule.Selector,
+			Tags:           []string{"store"},
+			Scope:          endpoint.Rule.Scope,
+			AllowAnonymous: endpoint.Rule.AllowAnonymous,
+			Idempotent:     !isSafeMethod(endpoint.Method),
+			Response:       endpoint.Response,
+		}
+		if !isReadRPC(endpoint.RPC) {
+			rt.Scope, rt.AllowAnonymous = scopeAdmin, false
+		}
+		if endpoint.Body != nil {
+			rt.Body = endpoint.Body
+		}
+
+		queryFields := endpoint.QueryFields()
+		names := make([]string, 0, len(queryFields))
+		for name := range queryFields {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			rt.Parameters = append(rt.Parameters, &openapi.Parameter{
+				Name:   name,
+				In:     "query",
+				Schema: openapi.ScalarSchema(queryFields[name]),
+			})
+		}
+		for _, name := range transcoding.PathVariables(endpoint.Path) {
+			rt.Parameters = append(rt.Parameters, &openapi.Parameter{
+				Name:     name,
+				In:       "path",
+				Required: true,
+				Schema:   &openapi.Schema{Type: "string"},
+			})
+		}
+
+		s.handle(rt, s.transcodingHandler(endpoint))
+	}
+}
+
+func (s *server) transcodingHandler(endpoint *transcoding.Endpoint) http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req, err := endpoint.Decode(r.Body, r.URL.Query(), mux.Vars(r))
+		if err != nil {
+			s.writeProblem(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		resp, err := endpoint.Invoke(r.Context(), req)
+		if err != nil {
+			st, _ := status.FromError(err)
+			code := transcoding.HTTPStatus(st.Code())
+			if code >= http.StatusInternalServerError {
+				s.logger.Errorf("could not call %s, error: %v", endpoint.Rule.Selector, err)
+				s.writeProblem(w, code, "places store call failed")
+				return
+			}
+			s.writeProblem(w, code, st.Message())
+			return
+		}
+
+		if !isReadRPC(endpoint.RPC) {
+			s.citiesChanged()
+		}
+		s.writeResponse(w, r, http.StatusOK, resp)
+	})
+}
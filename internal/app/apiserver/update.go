@@ -0,0 +1,172 @@
+package apiserver
+
+import (
+	"chillit-rest-gateway/internal/app/history"
+	"chillit-rest-gateway/internal/app/openapi"
+	"chillit-rest-gateway/internal/app/places"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"google.golang.org/genproto/protobuf/field_mask"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var ifMatchParameter = &openapi.Parameter{
+	Name:        "If-Match",
+	In:          "header",
+	Description: "ETag of place from previous response, `*` skips version check",
+	Required:    true,
+	Schema:      &openapi.Schema{Type: "string"},
+}
+
+// updatePlaceRequest changes only present fields
+type updatePlaceRequest struct {
+	Title       *string `json:"title,omitempty" validate:"minlen=1,maxlen=200"`
+	Address     *string `json:"address,omitempty" validate:"maxlen=300"`
+	Description *string `json:"description,omitempty" validate:"maxlen=5000"`
+	ImgURL      *string `json:"image_url,omitempty" validate:"maxlen=2000"`
+	// Latitude and Longitude are given together or not at all
+	Latitude  *float64 `json:"latitude,omitempty" validate:"min=-90,max=90"`
+	Longitude *float64 `json:"longitude,omitempty" validate:"min=-180,max=180"`
+}
+
+// toProto returns place with present fields and field mask listing them
+func (req *updatePlaceRequest) toProto(id uint64) (*places.Place, *field_mask.FieldMask) {
+	place := &places.Place{Id: id}
+	mask := &field_mask.FieldMask{}
+	for _, field := range []struct {
+		value  *string
+		target *string
+		path   string
+	}{
+		{req.Title, &place.Title, "title"},
+		{req.Address, &place.Address, "address"},
+		{req.Description, &place.Description, "description"},
+		{req.ImgURL, &place.ImgURL, "imgURL"},
+	} {
+		if field.value != nil {
+			*field.target = *field.value
+			mask.Paths = append(mask.Paths, field.path)
+		}
+	}
+	if location := newLocation(req.Latitude, req.Longitude); location != nil {
+		place.Location = location
+		mask.Paths = append(mask.Paths, "location")
+	}
+	return place, mask
+}
+
+func placeETag(version uint64) string {
+	return `"` + strconv.FormatUint(version, 10) + `"`
+}
+
+// ifMatchVersion returns version of place expected by If-Match header, zero for "*"
+func (s *server) ifMatchVersion(w http.ResponseWriter, r *http.Request) (uint64, bool) {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" {
+		s.writeProblem(w, http.StatusPreconditionRequired, "If-Match header with ETag of place is required")
+		return 0, false
+	}
+	if header == "*" {
+		return 0, true
+	}
+	version, err := strconv.ParseUint(strings.Trim(strings.TrimPrefix(header, "W/"), `"`), 10, 64)
+	if err != nil || version == 0 {
+		s.writeProblem(w, http.StatusPreconditionFailed, "If-Match header does not match ETag of place")
+		return 0, false
+	}
+	return version, true
+}
+
+// writePlaceError maps error of places store call changing place
+func (s *server) writePlaceError(w http.ResponseWriter, err error) {
+	switch status.Code(err) {
+	case codes.NotFound:
+		s.writeProblem(w, http.StatusNotFound, "place not found")
+	case codes.FailedPrecondition, codes.Aborted:
+		s.writeProblem(w, http.StatusPreconditionFailed, "place was changed, fetch it again to get current ETag")
+	case codes.InvalidArgument:
+		s.writeProblem(w, http.StatusBadRequest, status.Convert(err).Message())
+	default:
+		s.logger.Errorf("could not change place in places store, error: %v", err)
+		s.writeProblem(w, http.StatusBadGateway, "could not change place")
+	}
+}
+
+func (s *server) placeID(w http.ResponseWriter, r *http.Request) (uint64, bool) {
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil || id == 0 {
+		s.writeProblem(w, http.StatusBadRequest, "invalid place id")
+		return 0, false
+	}
+	return id, true
+}
+
+func (s *server) updatePlaceHandler() http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, ok := s.placeID(w, r)
+		if !ok {
+			return
+		}
+		var requestValues updatePlaceRequest
+		if err := json.NewDecoder(r.Body).Decode(&requestValues); err != nil {
+			s.writeProblem(w, http.StatusBadRequest, "could not decode JSON body: "+err.Error())
+			return
+		}
+		if (requestValues.Latitude == nil) != (requestValues.Longitude == nil) {
+			s.writeProblem(w, http.StatusBadRequest, "latitude and longitude must be given together")
+			return
+		}
+		place, mask := requestValues.toProto(id)
+		if len(mask.Paths) == 0 {
+			s.writeProblem(w, http.StatusBadRequest, "no fields to update")
+			return
+		}
+		version, ok := s.ifMatchVersion(w, r)
+		if !ok {
+			return
+		}
+
+		resp, err := s.placesStore.UpdatePlace(r.Context(), &places.UpdatePlaceRequest{
+			Place:      place,
+			UpdateMask: mask,
+			Version:    version,
+		})
+		if err != nil {
+			s.writePlaceError(w, err)
+			return
+		}
+
+		author := submitterOf(principalFromContext(r.Context()))
+		s.recordRevision(history.ActionUpdate, author, resp.GetPlace(), requestValues.changedFields())
+		s.reindexPlace(resp.GetPlace())
+
+		w.Header().Set("ETag", placeETag(resp.GetPlace().GetVersion()))
+		s.writeResponse(w, r, http.StatusOK, newResponsePlace(resp.GetPlace()))
+	})
+}
+
+func (s *server) deletePlaceHandler() http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, ok := s.placeID(w, r)
+		if !ok {
+			return
+		}
+		version, ok := s.ifMatchVersion(w, r)
+		if !ok {
+			return
+		}
+
+		if _, err := s.placesStore.DeletePlace(r.Context(), &places.DeletePlaceRequest{Id: id, Version: version}); err != nil {
+			s.writePlaceError(w, err)
+			return
+		}
+		s.recordRevision(history.ActionDelete, submitterOf(principalFromContext(r.Context())), &places.Place{Id: id}, nil)
+		s.unindexPlace(id)
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
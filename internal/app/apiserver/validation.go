@@ -0,0 +1,113 @@
+package apiserver
+
+import (
+	"bytes"
+	"chillit-rest-gateway/internal/app/apikeys"
+	"chillit-rest-gateway/internal/app/openapi"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// maxBodySize limits JSON bodies of requests
+const maxBodySize = 1 << 20
+
+// globalQueryParams are accepted by every route
+var globalQueryParams = []string{apikeys.QueryParam, formatParam}
+
+// ValidationMiddleware rejects requests violating API document and,
+// if enabled, logs responses violating it
+func (s *server) ValidationMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := mux.CurrentRoute(r)
+		if s.apiDoc == nil || route == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		path, _ := route.GetPathTemplate()
+		op := s.apiDoc.Operation(r.Method, path)
+		if op == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if errs := s.apiDoc.ValidateQuery(op, r.URL.Query(), globalQueryParams...); len(errs) > 0 {
+			s.writeProblemDetails(w, &problem{
+				Type:   "about:blank",
+				Title:  http.StatusText(http.StatusBadRequest),
+				Status: http.StatusBadRequest,
+				Detail: "invalid query parameters",
+				Errors: errs,
+			})
+			return
+		}
+
+		if op.RequestBody != nil {
+			body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+			if err != nil {
+				s.writeProblem(w, http.StatusRequestEntityTooLarge, "request body is too large")
+				return
+			}
+			if errs := s.apiDoc.ValidateJSON(op.RequestBody.Content["application/json"].Schema, body); len(errs) > 0 {
+				s.writeProblemDetails(w, &problem{
+					Type:   "about:blank",
+					Title:  http.StatusText(http.StatusBadRequest),
+					Status: http.StatusBadRequest,
+					Detail: "invalid request body",
+					Errors: errs,
+				})
+				return
+			}
+			r.Body = ioutil.NopCloser(bytes.NewReader(body))
+		}
+
+		// Streamed responses have no schema and are not recorded
+		if !s.validateResponses || !hasResponseSchema(op) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		rec := &responseRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r)
+
+		resp, ok := op.Responses[strconv.Itoa(rec.status)]
+		if !ok {
+			resp = op.Responses["default"]
+		}
+		if resp == nil {
+			s.logger.Warnf("contract violation: %s %s responded with undocumented status %d", r.Method, path, rec.status)
+			return
+		}
+		contentType := strings.TrimSpace(strings.Split(rec.Header().Get("Content-Type"), ";")[0])
+		media, ok := resp.Content[contentType]
+		if !ok {
+			if len(resp.Content) > 0 {
+				s.logger.Warnf("contract violation: %s %s responded with undocumented content type '%s'", r.Method, path, contentType)
+			}
+			return
+		}
+		if media.Schema == nil {
+			return
+		}
+		for _, fieldErr := range s.apiDoc.ValidateJSON(media.Schema, rec.body.Bytes()) {
+			s.logger.Warnf("contract violation: %s %s response field '%s' %s", r.Method, path, fieldErr.Field, fieldErr.Message)
+		}
+	})
+}
+
+func hasResponseSchema(op *openapi.Operation) bool {
+	for status, resp := range op.Responses {
+		if status == "default" {
+			continue
+		}
+		for _, media := range resp.Content {
+			if media.Schema != nil {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,48 @@
+package apiserver
+
+import (
+	"chillit-rest-gateway/internal/app/metrics"
+	"net/http"
+	"regexp"
+	"strconv"
+)
+
+// API versions, unversioned paths are aliases of apiV1
+const (
+	apiV1 = "v1"
+	apiV2 = "v2"
+)
+
+var apiVersions = []string{apiV1, apiV2}
+
+var versionPrefixRe = regexp.MustCompile(`^/v[0-9]+(/|$)`)
+
+// unversionedPath strips API version prefix from path
+func unversionedPath(path string) string {
+	return versionPrefixRe.ReplaceAllString(path, "/")
+}
+
+// VersionMiddleware counts usage of API version and announces its deprecation and sunset
+func (s *server) VersionMiddleware(version string, alias bool, next http.HandlerFunc) http.HandlerFunc {
+	usage := version
+	if alias {
+		usage = "unversioned"
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		metrics.RequestsByAPIVersion.Add(usage, 1)
+
+		if config, ok := s.versions[version]; ok {
+			if !config.Deprecation.IsZero() {
+				w.Header().Set("Deprecation", "@"+strconv.FormatInt(config.Deprecation.Unix(), 10))
+			}
+			if !config.Sunset.IsZero() {
+				w.Header().Set("Sunset", config.Sunset.UTC().Format(http.TimeFormat))
+			}
+			if config.Link != "" {
+				w.Header().Add("Link", "<"+config.Link+`>; rel="deprecation"; type="text/html"`)
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,171 @@
+package audit
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"strings"
+	"time"
+)
+
+// ErrNotQueryable is returned by Query when sink can not be read back
+var ErrNotQueryable = errors.New("audit sink does not support queries")
+
+const redacted = "[REDACTED]"
+
+// Entry is audit record of request
+type Entry struct {
+	Time      time.Time `json:"time"`
+	Principal string    `json:"principal,omitempty"`
+	IP        string    `json:"ip"`
+	Method    string    `json:"method"`
+	// Route is unversioned path template of matched route
+	Route string `json:"route"`
+	Path  string `json:"path"`
+	// Body is sanitized request body
+	Body   interface{} `json:"body,omitempty"`
+	Status int         `json:"status"`
+	// Result is problem detail of failed request
+	Result    string  `json:"result,omitempty"`
+	LatencyMs float64 `json:"latency_ms"`
+}
+
+// Filter of entries, zero fields match everything
+type Filter struct {
+	Principal string
+	Route     string
+	From      time.Time
+	To        time.Time
+	// Limit of returned entries, newest are kept
+	Limit int
+}
+
+// Match reports whether entry passes filter
+func (f *Filter) Match(e *Entry) bool {
+	if f.Principal != "" && e.Principal != f.Principal {
+		return false
+	}
+	if f.Route != "" && e.Route != f.Route {
+		return false
+	}
+	if !f.From.IsZero() && e.Time.Before(f.From) {
+		return false
+	}
+	if !f.To.IsZero() && !e.Time.Before(f.To) {
+		return false
+	}
+	return true
+}
+
+// Sink stores entries
+type Sink interface {
+	Write(e *Entry) error
+	Close() error
+}
+
+// Querier is sink able to read entries back
+type Querier interface {
+	// Query returns entries matching filter, newest first
+	Query(f *Filter) ([]*Entry, error)
+}
+
+// Auditor sanitizes and records entries
+type Auditor struct {
+	config *Config
+	sink   Sink
+}
+
+// NewAuditor returns auditor writing to sink, rotating file of config if sink is nil
+func NewAuditor(config *Config, sink Sink) (*Auditor, error) {
+	if config == nil {
+		return nil, errors.New("[ NewAuditor ] <nil> config")
+	}
+	if config.MaxBodySize <= 0 {
+		config.MaxBodySize = 4 << 10
+	}
+	if len(config.RedactFields) == 0 {
+		config.RedactFields = []string{"password", "secret", "token", "api_key"}
+	}
+	if sink == nil {
+		file, err := NewFileSink(config)
+		if err != nil {
+			return nil, errors.New("[ NewAuditor ] " + err.Error())
+		}
+		sink = file
+	}
+	return &Auditor{config: config, sink: sink}, nil
+}
+
+// Config returns config with defaults applied
+func (a *Auditor) Config() *Config {
+	return a.config
+}
+
+// Close closes sink
+func (a *Auditor) Close() error {
+	return a.sink.Close()
+}
+
+// Record writes entry to sink
+func (a *Auditor) Record(e *Entry) error {
+	return a.sink.Write(e)
+}
+
+// Query returns entries matching filter, newest first
+func (a *Auditor) Query(f *Filter) ([]*Entry, error) {
+	querier, ok := a.sink.(Querier)
+	if !ok {
+		return nil, ErrNotQueryable
+	}
+	return querier.Query(f)
+}
+
+// Body returns sanitized request body: JSON with values of sensitive fields redacted,
+// or placeholder for body of other media type or too large body
+func (a *Auditor) Body(contentType string, data []byte, truncated bool) interface{} {
+	if len(data) == 0 {
+		return nil
+	}
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if truncated {
+		return fmt.Sprintf("[more than %d bytes omitted]", a.config.MaxBodySize)
+	}
+	if mediaType != "" && mediaType != "application/json" && !strings.HasSuffix(mediaType, "+json") {
+		return fmt.Sprintf("[%d bytes of %s omitted]", len(data), mediaType)
+	}
+
+	var body interface{}
+	if err := json.Unmarshal(data, &body); err != nil {
+		return fmt.Sprintf("[%d bytes of invalid JSON omitted]", len(data))
+	}
+	return a.redact(body)
+}
+
+func (a *Auditor) redact(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			if a.sensitive(key) {
+				v[key] = redacted
+				continue
+			}
+			v[key] = a.redact(field)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = a.redact(item)
+		}
+	}
+	return value
+}
+
+func (a *Auditor) sensitive(key string) bool {
+	key = strings.ToLower(key)
+	for _, field := range a.config.RedactFields {
+		if strings.Contains(key, strings.ToLower(field)) {
+			return true
+		}
+	}
+	return false
+}
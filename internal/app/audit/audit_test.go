@@ -0,0 +1,122 @@
+package audit
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuditor_Body(t *testing.T) {
+	a, err := NewAuditor(&Config{MaxBodySize: 64}, &FileSink{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body := a.Body("application/json", []byte(`{"username":"anna","password":"secret","keys":[{"API_KEY":"x"}]}`), false)
+	assert.Equal(t, map[string]interface{}{
+		"username": "anna",
+		"password": redacted,
+		"keys":     []interface{}{map[string]interface{}{"API_KEY": redacted}},
+	}, body)
+
+	assert.Nil(t, a.Body("application/json", nil, false))
+	assert.Equal(t, "[12 bytes of text/csv omitted]", a.Body("text/csv; charset=utf-8", []byte("title\nCoffee"), false))
+	assert.Equal(t, "[more than 64 bytes omitted]", a.Body("application/json", []byte(`{}`), true))
+	assert.Equal(t, "[4 bytes of invalid JSON omitted]", a.Body("", []byte("oops"), false))
+}
+
+func TestFileSink(t *testing.T) {
+	dir, err := ioutil.TempDir("", "audit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "audit.jsonl")
+	sink, err := NewFileSink(&Config{Path: path, MaxSize: 300, MaxFiles: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sink.Close()
+
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 10; i++ {
+		principal := "user:anna"
+		if i%2 == 1 {
+			principal = "api_key:importer"
+		}
+		assert.NoError(t, sink.Write(&Entry{
+			Time:      start.Add(time.Duration(i) * time.Minute),
+			Principal: principal,
+			Method:    "POST",
+			Route:     "/places",
+			Status:    201,
+		}))
+	}
+
+	// Files beyond max files are dropped
+	_, err = os.Stat(path + ".2")
+	assert.NoError(t, err)
+	_, err = os.Stat(path + ".3")
+	assert.True(t, os.IsNotExist(err))
+
+	entries, err := sink.Query(&Filter{})
+	assert.NoError(t, err)
+	assert.True(t, len(entries) < 10)
+	assert.Equal(t, start.Add(9*time.Minute), entries[0].Time)
+	for i := 1; i < len(entries); i++ {
+		assert.True(t, entries[i].Time.Before(entries[i-1].Time))
+	}
+
+	entries, err = sink.Query(&Filter{Principal: "user:anna", To: start.Add(8 * time.Minute), Limit: 2})
+	assert.NoError(t, err)
+	if assert.Len(t, entries, 2) {
+		assert.Equal(t, start.Add(6*time.Minute), entries[0].Time)
+		assert.Equal(t, start.Add(4*time.Minute), entries[1].Time)
+	}
+	entries, err = sink.Query(&Filter{Route: "/places/{id}"})
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestFileSink_QueryWhileWriting(t *testing.T) {
+	dir, err := ioutil.TempDir("", "audit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	sink, err := NewFileSink(&Config{Path: filepath.Join(dir, "audit.jsonl"), MaxSize: 1000, MaxFiles: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sink.Close()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 200; i++ {
+			assert.NoError(t, sink.Write(&Entry{Time: time.Now(), Principal: "user:anna", Route: "/places"}))
+		}
+	}()
+	for {
+		select {
+		case <-done:
+			entries, err := sink.Query(&Filter{})
+			assert.NoError(t, err)
+			assert.NotEmpty(t, entries)
+			return
+		default:
+			entries, err := sink.Query(&Filter{})
+			assert.NoError(t, err)
+			// Every entry is read whole, file being written is read up to its size at start of query
+			for _, e := range entries {
+				assert.Equal(t, "user:anna", e.Principal)
+			}
+		}
+	}
+}
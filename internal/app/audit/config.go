@@ -0,0 +1,16 @@
+package audit
+
+// Config for audit log of mutating requests
+type Config struct {
+	// Path of JSONL file, rotated files get suffixes .1, .2 and so on
+	Path string `yaml:"path"`
+	// MaxSize of file in bytes before rotation, 100 MiB by default
+	MaxSize int64 `yaml:"max_size"`
+	// MaxFiles is number of rotated files kept, 5 by default
+	MaxFiles int `yaml:"max_files"`
+	// MaxBodySize of request body kept in entry, 4 KiB by default
+	MaxBodySize int `yaml:"max_body_size"`
+	// RedactFields are parts of JSON field names whose values are hidden,
+	// "password", "secret", "token" and "api_key" by default
+	RedactFields []string `yaml:"redact_fields"`
+}
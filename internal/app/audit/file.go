@@ -0,0 +1,192 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"strconv"
+	"sync"
+)
+
+// maxLineSize is limit of entry line read back by Query
+const maxLineSize = 1 << 20
+
+// FileSink writes entries as JSON lines to file rotated by size
+type FileSink struct {
+	mu       sync.Mutex
+	path     string
+	maxSize  int64
+	maxFiles int
+	file     *os.File
+	size     int64
+}
+
+// NewFileSink opens file of config for appending
+func NewFileSink(config *Config) (*FileSink, error) {
+	if config.Path == "" {
+		return nil, errors.New("[ NewFileSink ] path is required")
+	}
+	if config.MaxSize <= 0 {
+		config.MaxSize = 100 << 20
+	}
+	if config.MaxFiles <= 0 {
+		config.MaxFiles = 5
+	}
+
+	f := &FileSink{path: config.Path, maxSize: config.MaxSize, maxFiles: config.MaxFiles}
+	if err := f.open(); err != nil {
+		return nil, errors.New("[ NewFileSink ] could not open file: " + err.Error())
+	}
+	return f, nil
+}
+
+func (f *FileSink) open() error {
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	f.file = file
+	f.size = info.Size()
+	return nil
+}
+
+// rotated returns path of rotated file, current file for zero
+func (f *FileSink) rotated(n int) string {
+	if n == 0 {
+		return f.path
+	}
+	return f.path + "." + strconv.Itoa(n)
+}
+
+// rotate shifts files by one suffix dropping the oldest one and opens empty file
+func (f *FileSink) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return err
+	}
+	os.Remove(f.rotated(f.maxFiles))
+	for n := f.maxFiles - 1; n >= 0; n-- {
+		if err := os.Rename(f.rotated(n), f.rotated(n+1)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return f.open()
+}
+
+// Write appends entry, rotating file when it would grow over max size
+func (f *FileSink) Write(e *Entry) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return errors.New("[ FileSink.Write ] could not encode entry: " + err.Error())
+	}
+	data = append(data, '\n')
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.size > 0 && f.size+int64(len(data)) > f.maxSize {
+		if err := f.rotate(); err != nil {
+			return errors.New("[ FileSink.Write ] could not rotate file: " + err.Error())
+		}
+	}
+	n, err := f.file.Write(data)
+	f.size += int64(n)
+	if err != nil {
+		return errors.New("[ FileSink.Write ] could not write entry: " + err.Error())
+	}
+	return nil
+}
+
+// Close closes current file
+func (f *FileSink) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.file.Close()
+}
+
+// Query reads current and rotated files, newest entries first. Files are opened under lock,
+// so rotation does not move them during query, and read without it, so writes are not blocked
+func (f *FileSink) Query(filter *Filter) ([]*Entry, error) {
+	files, err := f.snapshot()
+	if err != nil {
+		return nil, errors.New("[ FileSink.Query ] could not open files: " + err.Error())
+	}
+	defer func() {
+		for _, file := range files {
+			file.Close()
+		}
+	}()
+
+	var matched []*Entry
+	for _, file := range files {
+		if err := scan(file, func(e *Entry) {
+			if !filter.Match(e) {
+				return
+			}
+			matched = append(matched, e)
+			if filter.Limit > 0 && len(matched) > filter.Limit {
+				matched = matched[1:]
+			}
+		}); err != nil {
+			return nil, errors.New("[ FileSink.Query ] could not read entries: " + err.Error())
+		}
+	}
+
+	entries := make([]*Entry, 0, len(matched))
+	for i := len(matched) - 1; i >= 0; i-- {
+		entries = append(entries, matched[i])
+	}
+	return entries, nil
+}
+
+// snapshotFile is file opened by query, read up to size it had when opened
+type snapshotFile struct {
+	*os.File
+	size int64
+}
+
+// snapshot opens existing files oldest first
+func (f *FileSink) snapshot() ([]*snapshotFile, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var files []*snapshotFile
+	for n := f.maxFiles; n >= 0; n-- {
+		file, err := os.Open(f.rotated(n))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err == nil {
+			var info os.FileInfo
+			if info, err = file.Stat(); err == nil {
+				files = append(files, &snapshotFile{File: file, size: info.Size()})
+				continue
+			}
+			file.Close()
+		}
+		for _, file := range files {
+			file.Close()
+		}
+		return nil, err
+	}
+	return files, nil
+}
+
+func scan(file *snapshotFile, fn func(e *Entry)) error {
+	scanner := bufio.NewScanner(io.LimitReader(file, file.size))
+	scanner.Buffer(make([]byte, 64<<10), maxLineSize)
+	for scanner.Scan() {
+		var e Entry
+		// Line cut by crash is skipped
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			continue
+		}
+		fn(&e)
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,222 @@
+package cache
+
+import (
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v7"
+	"github.com/sirupsen/logrus"
+)
+
+// Store keeps cached values
+type Store interface {
+	// Get returns value and false if key is absent or expired
+	Get(key string) ([]byte, bool, error)
+	Set(key string, value []byte, ttl time.Duration) error
+	// Add sets value only if key is absent and reports whether it was set
+	Add(key string, value []byte, ttl time.Duration) (bool, error)
+	Delete(key string) error
+}
+
+// memoryStore keeps values in process memory
+type memoryStore struct {
+	mu         sync.Mutex
+	entries    map[string]*entry
+	maxEntries int
+	now        func() time.Time
+}
+
+type entry struct {
+	value     []byte
+	expiresAt time.Time
+}
+
+// NewMemoryStore creates in-process store holding at most maxEntries values
+func NewMemoryStore(maxEntries int) Store {
+	if maxEntries <= 0 {
+		maxEntries = 10000
+	}
+	return &memoryStore{
+		entries:    make(map[string]*entry),
+		maxEntries: maxEntries,
+		now:        time.Now,
+	}
+}
+
+func (s *memoryStore) Get(key string) ([]byte, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	if s.now().After(e.expiresAt) {
+		delete(s.entries, key)
+		return nil, false, nil
+	}
+	return e.value, true, nil
+}
+
+func (s *memoryStore) Set(key string, value []byte, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if _, ok := s.entries[key]; !ok && len(s.entries) >= s.maxEntries {
+		s.evict(now)
+	}
+	s.entries[key] = &entry{value: value, expiresAt: now.Add(ttl)}
+	return nil
+}
+
+func (s *memoryStore) Add(key string, value []byte, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if e, ok := s.entries[key]; ok && !now.After(e.expiresAt) {
+		return false, nil
+	}
+	if len(s.entries) >= s.maxEntries {
+		s.evict(now)
+	}
+	s.entries[key] = &entry{value: value, expiresAt: now.Add(ttl)}
+	return true, nil
+}
+
+func (s *memoryStore) Delete(key string) error {
+	s.mu.Lock()
+	delete(s.entries, key)
+	s.mu.Unlock()
+	return nil
+}
+
+// evict drops expired entries or, if there are none, entry closest to expiration
+func (s *memoryStore) evict(now time.Time) {
+	var oldestKey string
+	var oldest time.Time
+	for key, e := range s.entries {
+		if now.After(e.expiresAt) {
+			delete(s.entries, key)
+			continue
+		}
+		if oldestKey == "" || e.expiresAt.Before(oldest) {
+			oldestKey, oldest = key, e.expiresAt
+		}
+	}
+	if len(s.entries) >= s.maxEntries {
+		delete(s.entries, oldestKey)
+	}
+}
+
+// redisStore keeps values in Redis
+type redisStore struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedisStore creates store shared between gateway instances
+func NewRedisStore(client *redis.Client, prefix string) Store {
+	return &redisStore{client: client, prefix: prefix}
+}
+
+func (s *redisStore) Get(key string) ([]byte, bool, error) {
+	value, err := s.client.Get(s.prefix + key).Bytes()
+	if err == redis.Nil {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return value, true, nil
+}
+
+func (s *redisStore) Set(key string, value []byte, ttl time.Duration) error {
+	return s.client.Set(s.prefix+key, value, ttl).Err()
+}
+
+func (s *redisStore) Add(key string, value []byte, ttl time.Duration) (bool, error) {
+	return s.client.SetNX(s.prefix+key, value, ttl).Result()
+}
+
+func (s *redisStore) Delete(key string) error {
+	return s.client.Del(s.prefix + key).Err()
+}
+
+// fallbackStore uses local store while primary is unreachable
+type fallbackStore struct {
+	primary    Store
+	local      Store
+	logger     logrus.FieldLogger
+	retryDelay time.Duration
+
+	mu      sync.Mutex
+	retryAt time.Time
+}
+
+// NewFallbackStore creates store which switches to local mode on primary errors
+// and tries primary again after retryDelay
+func NewFallbackStore(primary, local Store, retryDelay time.Duration, logger logrus.FieldLogger) Store {
+	return &fallbackStore{
+		primary:    primary,
+		local:      local,
+		logger:     logger,
+		retryDelay: retryDelay,
+	}
+}
+
+func (s *fallbackStore) degraded() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return time.Now().Before(s.retryAt)
+}
+
+func (s *fallbackStore) fail(err error) {
+	s.logger.Warnf("cache store is unreachable, falling back to local mode, error: %v", err)
+	s.mu.Lock()
+	s.retryAt = time.Now().Add(s.retryDelay)
+	s.mu.Unlock()
+}
+
+func (s *fallbackStore) Get(key string) ([]byte, bool, error) {
+	if !s.degraded() {
+		value, ok, err := s.primary.Get(key)
+		if err == nil {
+			return value, ok, nil
+		}
+		s.fail(err)
+	}
+	return s.local.Get(key)
+}
+
+func (s *fallbackStore) Set(key string, value []byte, ttl time.Duration) error {
+	if !s.degraded() {
+		err := s.primary.Set(key, value, ttl)
+		if err == nil {
+			return nil
+		}
+		s.fail(err)
+	}
+	return s.local.Set(key, value, ttl)
+}
+
+func (s *fallbackStore) Add(key string, value []byte, ttl time.Duration) (bool, error) {
+	if !s.degraded() {
+		added, err := s.primary.Add(key, value, ttl)
+		if err == nil {
+			return added, nil
+		}
+		s.fail(err)
+	}
+	return s.local.Add(key, value, ttl)
+}
+
+func (s *fallbackStore) Delete(key string) error {
+	if !s.degraded() {
+		if err := s.primary.Delete(key); err != nil {
+			s.fail(err)
+		}
+	}
+	return s.local.Delete(key)
+}
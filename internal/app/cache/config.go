@@ -0,0 +1,12 @@
+package cache
+
+import "time"
+
+// Config for response cache
+type Config struct {
+	// Backend is "local" (default) or "redis"
+	Backend string        `yaml:"backend"`
+	TTL     time.Duration `yaml:"ttl"`
+	// MaxEntries bounds local cache size
+	MaxEntries int `yaml:"max_entries"`
+}
@@ -0,0 +1,116 @@
+package cities
+
+import (
+	"chillit-rest-gateway/internal/app/places"
+	"chillit-rest-gateway/internal/app/textnorm"
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// pageSize is amount of cities requested from places store at once
+const pageSize = 100
+
+// ErrUnknownCity is returned for names matching no active city
+var ErrUnknownCity = errors.New("unknown city")
+
+// Index is snapshot of active cities by normalized title
+type Index map[string]*places.City
+
+// Lookup returns city matching name ignoring case, diacritics and punctuation or nil
+func (i Index) Lookup(name string) *places.City {
+	return i[textnorm.Normalize(name)]
+}
+
+// ByID returns active city by ID or nil
+func (i Index) ByID(id uint64) *places.City {
+	for _, city := range i {
+		if city.GetId() == id {
+			return city
+		}
+	}
+	return nil
+}
+
+// Resolver finds canonical cities by free-text names, so "moscow" and "Moscow " resolve to the same city
+type Resolver struct {
+	client places.PlacesStoreClient
+	ttl    time.Duration
+
+	mu       sync.Mutex
+	index    Index
+	loadedAt time.Time
+}
+
+// NewResolver creates resolver reading cities from places store on every call
+func NewResolver(client places.PlacesStoreClient) *Resolver {
+	return &Resolver{client: client}
+}
+
+// NewCachedResolver creates resolver keeping index of cities for ttl or until Invalidate
+func NewCachedResolver(client places.PlacesStoreClient, ttl time.Duration) *Resolver {
+	return &Resolver{client: client, ttl: ttl}
+}
+
+// Invalidate drops kept index, it is called after cities are changed
+func (r *Resolver) Invalidate() {
+	r.mu.Lock()
+	r.index = nil
+	r.mu.Unlock()
+}
+
+// Index returns all active cities, it is used to resolve many names at once.
+// Returned index is shared and must not be modified
+func (r *Resolver) Index(ctx context.Context) (Index, error) {
+	if r.ttl <= 0 {
+		return r.load(ctx)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.index != nil && time.Since(r.loadedAt) < r.ttl {
+		return r.index, nil
+	}
+	index, err := r.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	r.index, r.loadedAt = index, time.Now()
+	return index, nil
+}
+
+func (r *Resolver) load(ctx context.Context) (Index, error) {
+	index := make(Index)
+	for offset := uint64(0); ; offset += pageSize {
+		resp, err := r.client.GetCities(ctx, &places.GetCitiesRequest{Offset: offset, Amount: pageSize})
+		if err != nil {
+			return nil, errors.New("[ Resolver.Index ] could not get cities: " + err.Error())
+		}
+		for _, city := range resp.GetCities() {
+			if city.GetArchived() {
+				continue
+			}
+			// The first listed of cities differing only in case wins
+			key := textnorm.Normalize(city.GetTitle())
+			if _, ok := index[key]; !ok {
+				index[key] = city
+			}
+		}
+		if len(resp.GetCities()) < pageSize {
+			return index, nil
+		}
+	}
+}
+
+// Resolve returns active city matching name or ErrUnknownCity
+func (r *Resolver) Resolve(ctx context.Context, name string) (*places.City, error) {
+	index, err := r.Index(ctx)
+	if err != nil {
+		return nil, err
+	}
+	city := index.Lookup(name)
+	if city == nil {
+		return nil, ErrUnknownCity
+	}
+	return city, nil
+}
@@ -3,6 +3,7 @@ package configuration
 import (
 	"chillit-rest-gateway/internal/app/apiserver"
 	"chillit-rest-gateway/internal/app/places"
+	"chillit-rest-gateway/internal/app/redisconn"
 	"errors"
 	"io/ioutil"
 	"os"
@@ -14,6 +15,7 @@ import (
 type Configuration struct {
 	StoreService *places.Config    `yaml:"store_service"`
 	APIServer    *apiserver.Config `yaml:"api_server"`
+	Redis        *redisconn.Config `yaml:"redis"`
 }
 
 // ParseConfig parses from file
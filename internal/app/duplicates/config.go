@@ -0,0 +1,9 @@
+package duplicates
+
+// Config for duplicate place detection
+type Config struct {
+	// Threshold is minimal similarity from 0 to 1 of place considered duplicate, 0.8 by default
+	Threshold float64 `yaml:"threshold"`
+	// MaxMatches limits number of reported duplicates, 5 by default
+	MaxMatches int `yaml:"max_matches"`
+}
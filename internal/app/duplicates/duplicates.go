@@ -0,0 +1,133 @@
+package duplicates
+
+import (
+	"chillit-rest-gateway/internal/app/places"
+	"chillit-rest-gateway/internal/app/placesync"
+	"chillit-rest-gateway/internal/app/textnorm"
+	"context"
+	"errors"
+	"sort"
+	"strings"
+)
+
+// Weights of title and address similarity when both places have address
+const (
+	titleWeight   = 0.7
+	addressWeight = 0.3
+)
+
+// Match is existing place similar to submitted one
+type Match struct {
+	Place *places.Place
+	// Score is similarity from 0 to 1
+	Score float64
+}
+
+// Detector finds existing places of city similar to submitted place
+type Detector struct {
+	config *Config
+	client places.PlacesStoreClient
+}
+
+// NewDetector creates detector reading places from places store
+func NewDetector(config *Config, client places.PlacesStoreClient) (*Detector, error) {
+	if config == nil {
+		return nil, errors.New("[ NewDetector ] <nil> config")
+	}
+	if config.Threshold <= 0 {
+		config.Threshold = 0.8
+	}
+	if config.Threshold > 1 {
+		return nil, errors.New("[ NewDetector ] threshold should not exceed 1")
+	}
+	if config.MaxMatches <= 0 {
+		config.MaxMatches = 5
+	}
+	return &Detector{config: config, client: client}, nil
+}
+
+// Find returns likely duplicates of place in city, most similar first
+func (d *Detector) Find(ctx context.Context, cityID uint64, place *places.Place) ([]*Match, error) {
+	var matches []*Match
+	if err := placesync.EachPlace(ctx, d.client, cityID, func(existing *places.Place) {
+		if score := Score(place, existing); score >= d.config.Threshold {
+			matches = append(matches, &Match{Place: existing, Score: score})
+		}
+	}); err != nil {
+		return nil, errors.New("[ Detector.Find ] could not get places: " + err.Error())
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].Score > matches[j].Score })
+	if len(matches) > d.config.MaxMatches {
+		matches = matches[:d.config.MaxMatches]
+	}
+	return matches, nil
+}
+
+// Score is similarity of places by title and, if both have it, by address
+func Score(a, b *places.Place) float64 {
+	score := Similarity(a.GetTitle(), b.GetTitle())
+	if a.GetAddress() != "" && b.GetAddress() != "" {
+		score = titleWeight*score + addressWeight*Similarity(a.GetAddress(), b.GetAddress())
+	}
+	return score
+}
+
+// Similarity of normalized strings from 0 to 1 by edit distance. Words are also compared
+// in sorted order, so "Pushkin Cafe" is similar to "Cafe Pushkin"
+func Similarity(a, b string) float64 {
+	a, b = textnorm.Normalize(a), textnorm.Normalize(b)
+	if a == "" || b == "" {
+		return 0
+	}
+	score := ratio([]rune(a), []rune(b))
+	if sorted := ratio([]rune(sortWords(a)), []rune(sortWords(b))); sorted > score {
+		score = sorted
+	}
+	return score
+}
+
+func sortWords(s string) string {
+	words := strings.Fields(s)
+	sort.Strings(words)
+	return strings.Join(words, " ")
+}
+
+// ratio is 1 - levenshtein distance / length of longer string
+func ratio(a, b []rune) float64 {
+	longer := len(a)
+	if len(b) > longer {
+		longer = len(b)
+	}
+	return 1 - float64(levenshtein(a, b))/float64(longer)
+}
+
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = minInt(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+func minInt(values ...int) int {
+	m := values[0]
+	for _, v := range values[1:] {
+		if v < m {
+			m = v
+		}
+	}
+	return m
+}
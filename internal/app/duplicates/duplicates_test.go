@@ -0,0 +1,53 @@
+package duplicates
+
+import (
+	"chillit-rest-gateway/internal/app/places"
+	"chillit-rest-gateway/internal/app/places/placestest"
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSimilarity(t *testing.T) {
+	assert.Equal(t, 1.0, Similarity("Café Pushkin", "cafe pushkin"))
+	assert.Equal(t, 1.0, Similarity("Pushkin Cafe", "Cafe Pushkin"))
+	assert.True(t, Similarity("Cafe Pushkin", "Cafe Pushkn") > 0.9)
+	assert.True(t, Similarity("Cafe Pushkin", "Tea House") < 0.5)
+	assert.Equal(t, 0.0, Similarity("", "Tea House"))
+
+	// Branches of chain at different addresses are not duplicates
+	a := &places.Place{Title: "Coffee Bean", Address: "Tverskaya 1"}
+	b := &places.Place{Title: "Coffee Bean", Address: "Arbat 24"}
+	assert.True(t, Score(a, b) < 0.8)
+	assert.Equal(t, 1.0, Score(a, &places.Place{Title: "coffee bean"}))
+}
+
+func TestDetector_Find(t *testing.T) {
+	store := placestest.New(nil)
+	for i := 0; i < 250; i++ {
+		store.Places[1] = append(store.Places[1], &places.Place{Id: uint64(i + 1), Title: fmt.Sprintf("Place %d", i)})
+	}
+	store.Places[1] = append(store.Places[1],
+		&places.Place{Id: 1000, Title: "Café Pushkin", Address: "Tverskoy bulvar 26А"},
+		&places.Place{Id: 1001, Title: "Pushkin", Address: "Tverskoy bulvar 26"},
+	)
+	d, err := NewDetector(&Config{}, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	matches, err := d.Find(context.Background(), 1, &places.Place{Title: "Cafe Pushkin", Address: "Tverskoy blvd 26a"})
+	assert.NoError(t, err)
+	if assert.Len(t, matches, 1) {
+		assert.Equal(t, uint64(1000), matches[0].Place.Id)
+	}
+
+	matches, err = d.Find(context.Background(), 2, &places.Place{Title: "Cafe Pushkin"})
+	assert.NoError(t, err)
+	assert.Empty(t, matches)
+
+	_, err = NewDetector(&Config{Threshold: 2}, store)
+	assert.Error(t, err)
+}
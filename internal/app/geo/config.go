@@ -0,0 +1,11 @@
+package geo
+
+import "time"
+
+// Config for nearby search over places with coordinates
+type Config struct {
+	// SyncInterval is how often index is reconciled with places store, 10m by default
+	SyncInterval time.Duration `yaml:"sync_interval"`
+	// MaxRadius of nearby search in meters, 50 km by default
+	MaxRadius float64 `yaml:"max_radius"`
+}
@@ -0,0 +1,203 @@
+package geo
+
+import (
+	"chillit-rest-gateway/internal/app/places"
+	"chillit-rest-gateway/internal/app/placesync"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// precisions of geohash cells indexed, from about 39x20 km to 1.2x0.6 km
+var precisions = []int{4, 5, 6}
+
+// maxCells is number of cells searched, finest precision within it is chosen for radius
+const maxCells = 64
+
+// Request of nearby search
+type Request struct {
+	Latitude  float64
+	Longitude float64
+	// Radius in meters
+	Radius float64
+	// CityID limits search to city if set
+	CityID uint64
+	Limit  int
+}
+
+// Hit is place within radius with distance to it in meters
+type Hit struct {
+	Place    *places.Place
+	CityID   uint64
+	Distance float64
+}
+
+type entry struct {
+	place  *places.Place
+	cityID uint64
+	hash   string
+}
+
+// grid keeps places by geohash cells of every indexed precision
+type grid struct {
+	entries map[uint64]*entry
+	cells   map[int]map[string][]uint64
+}
+
+func newGrid() *grid {
+	g := &grid{entries: make(map[uint64]*entry), cells: make(map[int]map[string][]uint64)}
+	for _, precision := range precisions {
+		g.cells[precision] = make(map[string][]uint64)
+	}
+	return g
+}
+
+func (g *grid) put(e *entry) {
+	g.delete(e.place.GetId())
+	location := e.place.GetLocation()
+	if location == nil {
+		return
+	}
+	e.hash = Encode(location.GetLatitude(), location.GetLongitude(), precisions[len(precisions)-1])
+	g.entries[e.place.GetId()] = e
+	for _, precision := range precisions {
+		// Cell of lower precision is prefix of geohash
+		cell := e.hash[:precision]
+		g.cells[precision][cell] = append(g.cells[precision][cell], e.place.GetId())
+	}
+}
+
+func (g *grid) delete(id uint64) {
+	e := g.entries[id]
+	if e == nil {
+		return
+	}
+	delete(g.entries, id)
+	for _, precision := range precisions {
+		cell := e.hash[:precision]
+		ids := g.cells[precision][cell]
+		for i, other := range ids {
+			if other == id {
+				ids = append(ids[:i], ids[i+1:]...)
+				break
+			}
+		}
+		if len(ids) == 0 {
+			delete(g.cells[precision], cell)
+		} else {
+			g.cells[precision][cell] = ids
+		}
+	}
+}
+
+// Index finds places near point, reloaded from places store and updated by gateway writes
+type Index struct {
+	config *Config
+
+	mu   sync.RWMutex
+	grid *grid
+}
+
+// NewIndex creates empty index, it is filled by placesync.Syncer
+func NewIndex(config *Config) (*Index, error) {
+	if config == nil {
+		return nil, errors.New("[ NewIndex ] <nil> config")
+	}
+	if config.SyncInterval <= 0 {
+		config.SyncInterval = 10 * time.Minute
+	}
+	if config.MaxRadius <= 0 {
+		config.MaxRadius = 50000
+	}
+	return &Index{config: config, grid: newGrid()}, nil
+}
+
+// Config returns config with defaults applied
+func (i *Index) Config() *Config {
+	return i.config
+}
+
+// Put indexes place of city, place without location is removed
+func (i *Index) Put(cityID uint64, place *places.Place) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.grid.put(&entry{place: place, cityID: cityID})
+}
+
+// Update reindexes changed place in its known city, unknown place is left for next sync
+func (i *Index) Update(place *places.Place) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	existing := i.grid.entries[place.GetId()]
+	if existing == nil {
+		return
+	}
+	i.grid.put(&entry{place: place, cityID: existing.cityID})
+}
+
+// Delete removes place from index
+func (i *Index) Delete(id uint64) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.grid.delete(id)
+}
+
+// Reload rebuilds index from snapshot, keeping places written since snapshot was started as they are
+func (i *Index) Reload(snapshot *placesync.Snapshot) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	synced := newGrid()
+	for _, city := range snapshot.Cities {
+		for _, place := range snapshot.Places[city.GetId()] {
+			if !snapshot.Written(place.GetId()) {
+				synced.put(&entry{place: place, cityID: city.GetId()})
+			}
+		}
+	}
+	for id, e := range i.grid.entries {
+		if snapshot.Written(id) {
+			synced.put(&entry{place: e.place, cityID: e.cityID})
+		}
+	}
+	i.grid = synced
+	return nil
+}
+
+// Nearby returns places within radius of point, nearest first
+func (i *Index) Nearby(req *Request) []*Hit {
+	precision := precisions[0]
+	for _, p := range precisions {
+		if cells(req.Latitude, req.Longitude, req.Radius, p) <= maxCells {
+			precision = p
+		}
+	}
+
+	i.mu.RLock()
+	var hits []*Hit
+	for _, cell := range cover(req.Latitude, req.Longitude, req.Radius, precision) {
+		for _, id := range i.grid.cells[precision][cell] {
+			e := i.grid.entries[id]
+			if req.CityID != 0 && e.cityID != req.CityID {
+				continue
+			}
+			location := e.place.GetLocation()
+			distance := Distance(req.Latitude, req.Longitude, location.GetLatitude(), location.GetLongitude())
+			if distance <= req.Radius {
+				hits = append(hits, &Hit{Place: e.place, CityID: e.cityID, Distance: distance})
+			}
+		}
+	}
+	i.mu.RUnlock()
+
+	sort.Slice(hits, func(a, b int) bool {
+		if hits[a].Distance != hits[b].Distance {
+			return hits[a].Distance < hits[b].Distance
+		}
+		return hits[a].Place.GetId() < hits[b].Place.GetId()
+	})
+	if req.Limit > 0 && len(hits) > req.Limit {
+		hits = hits[:req.Limit]
+	}
+	return hits
+}
@@ -0,0 +1,82 @@
+package geo
+
+import (
+	"chillit-rest-gateway/internal/app/places"
+	"chillit-rest-gateway/internal/app/places/placestest"
+	"chillit-rest-gateway/internal/app/placesync"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func at(lat, lon float64) *places.Location {
+	return &places.Location{Latitude: lat, Longitude: lon}
+}
+
+func TestEncode(t *testing.T) {
+	assert.Equal(t, "ezs42", Encode(42.605, -5.603, 5))
+	assert.Equal(t, "ucfv0j", Encode(55.7520, 37.6175, 6))
+}
+
+func TestDistance(t *testing.T) {
+	// Moscow to Saint Petersburg
+	assert.InDelta(t, 634000, Distance(55.7558, 37.6173, 59.9343, 30.3351), 2000)
+	assert.Equal(t, 0.0, Distance(10, 20, 10, 20))
+}
+
+func TestIndex(t *testing.T) {
+	store := placestest.New(map[uint64][]*places.Place{
+		1: {
+			{Id: 1, Title: "Kremlin", Location: at(55.7520, 37.6175)},
+			{Id: 2, Title: "Red Square", Location: at(55.7539, 37.6208)},
+			{Id: 3, Title: "Gorky Park", Location: at(55.7298, 37.6036)},
+			{Id: 4, Title: "No Coordinates"},
+			{Id: 5, Title: "Sheremetyevo", Location: at(55.9726, 37.4146)},
+		},
+		2: {
+			{Id: 6, Title: "East of Antimeridian", Location: at(-16.5, 179.999)},
+		},
+	})
+	index, err := NewIndex(&Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	syncer := placesync.NewSyncer(store, logrus.New())
+	syncer.Add(index, time.Hour)
+	assert.NoError(t, syncer.Sync(context.Background()))
+
+	ids := func(hits []*Hit) []uint64 {
+		list := []uint64{}
+		for _, hit := range hits {
+			list = append(list, hit.Place.Id)
+		}
+		return list
+	}
+
+	// Nearest first, within radius only
+	hits := index.Nearby(&Request{Latitude: 55.7525, Longitude: 37.6180, Radius: 1000})
+	assert.Equal(t, []uint64{1, 2}, ids(hits))
+	assert.InDelta(t, 66, hits[0].Distance, 5)
+	assert.Equal(t, uint64(1), hits[0].CityID)
+	assert.Equal(t, []uint64{1, 2, 3}, ids(index.Nearby(&Request{Latitude: 55.7525, Longitude: 37.6180, Radius: 5000})))
+	assert.Equal(t, []uint64{1, 2, 3, 5}, ids(index.Nearby(&Request{Latitude: 55.7525, Longitude: 37.6180, Radius: 50000})))
+	assert.Equal(t, []uint64{1}, ids(index.Nearby(&Request{Latitude: 55.7525, Longitude: 37.6180, Radius: 50000, Limit: 1})))
+	assert.Empty(t, index.Nearby(&Request{Latitude: 55.7525, Longitude: 37.6180, Radius: 50000, CityID: 2}))
+
+	// Search crosses antimeridian
+	assert.Equal(t, []uint64{6}, ids(index.Nearby(&Request{Latitude: -16.5, Longitude: -179.999, Radius: 1000})))
+
+	// Writes through gateway
+	index.Put(1, &places.Place{Id: 7, Title: "Bolshoi", Location: at(55.7601, 37.6186)})
+	index.Update(&places.Place{Id: 2, Title: "Red Square"})
+	index.Update(&places.Place{Id: 9, Title: "Unknown", Location: at(55.7525, 37.6180)})
+	index.Delete(1)
+	assert.Equal(t, []uint64{7}, ids(index.Nearby(&Request{Latitude: 55.7525, Longitude: 37.6180, Radius: 1000})))
+
+	// Sync restores state of places store
+	assert.NoError(t, syncer.Sync(context.Background()))
+	assert.Equal(t, []uint64{1, 2}, ids(index.Nearby(&Request{Latitude: 55.7525, Longitude: 37.6180, Radius: 1000})))
+}
@@ -0,0 +1,114 @@
+package geo
+
+import (
+	"math"
+	"strings"
+)
+
+const base32 = "0123456789bcdefghjkmnpqrstuvwxyz"
+
+// earthRadius is mean radius of Earth in meters
+const earthRadius = 6371008.8
+
+// Encode returns geohash of point with precision characters
+func Encode(lat, lon float64, precision int) string {
+	minLat, maxLat := -90.0, 90.0
+	minLon, maxLon := -180.0, 180.0
+	var b strings.Builder
+	bits, ch := 0, 0
+	even := true
+	for b.Len() < precision {
+		if even {
+			mid := (minLon + maxLon) / 2
+			if lon >= mid {
+				ch = ch<<1 | 1
+				minLon = mid
+			} else {
+				ch <<= 1
+				maxLon = mid
+			}
+		} else {
+			mid := (minLat + maxLat) / 2
+			if lat >= mid {
+				ch = ch<<1 | 1
+				minLat = mid
+			} else {
+				ch <<= 1
+				maxLat = mid
+			}
+		}
+		even = !even
+		if bits++; bits == 5 {
+			b.WriteByte(base32[ch])
+			bits, ch = 0, 0
+		}
+	}
+	return b.String()
+}
+
+// cellSize returns height and width in degrees of geohash cell with precision characters
+func cellSize(precision int) (float64, float64) {
+	bits := 5 * precision
+	lonBits := (bits + 1) / 2
+	latBits := bits / 2
+	return 180 / math.Exp2(float64(latBits)), 360 / math.Exp2(float64(lonBits))
+}
+
+// Distance returns great-circle distance between points in meters
+func Distance(lat1, lon1, lat2, lon2 float64) float64 {
+	phi1, phi2 := lat1*math.Pi/180, lat2*math.Pi/180
+	dPhi := (lat2 - lat1) * math.Pi / 180
+	dLambda := (lon2 - lon1) * math.Pi / 180
+	a := math.Sin(dPhi/2)*math.Sin(dPhi/2) + math.Cos(phi1)*math.Cos(phi2)*math.Sin(dLambda/2)*math.Sin(dLambda/2)
+	return 2 * earthRadius * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+// boundingBox returns latitudes and longitudes enclosing circle, longitudes may cross antimeridian
+func boundingBox(lat, lon, radius float64) (float64, float64, float64, float64) {
+	dLat := radius / earthRadius * 180 / math.Pi
+	minLat, maxLat := math.Max(lat-dLat, -90), math.Min(lat+dLat, 90)
+	if minLat == -90 || maxLat == 90 {
+		// Circle around pole covers every longitude
+		return minLat, maxLat, -180, 180
+	}
+	dLon := dLat / math.Cos(lat*math.Pi/180)
+	if dLon >= 180 {
+		return minLat, maxLat, -180, 180
+	}
+	return minLat, maxLat, lon - dLon, lon + dLon
+}
+
+// wrapLon brings longitude into [-180, 180)
+func wrapLon(lon float64) float64 {
+	lon = math.Mod(lon+180, 360)
+	if lon < 0 {
+		lon += 360
+	}
+	return lon - 180
+}
+
+// cover returns geohashes of cells with precision characters intersecting bounding box of circle
+func cover(lat, lon, radius float64, precision int) []string {
+	minLat, maxLat, minLon, maxLon := boundingBox(lat, lon, radius)
+	height, width := cellSize(precision)
+	seen := make(map[string]bool)
+	var hashes []string
+	// Centers of cells from the one containing min corner up to max corner
+	for y := math.Floor((minLat+90)/height)*height - 90 + height/2; y-height/2 <= maxLat; y += height {
+		for x := math.Floor((minLon+180)/width)*width - 180 + width/2; x-width/2 <= maxLon; x += width {
+			hash := Encode(math.Min(y, 90), wrapLon(x), precision)
+			if !seen[hash] {
+				seen[hash] = true
+				hashes = append(hashes, hash)
+			}
+		}
+	}
+	return hashes
+}
+
+// cells returns number of cells with precision characters intersecting bounding box of circle
+func cells(lat, lon, radius float64, precision int) float64 {
+	minLat, maxLat, minLon, maxLon := boundingBox(lat, lon, radius)
+	height, width := cellSize(precision)
+	return (math.Floor((maxLat-minLat)/height) + 2) * (math.Floor((maxLon-minLon)/width) + 2)
+}
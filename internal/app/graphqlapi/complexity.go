@@ -0,0 +1,52 @@
+package graphqlapi
+
+import (
+	"encoding/json"
+
+	"github.com/vektah/gqlparser/ast"
+)
+
+// complexity estimates number of fields resolved by operation. Children of field with
+// `first` argument are counted `first` times, children of other list fields defaultPageSize
+// times, sized is set for list inside of paginated field, e.g. connection nodes
+func complexity(set ast.SelectionSet, variables map[string]interface{}, sized bool) int {
+	total := 0
+	for _, selection := range set {
+		switch selection := selection.(type) {
+		case *ast.Field:
+			multiplier, childSized := 1, false
+			if definition := selection.Definition; definition == nil {
+				// Unknown field is rejected by executor
+			} else if definition.Arguments.ForName("first") != nil {
+				multiplier, childSized = defaultPageSize, true
+				if first, ok := intArgument(selection.ArgumentMap(variables)["first"]); ok && first > 0 {
+					multiplier = first
+				}
+			} else if !sized && definition.Type.Elem != nil {
+				multiplier = defaultPageSize
+			}
+			total += 1 + multiplier*complexity(selection.SelectionSet, variables, childSized)
+		case *ast.InlineFragment:
+			total += complexity(selection.SelectionSet, variables, sized)
+		case *ast.FragmentSpread:
+			if selection.Definition != nil {
+				total += complexity(selection.Definition.SelectionSet, variables, sized)
+			}
+		}
+	}
+	return total
+}
+
+// intArgument converts literal or variable value to int
+func intArgument(value interface{}) (int, bool) {
+	switch value := value.(type) {
+	case int64:
+		return int(value), true
+	case float64:
+		return int(value), true
+	case json.Number:
+		n, err := value.Int64()
+		return int(n), err == nil
+	}
+	return 0, false
+}
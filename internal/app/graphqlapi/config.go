@@ -0,0 +1,13 @@
+package graphqlapi
+
+import "time"
+
+// Config for GraphQL endpoint
+type Config struct {
+	// MaxDepth of selections, 8 by default
+	MaxDepth int `yaml:"max_depth"`
+	// MaxComplexity is limit of estimated number of resolved fields, 1000 by default
+	MaxComplexity int `yaml:"max_complexity"`
+	// BatchWait is how long loaders collect keys before calling places store, 2ms by default
+	BatchWait time.Duration `yaml:"batch_wait"`
+}
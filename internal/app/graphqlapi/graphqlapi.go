@@ -0,0 +1,92 @@
+package graphqlapi
+
+import (
+	"chillit-rest-gateway/internal/app/places"
+	"context"
+	"errors"
+	"time"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	gqlerrors "github.com/graph-gophers/graphql-go/errors"
+	"github.com/vektah/gqlparser"
+	"github.com/vektah/gqlparser/ast"
+)
+
+// Request is GraphQL query sent over HTTP
+type Request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+}
+
+// Response is result of query, Data is empty when query was rejected before execution
+type Response = graphql.Response
+
+// Executor runs queries against places store
+type Executor struct {
+	client        places.PlacesStoreClient
+	schema        *graphql.Schema
+	astSchema     *ast.Schema
+	maxComplexity int
+	batchWait     time.Duration
+}
+
+// NewExecutor parses schema and applies limits from config
+func NewExecutor(config *Config, client places.PlacesStoreClient) (*Executor, error) {
+	if config == nil {
+		return nil, errors.New("[ NewExecutor ] <nil> config")
+	}
+	if config.MaxDepth <= 0 {
+		config.MaxDepth = 8
+	}
+	if config.MaxComplexity <= 0 {
+		config.MaxComplexity = 1000
+	}
+	if config.BatchWait <= 0 {
+		config.BatchWait = 2 * time.Millisecond
+	}
+
+	schema, err := graphql.ParseSchema(schemaString, &rootResolver{}, graphql.MaxDepth(config.MaxDepth))
+	if err != nil {
+		return nil, errors.New("[ NewExecutor ] could not parse schema: " + err.Error())
+	}
+	astSchema, gqlErr := gqlparser.LoadSchema(&ast.Source{Name: "schema.graphql", Input: schemaString})
+	if gqlErr != nil {
+		return nil, errors.New("[ NewExecutor ] could not load schema: " + gqlErr.Error())
+	}
+
+	return &Executor{
+		client:        client,
+		schema:        schema,
+		astSchema:     astSchema,
+		maxComplexity: config.MaxComplexity,
+		batchWait:     config.BatchWait,
+	}, nil
+}
+
+// Exec runs query with loaders of its own
+func (e *Executor) Exec(ctx context.Context, req *Request) *Response {
+	if err := e.checkComplexity(req); err != nil {
+		return &Response{Errors: []*gqlerrors.QueryError{err}}
+	}
+	ctx = context.WithValue(ctx, loadersContextKey{}, newLoaders(ctx, e.client, e.batchWait))
+	return e.schema.Exec(ctx, req.Query, req.OperationName, req.Variables)
+}
+
+// checkComplexity rejects operation whose estimated complexity exceeds limit.
+// Invalid queries pass, executor reports their errors
+func (e *Executor) checkComplexity(req *Request) *gqlerrors.QueryError {
+	doc, errs := gqlparser.LoadQuery(e.astSchema, req.Query)
+	if len(errs) > 0 {
+		return nil
+	}
+	for _, op := range doc.Operations {
+		if req.OperationName != "" && op.Name != req.OperationName {
+			continue
+		}
+		if c := complexity(op.SelectionSet, req.Variables, false); c > e.maxComplexity {
+			return gqlerrors.Errorf("query complexity %d exceeds limit %d", c, e.maxComplexity)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package graphqlapi
+
+import (
+	"chillit-rest-gateway/internal/app/places"
+	"chillit-rest-gateway/internal/app/places/placestest"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestStore() *placestest.Store {
+	return placestest.New(map[uint64][]*places.Place{
+		1: {{Id: 1, Title: "Coffee Bean"}, {Id: 2, Title: "Tea House"}, {Id: 3, Title: "Bakery"}},
+		2: {{Id: 4, Title: "Chak-chak"}, {Id: 5, Title: "Tea House"}, {Id: 6, Title: "Bakery"}},
+	})
+}
+
+func TestExecutor_Batching(t *testing.T) {
+	store := newTestStore()
+	executor, err := NewExecutor(&Config{}, store)
+	assert.NoError(t, err)
+
+	resp := executor.Exec(context.Background(), &Request{Query: `{
+		cities { id places(first: 2) { nodes { title } } }
+		moscow: city(id: "1") { title places(first: 2) { pageInfo { hasNextPage } } randomPlace { title } }
+	}`})
+	assert.Empty(t, resp.Errors)
+	assert.Equal(t, 1, store.Calls("GetCities"))
+	assert.Equal(t, 2, store.Calls("GetPlacesByCityID"))
+
+	var data struct {
+		Cities []struct {
+			Places struct {
+				Nodes []struct{ Title string }
+			}
+		}
+		Moscow struct {
+			Places struct {
+				PageInfo struct{ HasNextPage bool }
+			}
+			RandomPlace struct{ Title string }
+		}
+	}
+	assert.NoError(t, json.Unmarshal(resp.Data, &data))
+	assert.Len(t, data.Cities, 2)
+	assert.Len(t, data.Cities[0].Places.Nodes, 2)
+	assert.True(t, data.Moscow.Places.PageInfo.HasNextPage)
+	assert.Equal(t, "Coffee Bean", data.Moscow.RandomPlace.Title)
+}
+
+func TestExecutor_Pagination(t *testing.T) {
+	executor, err := NewExecutor(&Config{}, newTestStore())
+	assert.NoError(t, err)
+
+	query := `query($after: String) { places(cityId: "1", first: 2, after: $after) { nodes { id } pageInfo { hasNextPage endCursor } } }`
+	var data struct {
+		Places struct {
+			Nodes    []struct{ ID string }
+			PageInfo struct {
+				HasNextPage bool
+				EndCursor   string
+			}
+		}
+	}
+	resp := executor.Exec(context.Background(), &Request{Query: query})
+	assert.Empty(t, resp.Errors)
+	assert.NoError(t, json.Unmarshal(resp.Data, &data))
+	assert.True(t, data.Places.PageInfo.HasNextPage)
+
+	resp = executor.Exec(context.Background(), &Request{
+		Query:     query,
+		Variables: map[string]interface{}{"after": data.Places.PageInfo.EndCursor},
+	})
+	assert.Empty(t, resp.Errors)
+	assert.NoError(t, json.Unmarshal(resp.Data, &data))
+	assert.False(t, data.Places.PageInfo.HasNextPage)
+	if assert.Len(t, data.Places.Nodes, 1) {
+		assert.Equal(t, "3", data.Places.Nodes[0].ID)
+	}
+
+	resp = executor.Exec(context.Background(), &Request{Query: `{ places(cityId: "1", after: "bogus") { nodes { id } } }`})
+	assert.NotEmpty(t, resp.Errors)
+}
+
+func TestExecutor_Limits(t *testing.T) {
+	executor, err := NewExecutor(&Config{MaxDepth: 3}, newTestStore())
+	assert.NoError(t, err)
+
+	resp := executor.Exec(context.Background(), &Request{Query: `{ cities { places { nodes { title } } } }`})
+	if assert.Len(t, resp.Errors, 1) {
+		assert.Contains(t, resp.Errors[0].Message, "depth")
+	}
+
+	executor, err = NewExecutor(&Config{MaxComplexity: 50}, newTestStore())
+	assert.NoError(t, err)
+
+	resp = executor.Exec(context.Background(), &Request{
+		Query:     `query($n: Int) { places(cityId: "1", first: $n) { nodes { title address } } }`,
+		Variables: map[string]interface{}{"n": float64(30)},
+	})
+	if assert.Len(t, resp.Errors, 1) {
+		assert.Contains(t, resp.Errors[0].Message, "complexity")
+	}
+
+	resp = executor.Exec(context.Background(), &Request{Query: `{ places(cityId: "1", first: 5) { nodes { title address } } }`})
+	assert.Empty(t, resp.Errors)
+}
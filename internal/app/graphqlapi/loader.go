@@ -0,0 +1,126 @@
+package graphqlapi
+
+import (
+	"chillit-rest-gateway/internal/app/places"
+	"context"
+	"sync"
+	"time"
+)
+
+// placesKey identifies page of city places
+type placesKey struct {
+	cityID uint64
+	offset uint64
+	amount uint64
+}
+
+// result of loaded key, done is closed when value is set
+type result struct {
+	done  chan struct{}
+	value interface{}
+	err   error
+}
+
+// loader collects keys requested by resolvers during wait and loads them
+// as one batch, every key is loaded once per query
+type loader struct {
+	wait  time.Duration
+	fetch func(key interface{}) (interface{}, error)
+
+	mu      sync.Mutex
+	results map[interface{}]*result
+	pending []interface{}
+}
+
+func newLoader(wait time.Duration, fetch func(key interface{}) (interface{}, error)) *loader {
+	return &loader{
+		wait:    wait,
+		fetch:   fetch,
+		results: make(map[interface{}]*result),
+	}
+}
+
+// Load waits for value of key
+func (l *loader) Load(ctx context.Context, key interface{}) (interface{}, error) {
+	l.mu.Lock()
+	res, ok := l.results[key]
+	if !ok {
+		res = &result{done: make(chan struct{})}
+		l.results[key] = res
+		if len(l.pending) == 0 {
+			time.AfterFunc(l.wait, l.dispatch)
+		}
+		l.pending = append(l.pending, key)
+	}
+	l.mu.Unlock()
+
+	select {
+	case <-res.done:
+		return res.value, res.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// dispatch loads pending keys concurrently, places store has no batch RPCs
+func (l *loader) dispatch() {
+	l.mu.Lock()
+	batch := l.pending
+	l.pending = nil
+	results := make([]*result, len(batch))
+	for i, key := range batch {
+		results[i] = l.results[key]
+	}
+	l.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for i := range batch {
+		wg.Add(1)
+		go func(key interface{}, res *result) {
+			defer wg.Done()
+			res.value, res.err = l.fetch(key)
+			close(res.done)
+		}(batch[i], results[i])
+	}
+	wg.Wait()
+}
+
+// loaders are created for every query, so results are not shared between callers
+type loaders struct {
+	cities       *loader
+	places       *loader
+	randomPlaces *loader
+}
+
+func newLoaders(ctx context.Context, client places.PlacesStoreClient, wait time.Duration) *loaders {
+	return &loaders{
+		cities: newLoader(wait, func(interface{}) (interface{}, error) {
+			resp, err := client.GetCities(ctx, &places.GetCitiesRequest{})
+			if err != nil {
+				return nil, err
+			}
+			return resp.GetCities(), nil
+		}),
+		places: newLoader(wait, func(key interface{}) (interface{}, error) {
+			k := key.(placesKey)
+			resp, err := client.GetPlacesByCityID(ctx, &places.GetPlacesByCityIDRequest{
+				CityID: k.cityID,
+				Offset: k.offset,
+				Amount: k.amount,
+			})
+			if err != nil {
+				return nil, err
+			}
+			return resp.GetPlaces(), nil
+		}),
+		randomPlaces: newLoader(wait, func(key interface{}) (interface{}, error) {
+			resp, err := client.GetRandomPlaceByCityName(ctx, &places.GetRandomPlaceByCityNameRequest{
+				CityName: key.(string),
+			})
+			if err != nil {
+				return nil, err
+			}
+			return resp.GetPlace(), nil
+		}),
+	}
+}
@@ -0,0 +1,244 @@
+package graphqlapi
+
+import (
+	"chillit-rest-gateway/internal/app/places"
+	"context"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+
+	graphql "github.com/graph-gophers/graphql-go"
+)
+
+const (
+	defaultPageSize = 10
+	maxPageSize     = 100
+	cursorPrefix    = "offset:"
+)
+
+type loadersContextKey struct{}
+
+func loadersFromContext(ctx context.Context) *loaders {
+	return ctx.Value(loadersContextKey{}).(*loaders)
+}
+
+// rootResolver resolves Query fields
+type rootResolver struct{}
+
+func (r *rootResolver) Cities(ctx context.Context) ([]*cityResolver, error) {
+	cities, err := loadCities(ctx)
+	if err != nil {
+		return nil, err
+	}
+	resolvers := make([]*cityResolver, len(cities))
+	for i, city := range cities {
+		resolvers[i] = &cityResolver{city: city}
+	}
+	return resolvers, nil
+}
+
+func (r *rootResolver) City(ctx context.Context, args struct{ ID graphql.ID }) (*cityResolver, error) {
+	id, err := parseID(args.ID)
+	if err != nil {
+		return nil, err
+	}
+	cities, err := loadCities(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, city := range cities {
+		if city.GetId() == id {
+			return &cityResolver{city: city}, nil
+		}
+	}
+	return nil, nil
+}
+
+type pageArgs struct {
+	First *int32
+	After *string
+}
+
+func (r *rootResolver) Places(ctx context.Context, args struct {
+	CityID graphql.ID
+	First  *int32
+	After  *string
+}) (*connectionResolver, error) {
+	id, err := parseID(args.CityID)
+	if err != nil {
+		return nil, err
+	}
+	return loadPage(ctx, id, pageArgs{First: args.First, After: args.After})
+}
+
+func (r *rootResolver) RandomPlace(ctx context.Context, args struct{ City string }) (*placeResolver, error) {
+	return loadRandomPlace(ctx, args.City)
+}
+
+type cityResolver struct {
+	city *places.City
+}
+
+func (r *cityResolver) ID() graphql.ID {
+	return formatID(r.city.GetId())
+}
+
+func (r *cityResolver) Title() string {
+	return r.city.GetTitle()
+}
+
+func (r *cityResolver) Places(ctx context.Context, args pageArgs) (*connectionResolver, error) {
+	return loadPage(ctx, r.city.GetId(), args)
+}
+
+func (r *cityResolver) RandomPlace(ctx context.Context) (*placeResolver, error) {
+	return loadRandomPlace(ctx, r.city.GetTitle())
+}
+
+type placeResolver struct {
+	place *places.Place
+}
+
+func (r *placeResolver) ID() graphql.ID {
+	return formatID(r.place.GetId())
+}
+
+func (r *placeResolver) Title() string {
+	return r.place.GetTitle()
+}
+
+func (r *placeResolver) Address() string {
+	return r.place.GetAddress()
+}
+
+func (r *placeResolver) Description() string {
+	return r.place.GetDescription()
+}
+
+func (r *placeResolver) ImageURL() string {
+	return r.place.GetImgURL()
+}
+
+type connectionResolver struct {
+	nodes       []*placeResolver
+	hasNextPage bool
+	endCursor   *string
+}
+
+func (r *connectionResolver) Nodes() []*placeResolver {
+	return r.nodes
+}
+
+func (r *connectionResolver) PageInfo() *pageInfoResolver {
+	return &pageInfoResolver{r}
+}
+
+type pageInfoResolver struct {
+	connection *connectionResolver
+}
+
+func (r *pageInfoResolver) HasNextPage() bool {
+	return r.connection.hasNextPage
+}
+
+func (r *pageInfoResolver) EndCursor() *string {
+	return r.connection.endCursor
+}
+
+func loadCities(ctx context.Context) ([]*places.City, error) {
+	cities, err := loadersFromContext(ctx).cities.Load(ctx, struct{}{})
+	if err != nil {
+		return nil, storeError(err)
+	}
+	return cities.([]*places.City), nil
+}
+
+// loadPage requests one place more than asked to know whether next page exists
+func loadPage(ctx context.Context, cityID uint64, args pageArgs) (*connectionResolver, error) {
+	first := defaultPageSize
+	if args.First != nil {
+		first = int(*args.First)
+	}
+	if first < 1 || first > maxPageSize {
+		return nil, errors.New("first must be between 1 and " + strconv.Itoa(maxPageSize))
+	}
+	var offset uint64
+	if args.After != nil {
+		var err error
+		if offset, err = parseCursor(*args.After); err != nil {
+			return nil, err
+		}
+	}
+
+	loaded, err := loadersFromContext(ctx).places.Load(ctx, placesKey{
+		cityID: cityID,
+		offset: offset,
+		amount: uint64(first) + 1,
+	})
+	if err != nil {
+		return nil, storeError(err)
+	}
+	cityPlaces := loaded.([]*places.Place)
+
+	connection := &connectionResolver{}
+	if len(cityPlaces) > first {
+		cityPlaces = cityPlaces[:first]
+		connection.hasNextPage = true
+	}
+	for _, place := range cityPlaces {
+		connection.nodes = append(connection.nodes, &placeResolver{place: place})
+	}
+	if len(cityPlaces) > 0 {
+		cursor := formatCursor(offset + uint64(len(cityPlaces)))
+		connection.endCursor = &cursor
+	}
+	return connection, nil
+}
+
+func loadRandomPlace(ctx context.Context, cityName string) (*placeResolver, error) {
+	place, err := loadersFromContext(ctx).randomPlaces.Load(ctx, cityName)
+	if err != nil {
+		return nil, storeError(err)
+	}
+	if place.(*places.Place) == nil {
+		return nil, nil
+	}
+	return &placeResolver{place: place.(*places.Place)}, nil
+}
+
+// storeError hides details of places store failures from clients
+func storeError(err error) error {
+	if err == context.Canceled || err == context.DeadlineExceeded {
+		return err
+	}
+	return errors.New("places store call failed")
+}
+
+func formatID(id uint64) graphql.ID {
+	return graphql.ID(strconv.FormatUint(id, 10))
+}
+
+func parseID(id graphql.ID) (uint64, error) {
+	value, err := strconv.ParseUint(string(id), 10, 64)
+	if err != nil {
+		return 0, errors.New("invalid id " + strconv.Quote(string(id)))
+	}
+	return value, nil
+}
+
+// Cursors are opaque for clients, they encode offset of next place
+func formatCursor(offset uint64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(cursorPrefix + strconv.FormatUint(offset, 10)))
+}
+
+func parseCursor(cursor string) (uint64, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err == nil && strings.HasPrefix(string(decoded), cursorPrefix) {
+		offset, err := strconv.ParseUint(strings.TrimPrefix(string(decoded), cursorPrefix), 10, 64)
+		if err == nil {
+			return offset, nil
+		}
+	}
+	return 0, errors.New("invalid cursor " + strconv.Quote(cursor))
+}
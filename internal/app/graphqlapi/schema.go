@@ -0,0 +1,40 @@
+package graphqlapi
+
+// schemaString is shared by executor and complexity estimation
+const schemaString = `
+schema {
+	query: Query
+}
+
+type Query {
+	cities: [City!]!
+	city(id: ID!): City
+	places(cityId: ID!, first: Int, after: String): PlaceConnection!
+	randomPlace(city: String!): Place
+}
+
+type City {
+	id: ID!
+	title: String!
+	places(first: Int, after: String): PlaceConnection!
+	randomPlace: Place
+}
+
+type Place {
+	id: ID!
+	title: String!
+	address: String!
+	description: String!
+	imageUrl: String!
+}
+
+type PlaceConnection {
+	nodes: [Place!]!
+	pageInfo: PageInfo!
+}
+
+type PageInfo {
+	hasNextPage: Boolean!
+	endCursor: String
+}
+`
@@ -0,0 +1,7 @@
+package grpcweb
+
+// Config for gRPC-Web and Connect proxy
+type Config struct {
+	// MaxMessageSize of request message in bytes, 4 MiB by default
+	MaxMessageSize int `yaml:"max_message_size"`
+}
@@ -0,0 +1,120 @@
+package grpcweb
+
+import (
+	"bytes"
+	"chillit-rest-gateway/internal/app/transcoding"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	contentTypeConnectProto = "application/proto"
+	contentTypeConnectJSON  = "application/json"
+)
+
+// connectCodes are names of status codes in Connect protocol
+var connectCodes = map[codes.Code]string{
+	codes.Canceled:           "canceled",
+	codes.Unknown:            "unknown",
+	codes.InvalidArgument:    "invalid_argument",
+	codes.DeadlineExceeded:   "deadline_exceeded",
+	codes.NotFound:           "not_found",
+	codes.AlreadyExists:      "already_exists",
+	codes.PermissionDenied:   "permission_denied",
+	codes.ResourceExhausted:  "resource_exhausted",
+	codes.FailedPrecondition: "failed_precondition",
+	codes.Aborted:            "aborted",
+	codes.OutOfRange:         "out_of_range",
+	codes.Unimplemented:      "unimplemented",
+	codes.Internal:           "internal",
+	codes.Unavailable:        "unavailable",
+	codes.DataLoss:           "data_loss",
+	codes.Unauthenticated:    "unauthenticated",
+}
+
+// connectError is body of failed Connect call
+type connectError struct {
+	Code    string `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+// serveConnect handles unary Connect call, message is whole body in binary or JSON encoding
+func (p *Proxy) serveConnect(w http.ResponseWriter, r *http.Request, m *method, contentType string) {
+	data, err := ioutil.ReadAll(io.LimitReader(r.Body, int64(p.maxMessageSize)+1))
+	if err != nil {
+		writeConnectError(w, errorStatus(codes.InvalidArgument, errors.New("could not read message")))
+		return
+	}
+	if len(data) > p.maxMessageSize {
+		writeConnectError(w, status.New(codes.ResourceExhausted, "message is larger than "+strconv.Itoa(p.maxMessageSize)+" bytes"))
+		return
+	}
+
+	req := m.newRequest()
+	if contentType == contentTypeConnectJSON {
+		err = jsonpb.Unmarshal(bytes.NewReader(data), req)
+		if err == io.EOF {
+			err = nil
+		}
+	} else {
+		err = proto.Unmarshal(data, req)
+	}
+	if err != nil {
+		writeConnectError(w, errorStatus(codes.InvalidArgument, errors.New("could not decode message: "+err.Error())))
+		return
+	}
+
+	var timeout time.Duration
+	if value := r.Header.Get("Connect-Timeout-Ms"); value != "" {
+		ms, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || ms < 0 || len(value) > 10 {
+			writeConnectError(w, status.New(codes.InvalidArgument, "invalid connect-timeout-ms "+strconv.Quote(value)))
+			return
+		}
+		timeout = time.Duration(ms) * time.Millisecond
+	}
+	ctx, cancel := withTimeout(r.Context(), timeout)
+	defer cancel()
+
+	resp, st := p.call(ctx, m, req)
+	if st != nil {
+		writeConnectError(w, st)
+		return
+	}
+
+	var buf bytes.Buffer
+	if contentType == contentTypeConnectJSON {
+		err = transcoding.Marshal(&buf, resp)
+	} else {
+		var out []byte
+		out, err = proto.Marshal(resp)
+		buf.Write(out)
+	}
+	if err != nil {
+		writeConnectError(w, status.New(codes.Internal, "could not encode response"))
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf.Bytes())
+}
+
+func writeConnectError(w http.ResponseWriter, st *status.Status) {
+	code, ok := connectCodes[st.Code()]
+	if !ok {
+		code = connectCodes[codes.Unknown]
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(transcoding.HTTPStatus(st.Code()))
+	json.NewEncoder(w).Encode(connectError{Code: code, Message: st.Message()})
+}
@@ -0,0 +1,165 @@
+package grpcweb
+
+import (
+	"chillit-rest-gateway/internal/app/metrics"
+	"chillit-rest-gateway/internal/app/transcoding"
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ServiceName of proxied service as declared in places.proto
+const ServiceName = "places.PlacesStore"
+
+var (
+	contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+	messageType = reflect.TypeOf((*proto.Message)(nil)).Elem()
+	errorType   = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// Proxy forwards unary calls of gRPC-Web and Connect protocols to gRPC client
+type Proxy struct {
+	logger         logrus.FieldLogger
+	maxMessageSize int
+	methods        map[string]*method
+}
+
+type method struct {
+	name        string
+	requestType reflect.Type
+	invoke      reflect.Value
+}
+
+// NewProxy exposes every unary RPC of client, e.g. places.PlacesStoreClient
+func NewProxy(config *Config, client interface{}, logger logrus.FieldLogger) (*Proxy, error) {
+	if config == nil {
+		return nil, errors.New("[ NewProxy ] <nil> config")
+	}
+	if config.MaxMessageSize <= 0 {
+		config.MaxMessageSize = 4 << 20
+	}
+
+	p := &Proxy{
+		logger:         logger,
+		maxMessageSize: config.MaxMessageSize,
+		methods:        make(map[string]*method),
+	}
+	clientValue := reflect.ValueOf(client)
+	for i := 0; i < clientValue.NumMethod(); i++ {
+		t := clientValue.Method(i).Type()
+		if t.NumIn() < 2 || t.In(0) != contextType || !t.In(1).Implements(messageType) ||
+			t.NumOut() != 2 || !t.Out(0).Implements(messageType) || t.Out(1) != errorType {
+			continue
+		}
+		name := clientValue.Type().Method(i).Name
+		p.methods[name] = &method{
+			name:        name,
+			requestType: t.In(1).Elem(),
+			invoke:      clientValue.Method(i),
+		}
+	}
+	if len(p.methods) == 0 {
+		return nil, errors.New("[ NewProxy ] client has no unary RPCs")
+	}
+	return p, nil
+}
+
+// Methods returns sorted names of proxied RPCs
+func (p *Proxy) Methods() []string {
+	names := make([]string, 0, len(p.methods))
+	for name := range p.methods {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Path returns HTTP path of RPC, e.g. /places.PlacesStore/GetCities
+func Path(rpc string) string {
+	return "/" + ServiceName + "/" + rpc
+}
+
+// ServeHTTP calls RPC named by last path segment. Protocol is chosen by content type:
+// application/grpc-web[-text][+proto] for gRPC-Web, application/proto or application/json for Connect
+func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m, ok := p.methods[r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	contentType := strings.TrimSpace(strings.Split(r.Header.Get("Content-Type"), ";")[0])
+	switch {
+	case strings.HasPrefix(contentType, contentTypeGRPCWeb):
+		metrics.RequestsByRPC.Add("grpc-web:"+m.name, 1)
+		p.serveGRPCWeb(w, r, m, contentType)
+	case contentType == contentTypeConnectProto || contentType == contentTypeConnectJSON:
+		metrics.RequestsByRPC.Add("connect:"+m.name, 1)
+		p.serveConnect(w, r, m, contentType)
+	default:
+		w.Header().Set("Accept-Post", strings.Join([]string{contentTypeGRPCWeb, contentTypeGRPCWebText, contentTypeConnectProto, contentTypeConnectJSON}, ", "))
+		w.WriteHeader(http.StatusUnsupportedMediaType)
+	}
+}
+
+// call invokes RPC and hides details of server side failures from clients
+func (p *Proxy) call(ctx context.Context, m *method, req proto.Message) (proto.Message, *status.Status) {
+	out := m.invoke.Call([]reflect.Value{reflect.ValueOf(ctx), reflect.ValueOf(req)})
+	if err, _ := out[1].Interface().(error); err != nil {
+		st, _ := status.FromError(err)
+		if transcoding.HTTPStatus(st.Code()) >= http.StatusInternalServerError {
+			p.logger.Errorf("could not call %s, error: %v", m.name, err)
+			return nil, status.New(st.Code(), "places store call failed")
+		}
+		return nil, st
+	}
+	return out[0].Interface().(proto.Message), nil
+}
+
+func (m *method) newRequest() proto.Message {
+	return reflect.New(m.requestType).Interface().(proto.Message)
+}
+
+// withTimeout applies deadline passed by client
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// parseGRPCTimeout parses grpc-timeout header, e.g. "100m" or "5S"
+func parseGRPCTimeout(value string) (time.Duration, error) {
+	if len(value) < 2 || len(value) > 9 {
+		return 0, errors.New("invalid grpc-timeout " + strconv.Quote(value))
+	}
+	units := map[byte]time.Duration{
+		'H': time.Hour,
+		'M': time.Minute,
+		'S': time.Second,
+		'm': time.Millisecond,
+		'u': time.Microsecond,
+		'n': time.Nanosecond,
+	}
+	unit, ok := units[value[len(value)-1]]
+	n, err := strconv.ParseInt(value[:len(value)-1], 10, 64)
+	if !ok || err != nil || n < 0 {
+		return 0, errors.New("invalid grpc-timeout " + strconv.Quote(value))
+	}
+	return time.Duration(n) * unit, nil
+}
+
+// errorStatus converts decoding error to gRPC status
+func errorStatus(code codes.Code, err error) *status.Status {
+	return status.New(code, err.Error())
+}
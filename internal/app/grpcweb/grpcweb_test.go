@@ -0,0 +1,97 @@
+package grpcweb
+
+import (
+	"bytes"
+	"chillit-rest-gateway/internal/app/places"
+	"chillit-rest-gateway/internal/app/places/placestest"
+	"encoding/base64"
+	"encoding/binary"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestProxy(t *testing.T) *Proxy {
+	p, err := NewProxy(&Config{}, placestest.New(map[uint64][]*places.Place{1: {{Id: 1, Title: "Coffee Bean"}}}), logrus.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func frame(flags byte, data []byte) []byte {
+	buf := make([]byte, 5, 5+len(data))
+	buf[0] = flags
+	binary.BigEndian.PutUint32(buf[1:], uint32(len(data)))
+	return append(buf, data...)
+}
+
+func TestProxy_GRPCWeb(t *testing.T) {
+	p := newTestProxy(t)
+	assert.Contains(t, p.Methods(), "GetRandomPlaceByCityName")
+
+	data, _ := proto.Marshal(&places.GetRandomPlaceByCityNameRequest{CityName: "Moscow"})
+	req := httptest.NewRequest(http.MethodPost, Path("GetRandomPlaceByCityName"), bytes.NewReader(frame(0, data)))
+	req.Header.Set("Content-Type", "application/grpc-web+proto")
+	rec := httptest.NewRecorder()
+	p.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	body := rec.Body.Bytes()
+	length := binary.BigEndian.Uint32(body[1:5])
+	var resp places.GetRandomPlaceByCityNameResponse
+	assert.NoError(t, proto.Unmarshal(body[5:5+length], &resp))
+	assert.Equal(t, "Coffee Bean", resp.Place.Title)
+	trailer := body[5+length:]
+	assert.Equal(t, byte(flagTrailer), trailer[0])
+	assert.Equal(t, "grpc-status: 0\r\n", string(trailer[5:]))
+
+	data, _ = proto.Marshal(&places.GetRandomPlaceByCityNameRequest{CityName: "Kazan"})
+	req = httptest.NewRequest(http.MethodPost, Path("GetRandomPlaceByCityName"),
+		strings.NewReader(base64.StdEncoding.EncodeToString(frame(0, data))))
+	req.Header.Set("Content-Type", "application/grpc-web-text")
+	rec = httptest.NewRecorder()
+	p.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/grpc-web-text+proto", rec.Header().Get("Content-Type"))
+	decoded, err := base64.StdEncoding.DecodeString(rec.Body.String())
+	assert.NoError(t, err)
+	assert.Equal(t, "grpc-status: 5\r\ngrpc-message: city Kazan is not found\r\n", string(decoded[5:]))
+}
+
+func TestProxy_Connect(t *testing.T) {
+	p := newTestProxy(t)
+
+	req := httptest.NewRequest(http.MethodPost, Path("GetRandomPlaceByCityName"), strings.NewReader(`{"cityName": "Moscow"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Connect-Protocol-Version", "1")
+	rec := httptest.NewRecorder()
+	p.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"title":"Coffee Bean"`)
+
+	data, _ := proto.Marshal(&places.GetRandomPlaceByCityNameRequest{CityName: "Kazan"})
+	req = httptest.NewRequest(http.MethodPost, Path("GetRandomPlaceByCityName"), bytes.NewReader(data))
+	req.Header.Set("Content-Type", "application/proto")
+	rec = httptest.NewRecorder()
+	p.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.JSONEq(t, `{"code": "not_found", "message": "city Kazan is not found"}`, rec.Body.String())
+
+	req = httptest.NewRequest(http.MethodPost, Path("GetRandomPlaceByCityName"), strings.NewReader(`{"cityName": 1}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec = httptest.NewRecorder()
+	p.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	req = httptest.NewRequest(http.MethodPost, Path("GetRandomPlaceByCityName"), strings.NewReader(`cityName=Moscow`))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec = httptest.NewRecorder()
+	p.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusUnsupportedMediaType, rec.Code)
+}
@@ -0,0 +1,134 @@
+package grpcweb
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	contentTypeGRPCWeb     = "application/grpc-web"
+	contentTypeGRPCWebText = "application/grpc-web-text"
+
+	// Frame flags
+	flagCompressed = 0x01
+	flagTrailer    = 0x80
+)
+
+// serveGRPCWeb handles unary gRPC-Web call, errors are reported in trailer frame with 200 status
+func (p *Proxy) serveGRPCWeb(w http.ResponseWriter, r *http.Request, m *method, contentType string) {
+	text := strings.HasPrefix(contentType, contentTypeGRPCWebText)
+	if text {
+		w.Header().Set("Content-Type", contentTypeGRPCWebText+"+proto")
+	} else {
+		w.Header().Set("Content-Type", contentTypeGRPCWeb+"+proto")
+	}
+
+	var body io.Reader = r.Body
+	if text {
+		body = base64.NewDecoder(base64.StdEncoding, body)
+	}
+	req := m.newRequest()
+	if st := p.readFrame(body, req); st != nil {
+		writeGRPCWeb(w, text, nil, st)
+		return
+	}
+
+	var timeout time.Duration
+	if value := r.Header.Get("Grpc-Timeout"); value != "" {
+		var err error
+		if timeout, err = parseGRPCTimeout(value); err != nil {
+			writeGRPCWeb(w, text, nil, errorStatus(codes.InvalidArgument, err))
+			return
+		}
+	}
+	ctx, cancel := withTimeout(r.Context(), timeout)
+	defer cancel()
+
+	resp, st := p.call(ctx, m, req)
+	writeGRPCWeb(w, text, resp, st)
+}
+
+// readFrame decodes single uncompressed data frame of unary request
+func (p *Proxy) readFrame(body io.Reader, msg proto.Message) *status.Status {
+	var header [5]byte
+	if _, err := io.ReadFull(body, header[:]); err != nil {
+		return errorStatus(codes.InvalidArgument, errors.New("could not read frame header"))
+	}
+	if header[0]&flagCompressed != 0 {
+		return status.New(codes.Unimplemented, "compressed messages are not supported")
+	}
+	length := binary.BigEndian.Uint32(header[1:])
+	if length > uint32(p.maxMessageSize) {
+		return status.New(codes.ResourceExhausted, "message is larger than "+strconv.Itoa(p.maxMessageSize)+" bytes")
+	}
+	data := make([]byte, length)
+	if _, err := io.ReadFull(body, data); err != nil {
+		return errorStatus(codes.InvalidArgument, errors.New("could not read message"))
+	}
+	if err := proto.Unmarshal(data, msg); err != nil {
+		return errorStatus(codes.InvalidArgument, errors.New("could not decode message: "+err.Error()))
+	}
+	return nil
+}
+
+// writeGRPCWeb writes data frame of response, if call succeeded, and trailer frame with status
+func writeGRPCWeb(w http.ResponseWriter, text bool, resp proto.Message, st *status.Status) {
+	var buf bytes.Buffer
+	if st == nil {
+		data, err := proto.Marshal(resp)
+		if err != nil {
+			st = status.New(codes.Internal, "could not encode response")
+		} else {
+			writeFrame(&buf, 0, data)
+			st = status.New(codes.OK, "")
+		}
+	}
+	trailer := "grpc-status: " + strconv.Itoa(int(st.Code())) + "\r\n"
+	if st.Message() != "" {
+		trailer += "grpc-message: " + encodeGRPCMessage(st.Message()) + "\r\n"
+	}
+	writeFrame(&buf, flagTrailer, []byte(trailer))
+
+	w.WriteHeader(http.StatusOK)
+	if text {
+		encoder := base64.NewEncoder(base64.StdEncoding, w)
+		encoder.Write(buf.Bytes())
+		encoder.Close()
+		return
+	}
+	w.Write(buf.Bytes())
+}
+
+func writeFrame(buf *bytes.Buffer, flags byte, data []byte) {
+	var header [5]byte
+	header[0] = flags
+	binary.BigEndian.PutUint32(header[1:], uint32(len(data)))
+	buf.Write(header[:])
+	buf.Write(data)
+}
+
+// encodeGRPCMessage percent-encodes status message as gRPC requires
+func encodeGRPCMessage(msg string) string {
+	var sb strings.Builder
+	for i := 0; i < len(msg); i++ {
+		c := msg[i]
+		if c >= ' ' && c <= '~' && c != '%' {
+			sb.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&sb, "%%%02X", c)
+	}
+	return sb.String()
+}
@@ -0,0 +1,7 @@
+package history
+
+// Config for revision history of places
+type Config struct {
+	// DBPath is path of embedded database file keeping revisions
+	DBPath string `yaml:"db_path"`
+}
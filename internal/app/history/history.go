@@ -0,0 +1,245 @@
+package history
+
+import (
+	"chillit-rest-gateway/internal/app/places"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Actions of revisions
+const (
+	ActionCreate   = "create"
+	ActionUpdate   = "update"
+	ActionDelete   = "delete"
+	ActionRollback = "rollback"
+)
+
+// ErrNotFound is returned for unknown revision
+var ErrNotFound = errors.New("revision not found")
+
+var revisionsBucket = []byte("revisions")
+
+// Change of place field, From is nil when previous value is unknown to history
+type Change struct {
+	Field string  `json:"field"`
+	From  *string `json:"from"`
+	To    string  `json:"to"`
+}
+
+// Revision is change of place made through gateway
+type Revision struct {
+	// Number of revision, starting from 1 for every place
+	Number  int       `json:"number"`
+	PlaceID uint64    `json:"place_id"`
+	Action  string    `json:"action"`
+	Author  string    `json:"author"`
+	At      time.Time `json:"at"`
+	// Version of place after change
+	Version uint64    `json:"version,omitempty"`
+	Changes []*Change `json:"changes"`
+	// RolledBackTo is number of revision restored by rollback
+	RolledBackTo int `json:"rolled_back_to,omitempty"`
+}
+
+// Fields returns recorded fields of place by their names in revisions.
+// Coordinates are decimal degrees, empty for place without location
+func Fields(p *places.Place) map[string]string {
+	fields := map[string]string{
+		"title":       p.GetTitle(),
+		"address":     p.GetAddress(),
+		"description": p.GetDescription(),
+		"image_url":   p.GetImgURL(),
+		"latitude":    "",
+		"longitude":   "",
+	}
+	if location := p.GetLocation(); location != nil {
+		fields["latitude"] = strconv.FormatFloat(location.GetLatitude(), 'f', -1, 64)
+		fields["longitude"] = strconv.FormatFloat(location.GetLongitude(), 'f', -1, 64)
+	}
+	return fields
+}
+
+// fieldOrder keeps changes in stable order
+var fieldOrder = []string{"title", "address", "description", "image_url", "latitude", "longitude"}
+
+// Store keeps revisions in embedded database, revisions are only appended
+type Store struct {
+	db *bolt.DB
+}
+
+// NewStore opens database
+func NewStore(config *Config) (*Store, error) {
+	if config == nil {
+		return nil, errors.New("[ NewStore ] <nil> config")
+	}
+	if config.DBPath == "" {
+		return nil, errors.New("[ NewStore ] db_path is required")
+	}
+
+	db, err := bolt.Open(config.DBPath, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, errors.New("[ NewStore ] could not open database: " + err.Error())
+	}
+	if err := db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(revisionsBucket)
+		return err
+	}); err != nil {
+		db.Close()
+		return nil, errors.New("[ NewStore ] could not create buckets: " + err.Error())
+	}
+	return &Store{db: db}, nil
+}
+
+// Close closes database
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+func key(n uint64) []byte {
+	k := make([]byte, 8)
+	binary.BigEndian.PutUint64(k, n)
+	return k
+}
+
+// Record appends revision of place. Changed fields are those of place listed in changed,
+// all fields if changed is nil; previous values are taken from earlier revisions
+func (s *Store) Record(action, author string, place *places.Place, changed []string) (*Revision, error) {
+	return s.record(action, author, place, changed, 0)
+}
+
+// Rollback appends revision restoring fields of place to revision number
+func (s *Store) Rollback(author string, place *places.Place, changed []string, number int) (*Revision, error) {
+	return s.record(ActionRollback, author, place, changed, number)
+}
+
+func (s *Store) record(action, author string, place *places.Place, changed []string, rolledBackTo int) (*Revision, error) {
+	rev := &Revision{
+		PlaceID:      place.GetId(),
+		Action:       action,
+		Author:       author,
+		At:           time.Now().UTC(),
+		Version:      place.GetVersion(),
+		Changes:      []*Change{},
+		RolledBackTo: rolledBackTo,
+	}
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.Bucket(revisionsBucket).CreateBucketIfNotExists(key(rev.PlaceID))
+		if err != nil {
+			return err
+		}
+		state, err := snapshot(bucket, 0)
+		if err != nil {
+			return err
+		}
+		if action != ActionDelete {
+			rev.Changes = diff(state, Fields(place), changed)
+		}
+
+		n, err := bucket.NextSequence()
+		if err != nil {
+			return err
+		}
+		rev.Number = int(n)
+		data, err := json.Marshal(rev)
+		if err != nil {
+			return err
+		}
+		return bucket.Put(key(n), data)
+	})
+	if err != nil {
+		return nil, errors.New("[ Store.Record ] could not save revision: " + err.Error())
+	}
+	return rev, nil
+}
+
+func diff(state, values map[string]string, changed []string) []*Change {
+	listed := make(map[string]bool)
+	for _, field := range changed {
+		listed[field] = true
+	}
+	changes := []*Change{}
+	for _, field := range fieldOrder {
+		if changed != nil && !listed[field] {
+			continue
+		}
+		change := &Change{Field: field, To: values[field]}
+		if from, ok := state[field]; ok {
+			if from == change.To {
+				continue
+			}
+			change.From = &from
+		} else if change.To == "" {
+			continue
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+// List returns revisions of place, oldest first
+func (s *Store) List(placeID uint64) ([]*Revision, error) {
+	list := []*Revision{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(revisionsBucket).Bucket(key(placeID))
+		if bucket == nil {
+			return nil
+		}
+		return bucket.ForEach(func(k, v []byte) error {
+			var rev Revision
+			if err := json.Unmarshal(v, &rev); err != nil {
+				return err
+			}
+			list = append(list, &rev)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, errors.New("[ Store.List ] could not read revisions: " + err.Error())
+	}
+	return list, nil
+}
+
+// Snapshot returns fields of place known to history after revision number, zero for latest
+func (s *Store) Snapshot(placeID uint64, number int) (map[string]string, error) {
+	var state map[string]string
+	err := s.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(revisionsBucket).Bucket(key(placeID))
+		if bucket == nil || number > 0 && bucket.Get(key(uint64(number))) == nil {
+			return ErrNotFound
+		}
+		var err error
+		state, err = snapshot(bucket, number)
+		return err
+	})
+	return state, err
+}
+
+// snapshot replays changes of revisions up to number, all revisions if number is zero
+func snapshot(bucket *bolt.Bucket, number int) (map[string]string, error) {
+	state := make(map[string]string)
+	err := bucket.ForEach(func(k, v []byte) error {
+		if number > 0 && binary.BigEndian.Uint64(k) > uint64(number) {
+			return nil
+		}
+		var rev Revision
+		if err := json.Unmarshal(v, &rev); err != nil {
+			return err
+		}
+		if rev.Action == ActionCreate {
+			// Fields absent from create revision were empty
+			for _, field := range fieldOrder {
+				state[field] = ""
+			}
+		}
+		for _, change := range rev.Changes {
+			state[change.Field] = change.To
+		}
+		return nil
+	})
+	return state, err
+}
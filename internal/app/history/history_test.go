@@ -0,0 +1,74 @@
+package history
+
+import (
+	"chillit-rest-gateway/internal/app/places"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "history")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	_, err = NewStore(&Config{})
+	assert.Error(t, err)
+	s, err := NewStore(&Config{DBPath: filepath.Join(dir, "history.db")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	created, err := s.Record(ActionCreate, "api_key:writer", &places.Place{Id: 7, Title: "Coffee Bean", Address: "Tverskaya 1"}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, created.Number)
+	// Empty fields of created place are not listed
+	assert.Len(t, created.Changes, 2)
+	assert.Nil(t, created.Changes[0].From)
+
+	updated, err := s.Record(ActionUpdate, "user:anna", &places.Place{Id: 7, Title: "Coffee Bean", Address: "Tverskaya 10", Version: 2}, []string{"title", "address"})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, updated.Number)
+	// Unchanged title is skipped
+	if assert.Len(t, updated.Changes, 1) {
+		assert.Equal(t, "address", updated.Changes[0].Field)
+		assert.Equal(t, "Tverskaya 1", *updated.Changes[0].From)
+		assert.Equal(t, "Tverskaya 10", updated.Changes[0].To)
+	}
+
+	state, err := s.Snapshot(7, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "Tverskaya 1", state["address"])
+	assert.Equal(t, "", state["description"])
+	state, err = s.Snapshot(7, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "Tverskaya 10", state["address"])
+	_, err = s.Snapshot(7, 3)
+	assert.Equal(t, ErrNotFound, err)
+	_, err = s.Snapshot(8, 0)
+	assert.Equal(t, ErrNotFound, err)
+
+	rolledBack, err := s.Rollback("user:admin", &places.Place{Id: 7, Title: "Coffee Bean", Address: "Tverskaya 1", Version: 3}, []string{"address"}, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, ActionRollback, rolledBack.Action)
+	assert.Equal(t, 1, rolledBack.RolledBackTo)
+
+	// Previous value of place unknown to history is not guessed
+	unknown, err := s.Record(ActionUpdate, "user:anna", &places.Place{Id: 9, Title: "Tea"}, []string{"title"})
+	assert.NoError(t, err)
+	assert.Nil(t, unknown.Changes[0].From)
+
+	list, err := s.List(7)
+	assert.NoError(t, err)
+	assert.Len(t, list, 3)
+	assert.Equal(t, "user:anna", list[1].Author)
+	list, err = s.List(100)
+	assert.NoError(t, err)
+	assert.Empty(t, list)
+}
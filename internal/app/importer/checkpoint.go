@@ -0,0 +1,99 @@
+package importer
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"sync"
+)
+
+// FileCheckpoint logs created records to file, one JSON object per line
+type FileCheckpoint struct {
+	path string
+	mu   sync.Mutex
+	file *os.File
+}
+
+// fileCheckpointEntry is line of checkpoint file
+type fileCheckpointEntry struct {
+	Record int    `json:"record"`
+	ID     uint64 `json:"id"`
+}
+
+// NewFileCheckpoint uses file at path, it is created on first created record
+func NewFileCheckpoint(path string) *FileCheckpoint {
+	return &FileCheckpoint{path: path}
+}
+
+// Done reads created records, last line may be cut by crash and such record is imported again
+func (c *FileCheckpoint) Done() (map[int]uint64, error) {
+	done := make(map[int]uint64)
+	file, err := os.Open(c.path)
+	if os.IsNotExist(err) {
+		return done, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry fileCheckpointEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err == nil {
+			done[entry.Record] = entry.ID
+		}
+	}
+	return done, scanner.Err()
+}
+
+// Created appends record to file
+func (c *FileCheckpoint) Created(created *Created) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.file == nil {
+		file, err := openAppend(c.path)
+		if err != nil {
+			return err
+		}
+		c.file = file
+	}
+	line, _ := json.Marshal(&fileCheckpointEntry{Record: created.Record, ID: created.ID})
+	_, err := c.file.Write(append(line, '\n'))
+	return err
+}
+
+// Rejected records are not logged, they are retried on next run
+func (c *FileCheckpoint) Rejected(rejected *Rejected) error {
+	return nil
+}
+
+// Close closes file
+func (c *FileCheckpoint) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.file == nil {
+		return nil
+	}
+	return c.file.Close()
+}
+
+// openAppend opens file for appending, line cut by crash is terminated first
+func openAppend(path string) (*os.File, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, err
+	}
+	info, err := file.Stat()
+	if err == nil && info.Size() > 0 {
+		last := make([]byte, 1)
+		if _, err = file.ReadAt(last, info.Size()-1); err == nil && last[0] != '\n' {
+			_, err = file.Write([]byte{'\n'})
+		}
+	}
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return file, nil
+}
@@ -0,0 +1,240 @@
+package importer
+
+import (
+	"chillit-rest-gateway/internal/app/cities"
+	"chillit-rest-gateway/internal/app/places"
+	"context"
+	"errors"
+	"io"
+	"sort"
+	"strconv"
+	"sync"
+	"unicode/utf8"
+
+	"google.golang.org/grpc/status"
+)
+
+// Options of import
+type Options struct {
+	// Concurrency is number of simultaneous AddPlace calls, 4 by default
+	Concurrency int
+	// DryRun only validates records
+	DryRun bool
+	// Checkpoint keeps results, records created according to it are skipped
+	Checkpoint Checkpoint
+}
+
+// Checkpoint keeps results of import, so interrupted import may be resumed
+type Checkpoint interface {
+	// Done returns IDs of records created by previous runs
+	Done() (map[int]uint64, error)
+	// Created is called as soon as store confirms record
+	Created(created *Created) error
+	// Rejected is called for every rejected record
+	Rejected(rejected *Rejected) error
+}
+
+// Report of import
+type Report struct {
+	Created  []*Created  `json:"created"`
+	Rejected []*Rejected `json:"rejected"`
+	// Skipped are records created by previous runs according to checkpoint
+	Skipped int  `json:"skipped"`
+	DryRun  bool `json:"dry_run,omitempty"`
+}
+
+// Created record, ID is zero in dry run
+type Created struct {
+	Record int    `json:"record"`
+	ID     uint64 `json:"id,omitempty"`
+	Title  string `json:"title"`
+	// CityID and Place as added to store, Place is nil in dry run
+	CityID uint64        `json:"-"`
+	Place  *places.Place `json:"-"`
+}
+
+// Rejected record with reasons
+type Rejected struct {
+	Record int      `json:"record"`
+	Errors []string `json:"errors"`
+}
+
+// Importer adds places read from file to places store
+type Importer struct {
+	client  places.PlacesStoreClient
+	options Options
+}
+
+// New creates importer
+func New(client places.PlacesStoreClient, options Options) *Importer {
+	if options.Concurrency <= 0 {
+		options.Concurrency = 4
+	}
+	return &Importer{client: client, options: options}
+}
+
+// Validate returns problems of record, limits are the same as of POST /places
+func Validate(record *Record) []string {
+	if record.err != nil {
+		return []string{record.err.Error()}
+	}
+	var errs []string
+	for _, field := range []struct {
+		name     string
+		value    string
+		required bool
+		maxLen   int
+	}{
+		{"city_name", record.CityName, true, 100},
+		{"title", record.Title, true, 200},
+		{"address", record.Address, false, 300},
+		{"description", record.Description, false, 5000},
+		{"image_url", record.ImgURL, false, 2000},
+	} {
+		if field.required && field.value == "" {
+			errs = append(errs, field.name+" is required")
+		}
+		if utf8.RuneCountInString(field.value) > field.maxLen {
+			errs = append(errs, field.name+" must be at most "+strconv.Itoa(field.maxLen)+" characters long")
+		}
+	}
+	if (record.Latitude == nil) != (record.Longitude == nil) {
+		errs = append(errs, "latitude and longitude must be given together")
+	}
+	if record.Latitude != nil && (*record.Latitude < -90 || *record.Latitude > 90) {
+		errs = append(errs, "latitude must be between -90 and 90")
+	}
+	if record.Longitude != nil && (*record.Longitude < -180 || *record.Longitude > 180) {
+		errs = append(errs, "longitude must be between -180 and 180")
+	}
+	return errs
+}
+
+// Run imports records until reader is exhausted or ctx is canceled. Records are added concurrently,
+// created ones are appended to checkpoint as soon as store confirms them
+func (im *Importer) Run(ctx context.Context, reader Reader) (*Report, error) {
+	report := &Report{DryRun: im.options.DryRun}
+	done := make(map[int]uint64)
+	checkpoint := im.options.Checkpoint
+	if checkpoint != nil {
+		var err error
+		if done, err = checkpoint.Done(); err != nil {
+			return nil, errors.New("[ Importer.Run ] could not read checkpoint: " + err.Error())
+		}
+	}
+	if im.options.DryRun {
+		checkpoint = nil
+	}
+	// Records refer to cities by name, places are added to canonical cities, dry run does not check them
+	var index cities.Index
+	if !im.options.DryRun {
+		var err error
+		if index, err = cities.NewResolver(im.client).Index(ctx); err != nil {
+			return nil, errors.New("[ Importer.Run ] could not read cities: " + err.Error())
+		}
+	}
+
+	var mu sync.Mutex
+	var checkpointErr error
+	reject := func(number int, errs []string) {
+		mu.Lock()
+		defer mu.Unlock()
+		rejected := &Rejected{Record: number, Errors: errs}
+		report.Rejected = append(report.Rejected, rejected)
+		if checkpoint != nil && checkpointErr == nil {
+			checkpointErr = checkpoint.Rejected(rejected)
+		}
+	}
+	create := func(record *Record, city *places.City, place *places.Place) {
+		mu.Lock()
+		defer mu.Unlock()
+		created := &Created{Record: record.Number, ID: place.GetId(), Title: record.Title, CityID: city.GetId(), Place: place}
+		report.Created = append(report.Created, created)
+		if checkpoint != nil && checkpointErr == nil {
+			checkpointErr = checkpoint.Created(created)
+		}
+	}
+
+	type job struct {
+		record *Record
+		city   *places.City
+	}
+	records := make(chan *job)
+	var wg sync.WaitGroup
+	for i := 0; i < im.options.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range records {
+				record := j.record
+				place := &places.Place{
+					Title:       record.Title,
+					Address:     record.Address,
+					Description: record.Description,
+					ImgURL:      record.ImgURL,
+				}
+				if record.Latitude != nil && record.Longitude != nil {
+					place.Location = &places.Location{Latitude: *record.Latitude, Longitude: *record.Longitude}
+				}
+				resp, err := im.client.AddPlace(ctx, &places.AddPlaceRequest{
+					CityID:   j.city.GetId(),
+					CityName: j.city.GetTitle(),
+					Place:    place,
+				})
+				if err != nil {
+					reject(record.Number, []string{"places store: " + status.Convert(err).Message()})
+					continue
+				}
+				place.Id = resp.GetId()
+				create(record, j.city, place)
+			}
+		}()
+	}
+
+	var readErr error
+	for ctx.Err() == nil {
+		record, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			readErr = errors.New("[ Importer.Run ] could not read records: " + err.Error())
+			break
+		}
+		if _, ok := done[record.Number]; ok {
+			report.Skipped++
+			continue
+		}
+		if errs := Validate(record); len(errs) > 0 {
+			reject(record.Number, errs)
+			continue
+		}
+		if im.options.DryRun {
+			create(record, nil, nil)
+			continue
+		}
+		city := index.Lookup(record.CityName)
+		if city == nil {
+			reject(record.Number, []string{"unknown city '" + record.CityName + "'"})
+			continue
+		}
+		select {
+		case records <- &job{record: record, city: city}:
+		case <-ctx.Done():
+		}
+	}
+	close(records)
+	wg.Wait()
+
+	sort.Slice(report.Created, func(i, j int) bool { return report.Created[i].Record < report.Created[j].Record })
+	sort.Slice(report.Rejected, func(i, j int) bool { return report.Rejected[i].Record < report.Rejected[j].Record })
+	switch {
+	case readErr != nil:
+		return report, readErr
+	case checkpointErr != nil:
+		return report, errors.New("[ Importer.Run ] could not write checkpoint: " + checkpointErr.Error())
+	case ctx.Err() != nil:
+		return report, errors.New("[ Importer.Run ] interrupted: " + ctx.Err().Error())
+	}
+	return report, nil
+}
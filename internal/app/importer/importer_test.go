@@ -0,0 +1,117 @@
+package importer
+
+import (
+	"chillit-rest-gateway/internal/app/places"
+	"chillit-rest-gateway/internal/app/places/placestest"
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// placesStoreStub rejects places titled "Broken"
+type placesStoreStub struct {
+	*placestest.Store
+}
+
+func (s *placesStoreStub) AddPlace(ctx context.Context, in *places.AddPlaceRequest, opts ...grpc.CallOption) (*places.AddPlaceResponse, error) {
+	if in.Place.Title == "Broken" {
+		return nil, status.Error(codes.InvalidArgument, "place is broken")
+	}
+	return s.Store.AddPlace(ctx, in, opts...)
+}
+
+func readAll(t *testing.T, input, format string) []*Record {
+	reader, err := NewReader(strings.NewReader(input), format)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var records []*Record
+	for {
+		record, err := reader.Next()
+		if err == io.EOF {
+			return records
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		records = append(records, record)
+	}
+}
+
+func TestReaders(t *testing.T) {
+	records := readAll(t, "title,city_name\nCoffee Bean,Moscow\n\"Tea, House\",Kazan\n", FormatCSV)
+	if assert.Len(t, records, 2) {
+		assert.Equal(t, "Tea, House", records[1].Title)
+		assert.Equal(t, "Kazan", records[1].CityName)
+		assert.Equal(t, 2, records[1].Number)
+	}
+
+	// Coordinates are optional, both are needed and checked
+	records = readAll(t, "title,city_name,latitude,longitude\nPier,Moscow,55.7575,37.6125\nTea,Kazan,,\nBar,Kazan,north,\nSky,Kazan,95,37\nBay,Kazan,55,\n", FormatCSV)
+	if assert.Len(t, records, 5) {
+		assert.Empty(t, Validate(records[0]))
+		assert.Equal(t, 37.6125, *records[0].Longitude)
+		assert.Empty(t, Validate(records[1]))
+		assert.Nil(t, records[1].Latitude)
+		assert.Equal(t, []string{"latitude 'north' is not a number"}, Validate(records[2]))
+		assert.Equal(t, []string{"latitude must be between -90 and 90"}, Validate(records[3]))
+		assert.Equal(t, []string{"latitude and longitude must be given together"}, Validate(records[4]))
+	}
+
+	records = readAll(t, ` [{"city_name": "Moscow", "title": "Coffee Bean"}, {"title": "Tea House", "rating": 5}]`, FormatJSON)
+	if assert.Len(t, records, 2) {
+		assert.Equal(t, "Coffee Bean", records[0].Title)
+		assert.Contains(t, Validate(records[1])[0], "rating")
+	}
+
+	records = readAll(t, "{\"city_name\": \"Moscow\", \"title\": \"Coffee Bean\"}\n{\"city_name\": \"Kazan\", \"title\": \"Tea House\"}\n", FormatNDJSON)
+	assert.Len(t, records, 2)
+
+	_, err := NewReader(strings.NewReader("name,city\n"), FormatCSV)
+	assert.Error(t, err)
+	assert.Equal(t, FormatNDJSON, FormatOf("dump.jsonl"))
+}
+
+func TestImporter_Run(t *testing.T) {
+	dir, err := ioutil.TempDir("", "importer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	checkpoint := filepath.Join(dir, "checkpoint")
+	input := "city_name,title\nMoscow,Coffee Bean\nMoscow,\nMoscow,Broken\nKazan,Tea House\n"
+
+	store := &placesStoreStub{Store: placestest.New(nil)}
+	reader, _ := NewReader(strings.NewReader(input), FormatCSV)
+	report, err := New(store, Options{DryRun: true, Checkpoint: NewFileCheckpoint(checkpoint)}).Run(context.Background(), reader)
+	assert.NoError(t, err)
+	assert.Len(t, report.Created, 3)
+	assert.Empty(t, store.AddedTitles())
+
+	// Previous run created first record only
+	assert.NoError(t, ioutil.WriteFile(checkpoint, []byte("{\"record\": 1, \"id\": 7}\n{\"rec"), 0644))
+	reader, _ = NewReader(strings.NewReader(input), FormatCSV)
+	fileCheckpoint := NewFileCheckpoint(checkpoint)
+	report, err = New(store, Options{Concurrency: 2, Checkpoint: fileCheckpoint}).Run(context.Background(), reader)
+	assert.NoError(t, fileCheckpoint.Close())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, report.Skipped)
+	assert.Equal(t, []string{"Tea House"}, store.AddedTitles())
+	if assert.Len(t, report.Rejected, 2) {
+		assert.Equal(t, []string{"title is required"}, report.Rejected[0].Errors)
+		assert.Equal(t, []string{"places store: place is broken"}, report.Rejected[1].Errors)
+	}
+
+	done, err := NewFileCheckpoint(checkpoint).Done()
+	assert.NoError(t, err)
+	assert.Equal(t, map[int]uint64{1: 7, 4: 1}, done)
+}
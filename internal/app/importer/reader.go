@@ -0,0 +1,204 @@
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Formats of import files
+const (
+	FormatCSV    = "csv"
+	FormatJSON   = "json"
+	FormatNDJSON = "ndjson"
+)
+
+// Record is place read from import file
+type Record struct {
+	// Number of record in file starting from 1, data rows for CSV
+	Number      int    `json:"-"`
+	CityName    string `json:"city_name"`
+	Title       string `json:"title"`
+	Address     string `json:"address,omitempty"`
+	Description string `json:"description,omitempty"`
+	ImgURL      string `json:"image_url,omitempty"`
+	// Latitude and Longitude are nil for places without location
+	Latitude  *float64 `json:"latitude,omitempty"`
+	Longitude *float64 `json:"longitude,omitempty"`
+	// err is set when record could not be decoded, e.g. JSON object with unknown field
+	err error
+}
+
+// Reader reads records one by one, so files of any size are imported with bounded memory
+type Reader interface {
+	// Next returns io.EOF after last record
+	Next() (*Record, error)
+}
+
+// FormatOf guesses format by file extension
+func FormatOf(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return FormatCSV
+	case ".ndjson", ".jsonl":
+		return FormatNDJSON
+	}
+	return FormatJSON
+}
+
+// NewReader creates reader of format
+func NewReader(r io.Reader, format string) (Reader, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVReader(r)
+	case FormatJSON, FormatNDJSON:
+		return newJSONReader(r)
+	}
+	return nil, errors.New("[ NewReader ] unknown format '" + format + "'")
+}
+
+// csvColumns are accepted columns of CSV header, named after JSON fields
+var csvColumns = []string{"city_name", "title", "address", "description", "image_url", "latitude", "longitude"}
+
+type csvReader struct {
+	reader  *csv.Reader
+	columns []string
+	number  int
+}
+
+func newCSVReader(r io.Reader) (*csvReader, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err != nil {
+		return nil, errors.New("[ NewReader ] could not read CSV header: " + err.Error())
+	}
+	for i, column := range header {
+		header[i] = strings.ToLower(strings.TrimSpace(column))
+		known := false
+		for _, name := range csvColumns {
+			known = known || header[i] == name
+		}
+		if !known {
+			return nil, fmt.Errorf("[ NewReader ] unknown CSV column '%s', expected %s", column, strings.Join(csvColumns, ", "))
+		}
+	}
+	return &csvReader{reader: reader, columns: header}, nil
+}
+
+func (r *csvReader) Next() (*Record, error) {
+	row, err := r.reader.Read()
+	if err == io.EOF {
+		return nil, io.EOF
+	}
+	r.number++
+	record := &Record{Number: r.number}
+	if err != nil {
+		if _, ok := err.(*csv.ParseError); !ok {
+			return nil, err
+		}
+		record.err = err
+		return record, nil
+	}
+	if len(row) != len(r.columns) {
+		record.err = fmt.Errorf("row has %d fields, header has %d", len(row), len(r.columns))
+		return record, nil
+	}
+	for i, value := range row {
+		value = strings.TrimSpace(value)
+		switch r.columns[i] {
+		case "city_name":
+			record.CityName = value
+		case "title":
+			record.Title = value
+		case "address":
+			record.Address = value
+		case "description":
+			record.Description = value
+		case "image_url":
+			record.ImgURL = value
+		case "latitude":
+			record.Latitude, err = parseCoordinate(r.columns[i], value)
+		case "longitude":
+			record.Longitude, err = parseCoordinate(r.columns[i], value)
+		}
+		if err != nil {
+			record.err = err
+			return record, nil
+		}
+	}
+	return record, nil
+}
+
+// parseCoordinate parses coordinate of CSV column, empty value is absent coordinate
+func parseCoordinate(column, value string) (*float64, error) {
+	if value == "" {
+		return nil, nil
+	}
+	coordinate, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%s '%s' is not a number", column, value)
+	}
+	return &coordinate, nil
+}
+
+// jsonReader reads JSON array of objects or stream of objects, which covers NDJSON
+type jsonReader struct {
+	decoder *json.Decoder
+	array   bool
+	number  int
+}
+
+func newJSONReader(r io.Reader) (*jsonReader, error) {
+	buffered := bufio.NewReader(r)
+	reader := &jsonReader{decoder: json.NewDecoder(buffered)}
+	for {
+		b, err := buffered.ReadByte()
+		if err == io.EOF {
+			return reader, nil
+		}
+		if err != nil {
+			return nil, errors.New("[ NewReader ] could not read JSON: " + err.Error())
+		}
+		if !strings.ContainsRune(" \t\r\n", rune(b)) {
+			buffered.UnreadByte()
+			reader.array = b == '['
+			break
+		}
+	}
+	if reader.array {
+		if _, err := reader.decoder.Token(); err != nil {
+			return nil, errors.New("[ NewReader ] could not read JSON: " + err.Error())
+		}
+	}
+	return reader, nil
+}
+
+func (r *jsonReader) Next() (*Record, error) {
+	if r.array && !r.decoder.More() {
+		return nil, io.EOF
+	}
+
+	var raw json.RawMessage
+	if err := r.decoder.Decode(&raw); err != nil {
+		if err == io.EOF && !r.array {
+			return nil, io.EOF
+		}
+		return nil, fmt.Errorf("could not decode JSON after record %d: %v", r.number, err)
+	}
+	r.number++
+	record := &Record{Number: r.number}
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(record); err != nil {
+		record = &Record{Number: r.number, err: err}
+	}
+	return record, nil
+}
@@ -0,0 +1,11 @@
+package importjobs
+
+// Config for background import jobs
+type Config struct {
+	// DBPath is path of embedded database file keeping jobs and uploaded files
+	DBPath string `yaml:"db_path"`
+	// Concurrency of AddPlace calls of running job, 4 by default
+	Concurrency int `yaml:"concurrency"`
+	// MaxFileSize of uploaded file in bytes, 10 MiB by default
+	MaxFileSize int64 `yaml:"max_file_size"`
+}
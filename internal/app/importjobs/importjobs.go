@@ -0,0 +1,445 @@
+package importjobs
+
+import (
+	"bytes"
+	"chillit-rest-gateway/internal/app/importer"
+	"chillit-rest-gateway/internal/app/places"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	bolt "go.etcd.io/bbolt"
+)
+
+// Statuses of job
+const (
+	StatusQueued    = "queued"
+	StatusRunning   = "running"
+	StatusCompleted = "completed"
+	StatusFailed    = "failed"
+)
+
+// FileError is problem of uploaded file found before job is queued
+type FileError struct {
+	Err error
+}
+
+func (e *FileError) Error() string {
+	return "invalid import file: " + e.Err.Error()
+}
+
+var (
+	jobsBucket     = []byte("import_jobs")
+	jobKey         = []byte("job")
+	fileKey        = []byte("file")
+	createdBucket  = []byte("created")
+	rejectedBucket = []byte("rejected")
+)
+
+// Job imports places from uploaded file
+type Job struct {
+	ID     uint64 `json:"id"`
+	Status string `json:"status"`
+	Format string `json:"format"`
+	// Owner is name of API key or user who submitted job
+	Owner string `json:"owner,omitempty"`
+	// Total number of records in file
+	Total       int        `json:"total"`
+	Created     int        `json:"created"`
+	Rejected    int        `json:"rejected"`
+	Error       string     `json:"error,omitempty"`
+	SubmittedAt time.Time  `json:"submitted_at"`
+	StartedAt   *time.Time `json:"started_at"`
+	FinishedAt  *time.Time `json:"finished_at"`
+	// Errors of rejected records
+	Errors []*importer.Rejected `json:"errors"`
+}
+
+// CreatedFunc is called for every place added to store by job
+type CreatedFunc func(job *Job, cityID uint64, place *places.Place)
+
+// Manager runs jobs one by one in background. Jobs and their progress are kept in
+// embedded database, so unfinished jobs are resumed after restart
+type Manager struct {
+	db      *bolt.DB
+	config  *Config
+	client  places.PlacesStoreClient
+	created CreatedFunc
+	logger  logrus.FieldLogger
+
+	wake   chan struct{}
+	cancel context.CancelFunc
+	done   sync.WaitGroup
+}
+
+// NewManager opens database and starts worker, created may be nil
+func NewManager(config *Config, client places.PlacesStoreClient, created CreatedFunc, logger logrus.FieldLogger) (*Manager, error) {
+	if config == nil {
+		return nil, errors.New("[ NewManager ] <nil> config")
+	}
+	if config.DBPath == "" {
+		return nil, errors.New("[ NewManager ] db_path is required")
+	}
+	if config.Concurrency <= 0 {
+		config.Concurrency = 4
+	}
+	if config.MaxFileSize <= 0 {
+		config.MaxFileSize = 10 << 20
+	}
+
+	db, err := bolt.Open(config.DBPath, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, errors.New("[ NewManager ] could not open database: " + err.Error())
+	}
+	if err := db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(jobsBucket)
+		return err
+	}); err != nil {
+		db.Close()
+		return nil, errors.New("[ NewManager ] could not create buckets: " + err.Error())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	m := &Manager{
+		db:      db,
+		config:  config,
+		client:  client,
+		created: created,
+		logger:  logger,
+		wake:    make(chan struct{}, 1),
+		cancel:  cancel,
+	}
+	m.done.Add(1)
+	go m.work(ctx)
+	return m, nil
+}
+
+// Config returns manager config with defaults applied
+func (m *Manager) Config() *Config {
+	return m.config
+}
+
+// Close stops worker, running job stays running and is resumed by next manager
+func (m *Manager) Close() error {
+	m.cancel()
+	m.done.Wait()
+	return m.db.Close()
+}
+
+func jobID(key []byte) uint64 {
+	return binary.BigEndian.Uint64(key)
+}
+
+func jobKeyOf(id uint64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, id)
+	return key
+}
+
+// Submit checks file and queues job importing it
+func (m *Manager) Submit(format string, data []byte, owner string) (*Job, error) {
+	reader, err := importer.NewReader(bytes.NewReader(data), format)
+	if err != nil {
+		return nil, &FileError{Err: err}
+	}
+	job := &Job{Status: StatusQueued, Format: format, Owner: owner, SubmittedAt: time.Now().UTC()}
+	for {
+		if _, err := reader.Next(); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, &FileError{Err: err}
+		}
+		job.Total++
+	}
+
+	err = m.db.Update(func(tx *bolt.Tx) error {
+		jobs := tx.Bucket(jobsBucket)
+		id, err := jobs.NextSequence()
+		if err != nil {
+			return err
+		}
+		job.ID = id
+		bucket, err := jobs.CreateBucket(jobKeyOf(id))
+		if err != nil {
+			return err
+		}
+		if err := bucket.Put(fileKey, data); err != nil {
+			return err
+		}
+		return putJob(bucket, job)
+	})
+	if err != nil {
+		return nil, errors.New("[ Submit ] could not save job: " + err.Error())
+	}
+
+	select {
+	case m.wake <- struct{}{}:
+	default:
+	}
+	return job, nil
+}
+
+func putJob(bucket *bolt.Bucket, job *Job) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	return bucket.Put(jobKey, data)
+}
+
+// Job returns job with its progress or nil if it does not exist
+func (m *Manager) Job(id uint64) (*Job, error) {
+	var job *Job
+	err := m.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(jobsBucket).Bucket(jobKeyOf(id))
+		if bucket == nil {
+			return nil
+		}
+		job = &Job{}
+		if err := json.Unmarshal(bucket.Get(jobKey), job); err != nil {
+			return err
+		}
+		job.Created, job.Rejected, job.Errors = 0, 0, []*importer.Rejected{}
+		if created := bucket.Bucket(createdBucket); created != nil {
+			job.Created = created.Stats().KeyN
+		}
+		if rejected := bucket.Bucket(rejectedBucket); rejected != nil {
+			return rejected.ForEach(func(k, v []byte) error {
+				item := &importer.Rejected{Record: int(binary.BigEndian.Uint64(k))}
+				if err := json.Unmarshal(v, &item.Errors); err != nil {
+					return err
+				}
+				job.Errors = append(job.Errors, item)
+				job.Rejected++
+				return nil
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.New("[ Job ] could not read job: " + err.Error())
+	}
+	return job, nil
+}
+
+// work runs unfinished jobs in order of submission until manager is closed
+func (m *Manager) work(ctx context.Context) {
+	defer m.done.Done()
+	for {
+		id, ok, err := m.nextJob()
+		if err != nil {
+			m.logger.Errorf("could not find next import job, error: %v", err)
+		}
+		if ok {
+			started := m.run(ctx, id)
+			if ctx.Err() != nil {
+				return
+			}
+			if started {
+				continue
+			}
+			// Job is neither started nor marked failed, database is retried later instead of hot loop
+			select {
+			case <-time.After(startRetryDelay):
+				continue
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		select {
+		case <-m.wake:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// startRetryDelay is pause of worker after job could not be started nor marked failed
+var startRetryDelay = 5 * time.Second
+
+// nextJob finds oldest queued or interrupted job. Unreadable job is returned too, so it is
+// marked failed instead of blocking jobs after it
+func (m *Manager) nextJob() (uint64, bool, error) {
+	var id uint64
+	var found bool
+	err := m.db.View(func(tx *bolt.Tx) error {
+		cursor := tx.Bucket(jobsBucket).Cursor()
+		for k, v := cursor.First(); k != nil; k, v = cursor.Next() {
+			if v != nil {
+				continue
+			}
+			var job Job
+			err := json.Unmarshal(tx.Bucket(jobsBucket).Bucket(k).Get(jobKey), &job)
+			if err != nil || job.Status == StatusQueued || job.Status == StatusRunning {
+				id, found = jobID(k), true
+				return nil
+			}
+		}
+		return nil
+	})
+	return id, found, err
+}
+
+// update changes saved job
+func (m *Manager) update(id uint64, change func(job *Job)) error {
+	return m.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(jobsBucket).Bucket(jobKeyOf(id))
+		var job Job
+		if err := json.Unmarshal(bucket.Get(jobKey), &job); err != nil {
+			return err
+		}
+		change(&job)
+		return putJob(bucket, &job)
+	})
+}
+
+// run runs job and returns false if job could not be started nor marked failed
+func (m *Manager) run(ctx context.Context, id uint64) bool {
+	var job Job
+	var data []byte
+	err := m.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(jobsBucket).Bucket(jobKeyOf(id))
+		if bucket == nil {
+			return errors.New("job not found")
+		}
+		data = append([]byte(nil), bucket.Get(fileKey)...)
+		return json.Unmarshal(bucket.Get(jobKey), &job)
+	})
+	if err == nil {
+		err = m.update(id, func(job *Job) {
+			if job.StartedAt == nil {
+				now := time.Now().UTC()
+				job.StartedAt = &now
+			}
+			job.Status = StatusRunning
+		})
+	}
+	if err != nil {
+		m.logger.Errorf("could not start import job %d, error: %v", id, err)
+		if err := m.fail(id, errors.New("could not start job: "+err.Error())); err != nil {
+			m.logger.Errorf("could not mark import job %d failed, error: %v", id, err)
+			return false
+		}
+		return true
+	}
+	m.logger.Infof("running import job %d of %d records", id, job.Total)
+
+	var runErr error
+	reader, err := importer.NewReader(bytes.NewReader(data), job.Format)
+	if err == nil {
+		_, runErr = importer.New(m.client, importer.Options{
+			Concurrency: m.config.Concurrency,
+			Checkpoint:  &checkpoint{db: m.db, key: jobKeyOf(id), job: &job, created: m.created},
+		}).Run(ctx, reader)
+	} else {
+		runErr = err
+	}
+	if ctx.Err() != nil {
+		// Manager is closed, job is resumed after restart
+		return true
+	}
+
+	err = m.update(id, func(job *Job) {
+		now := time.Now().UTC()
+		job.FinishedAt = &now
+		job.Status = StatusCompleted
+		if runErr != nil {
+			job.Status = StatusFailed
+			job.Error = runErr.Error()
+		}
+	})
+	if err != nil {
+		m.logger.Errorf("could not finish import job %d, error: %v", id, err)
+	}
+	return true
+}
+
+// fail marks job which could not be started as failed, unreadable job is replaced by failed one
+func (m *Manager) fail(id uint64, cause error) error {
+	return m.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(jobsBucket).Bucket(jobKeyOf(id))
+		if bucket == nil {
+			// Job is gone, nothing is left to pick again
+			return nil
+		}
+		var job Job
+		if err := json.Unmarshal(bucket.Get(jobKey), &job); err != nil {
+			job = Job{ID: id}
+		}
+		now := time.Now().UTC()
+		job.FinishedAt = &now
+		job.Status = StatusFailed
+		job.Error = cause.Error()
+		return putJob(bucket, &job)
+	})
+}
+
+// checkpoint keeps results of job records in job bucket
+type checkpoint struct {
+	db      *bolt.DB
+	key     []byte
+	job     *Job
+	created CreatedFunc
+}
+
+func recordKey(record int) []byte {
+	return jobKeyOf(uint64(record))
+}
+
+func (c *checkpoint) Done() (map[int]uint64, error) {
+	done := make(map[int]uint64)
+	err := c.db.View(func(tx *bolt.Tx) error {
+		created := tx.Bucket(jobsBucket).Bucket(c.key).Bucket(createdBucket)
+		if created == nil {
+			return nil
+		}
+		return created.ForEach(func(k, v []byte) error {
+			id, err := strconv.ParseUint(string(v), 10, 64)
+			done[int(binary.BigEndian.Uint64(k))] = id
+			return err
+		})
+	})
+	return done, err
+}
+
+func (c *checkpoint) Created(created *importer.Created) error {
+	err := c.db.Update(func(tx *bolt.Tx) error {
+		job := tx.Bucket(jobsBucket).Bucket(c.key)
+		bucket, err := job.CreateBucketIfNotExists(createdBucket)
+		if err != nil {
+			return err
+		}
+		// Record rejected by previous run may succeed on resume
+		if rejected := job.Bucket(rejectedBucket); rejected != nil {
+			if err := rejected.Delete(recordKey(created.Record)); err != nil {
+				return err
+			}
+		}
+		return bucket.Put(recordKey(created.Record), []byte(strconv.FormatUint(created.ID, 10)))
+	})
+	if err == nil && c.created != nil && created.Place != nil {
+		c.created(c.job, created.CityID, created.Place)
+	}
+	return err
+}
+
+func (c *checkpoint) Rejected(rejected *importer.Rejected) error {
+	return c.db.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.Bucket(jobsBucket).Bucket(c.key).CreateBucketIfNotExists(rejectedBucket)
+		if err != nil {
+			return err
+		}
+		data, err := json.Marshal(rejected.Errors)
+		if err != nil {
+			return err
+		}
+		return bucket.Put(recordKey(rejected.Record), data)
+	})
+}
@@ -0,0 +1,157 @@
+package importjobs
+
+import (
+	"chillit-rest-gateway/internal/app/places"
+	"chillit-rest-gateway/internal/app/places/placestest"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	bolt "go.etcd.io/bbolt"
+	"google.golang.org/grpc"
+)
+
+type placesStoreStub struct {
+	*placestest.Store
+	// block makes AddPlace of "Slow" wait for cancellation
+	block bool
+}
+
+func (s *placesStoreStub) AddPlace(ctx context.Context, in *places.AddPlaceRequest, opts ...grpc.CallOption) (*places.AddPlaceResponse, error) {
+	if s.block && in.Place.Title == "Slow" {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	return s.Store.AddPlace(ctx, in, opts...)
+}
+
+func waitStatus(t *testing.T, m *Manager, id uint64, status string) *Job {
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		job, err := m.Job(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if job.Status == status || time.Now().After(deadline) {
+			return job
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestManager(t *testing.T) {
+	dir, err := ioutil.TempDir("", "importjobs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	config := &Config{DBPath: filepath.Join(dir, "jobs.db"), Concurrency: 1}
+	logger := logrus.New()
+	logger.SetOutput(ioutil.Discard)
+
+	stub := &placesStoreStub{Store: placestest.New(nil), block: true}
+	m, err := NewManager(config, stub, nil, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = m.Submit("csv", []byte("name,city\n"), "admin")
+	assert.IsType(t, &FileError{}, err)
+
+	job, err := m.Submit("csv", []byte("city_name,title\nMoscow,Coffee Bean\nMoscow,\nMoscow,Slow\nKazan,Tea House\n"), "admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 4, job.Total)
+	assert.Equal(t, StatusQueued, job.Status)
+
+	// Restart while job waits for store, job is resumed by next manager
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if job, _ = m.Job(job.ID); job.Created == 1 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Equal(t, StatusRunning, job.Status)
+	if err := m.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	stub.block = false
+	m, err = NewManager(config, stub, nil, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	job = waitStatus(t, m, job.ID, StatusCompleted)
+	assert.Equal(t, StatusCompleted, job.Status)
+	assert.Equal(t, 3, job.Created)
+	assert.Equal(t, 1, job.Rejected)
+	if assert.Len(t, job.Errors, 1) {
+		assert.Equal(t, 2, job.Errors[0].Record)
+	}
+	assert.NotNil(t, job.FinishedAt)
+	assert.Equal(t, []string{"Coffee Bean", "Slow", "Tea House"}, stub.AddedTitles())
+
+	job, err = m.Job(100)
+	assert.NoError(t, err)
+	assert.Nil(t, job)
+}
+
+func TestManager_unreadableJob(t *testing.T) {
+	dir, err := ioutil.TempDir("", "importjobs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	logger := logrus.New()
+	logger.SetOutput(ioutil.Discard)
+
+	stub := &placesStoreStub{Store: placestest.New(nil)}
+	m, err := NewManager(&Config{DBPath: filepath.Join(dir, "jobs.db")}, stub, nil, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	// Broken job is failed once instead of being picked again, job after it still runs
+	var brokenID uint64
+	err = m.db.Update(func(tx *bolt.Tx) error {
+		jobs := tx.Bucket(jobsBucket)
+		if brokenID, err = jobs.NextSequence(); err != nil {
+			return err
+		}
+		bucket, err := jobs.CreateBucket(jobKeyOf(brokenID))
+		if err != nil {
+			return err
+		}
+		return bucket.Put(jobKey, []byte("{"))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	job, err := m.Submit("csv", []byte("city_name,title\nMoscow,Coffee Bean\n"), "admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	job = waitStatus(t, m, job.ID, StatusCompleted)
+	assert.Equal(t, StatusCompleted, job.Status)
+	broken, err := m.Job(brokenID)
+	assert.NoError(t, err)
+	if assert.NotNil(t, broken) {
+		assert.Equal(t, StatusFailed, broken.Status)
+		assert.Contains(t, broken.Error, "could not start job")
+		assert.NotNil(t, broken.FinishedAt)
+	}
+	assert.Equal(t, 1, stub.Calls("AddPlace"))
+
+	assert.True(t, m.run(context.Background(), 100))
+}
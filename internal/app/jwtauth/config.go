@@ -0,0 +1,18 @@
+package jwtauth
+
+import "time"
+
+// Config for JWT validation
+type Config struct {
+	Issuer   string `yaml:"issuer"`
+	Audience string `yaml:"audience"`
+	// HS256Secret enables HS256 signed tokens
+	HS256Secret string `yaml:"hs256_secret"`
+	// JWKSFile or JWKSURL enable RS256 signed tokens
+	JWKSFile string `yaml:"jwks_file"`
+	JWKSURL  string `yaml:"jwks_url"`
+	// JWKSCacheTTL is how long keys fetched from JWKSURL are cached
+	JWKSCacheTTL time.Duration `yaml:"jwks_cache_ttl"`
+	// Leeway allowed for clock skew when checking exp and nbf
+	Leeway time.Duration `yaml:"leeway"`
+}
@@ -0,0 +1,142 @@
+package jwtauth
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// jwks is JSON Web Key Set
+type jwks struct {
+	Keys []struct {
+		Kty string `json:"kty"`
+		Kid string `json:"kid"`
+		Use string `json:"use"`
+		N   string `json:"n"`
+		E   string `json:"e"`
+	} `json:"keys"`
+}
+
+func parseJWKS(r io.Reader) (map[string]*rsa.PublicKey, error) {
+	var set jwks
+	if err := json.NewDecoder(r).Decode(&set); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]*rsa.PublicKey)
+	for _, key := range set.Keys {
+		if key.Kty != "RSA" || (key.Use != "" && key.Use != "sig") {
+			continue
+		}
+		n, err := base64.RawURLEncoding.DecodeString(key.N)
+		if err != nil {
+			return nil, fmt.Errorf("key '%s': invalid modulus: %v", key.Kid, err)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(key.E)
+		if err != nil {
+			return nil, fmt.Errorf("key '%s': invalid exponent: %v", key.Kid, err)
+		}
+		keys[key.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+	return keys, nil
+}
+
+// keySet provides RSA keys by key ID, refreshing them from URL
+type keySet struct {
+	url        string
+	ttl        time.Duration
+	httpClient *http.Client
+
+	mu        sync.Mutex
+	keys      map[string]*rsa.PublicKey
+	fetchedAt time.Time
+}
+
+// minRefreshInterval protects JWKS endpoint from refreshing on every token with unknown key ID
+const minRefreshInterval = 30 * time.Second
+
+func newFileKeySet(path string) (*keySet, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	keys, err := parseJWKS(f)
+	if err != nil {
+		return nil, err
+	}
+	return &keySet{keys: keys}, nil
+}
+
+func newURLKeySet(url string, ttl time.Duration) *keySet {
+	if ttl <= 0 {
+		ttl = time.Hour
+	}
+	return &keySet{
+		url:        url,
+		ttl:        ttl,
+		httpClient: &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+func (ks *keySet) key(kid string) (*rsa.PublicKey, error) {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	key, ok := ks.keys[kid]
+	if ks.url == "" {
+		if !ok {
+			return nil, fmt.Errorf("unknown key '%s'", kid)
+		}
+		return key, nil
+	}
+
+	sinceFetch := time.Since(ks.fetchedAt)
+	expired := sinceFetch > ks.ttl
+	if expired || (!ok && sinceFetch > minRefreshInterval) {
+		if err := ks.refresh(); err != nil {
+			// Keep serving cached keys while JWKS endpoint is unavailable
+			if ks.keys == nil {
+				return nil, err
+			}
+		}
+		key, ok = ks.keys[kid]
+	}
+	if !ok {
+		return nil, fmt.Errorf("unknown key '%s'", kid)
+	}
+	return key, nil
+}
+
+func (ks *keySet) refresh() error {
+	resp, err := ks.httpClient.Get(ks.url)
+	if err != nil {
+		return errors.New("could not fetch JWKS: " + err.Error())
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		io.Copy(ioutil.Discard, resp.Body)
+		return fmt.Errorf("could not fetch JWKS: status %d", resp.StatusCode)
+	}
+
+	keys, err := parseJWKS(resp.Body)
+	if err != nil {
+		return errors.New("could not parse JWKS: " + err.Error())
+	}
+	ks.keys = keys
+	ks.fetchedAt = time.Now()
+	return nil
+}
@@ -0,0 +1,111 @@
+package jwtauth
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// Claims extracted from validated token
+type Claims struct {
+	Subject string
+	Scopes  []string
+}
+
+// Validator validates bearer tokens
+type Validator struct {
+	config  *Config
+	keySet  *keySet
+	methods []string
+}
+
+// tokenClaims accepts scopes both as space separated "scope" and as "scp" list
+type tokenClaims struct {
+	jwt.RegisteredClaims
+	Scope string   `json:"scope"`
+	Scp   []string `json:"scp"`
+}
+
+// NewValidator creates validator from config
+func NewValidator(config *Config) (*Validator, error) {
+	if config == nil {
+		return nil, errors.New("[ NewValidator ] <nil> config")
+	}
+
+	v := &Validator{config: config}
+	if config.HS256Secret != "" {
+		v.methods = append(v.methods, jwt.SigningMethodHS256.Alg())
+	}
+	switch {
+	case config.JWKSFile != "" && config.JWKSURL != "":
+		return nil, errors.New("[ NewValidator ] only one of jwks_file and jwks_url is allowed")
+	case config.JWKSFile != "":
+		keySet, err := newFileKeySet(config.JWKSFile)
+		if err != nil {
+			return nil, errors.New("[ NewValidator ] could not load JWKS: " + err.Error())
+		}
+		v.keySet = keySet
+	case config.JWKSURL != "":
+		v.keySet = newURLKeySet(config.JWKSURL, config.JWKSCacheTTL)
+	}
+	if v.keySet != nil {
+		v.methods = append(v.methods, jwt.SigningMethodRS256.Alg())
+	}
+	if len(v.methods) == 0 {
+		return nil, errors.New("[ NewValidator ] hs256_secret or JWKS is required")
+	}
+	return v, nil
+}
+
+// Validate checks token signature and registered claims
+func (v *Validator) Validate(tokenString string) (*Claims, error) {
+	claims := &tokenClaims{}
+	parser := jwt.NewParser(jwt.WithValidMethods(v.methods), jwt.WithoutClaimsValidation())
+	if _, err := parser.ParseWithClaims(tokenString, claims, v.keyFunc); err != nil {
+		return nil, err
+	}
+
+	if err := v.validateClaims(claims); err != nil {
+		return nil, err
+	}
+
+	scopes := claims.Scp
+	if claims.Scope != "" {
+		scopes = append(scopes, strings.Fields(claims.Scope)...)
+	}
+	return &Claims{Subject: claims.Subject, Scopes: scopes}, nil
+}
+
+func (v *Validator) keyFunc(token *jwt.Token) (interface{}, error) {
+	switch token.Method.Alg() {
+	case jwt.SigningMethodHS256.Alg():
+		return []byte(v.config.HS256Secret), nil
+	case jwt.SigningMethodRS256.Alg():
+		kid, _ := token.Header["kid"].(string)
+		return v.keySet.key(kid)
+	}
+	return nil, fmt.Errorf("unexpected signing method '%s'", token.Method.Alg())
+}
+
+func (v *Validator) validateClaims(claims *tokenClaims) error {
+	now := time.Now()
+	if claims.ExpiresAt == nil || now.After(claims.ExpiresAt.Add(v.config.Leeway)) {
+		return errors.New("token is expired")
+	}
+	if claims.NotBefore != nil && now.Add(v.config.Leeway).Before(claims.NotBefore.Time) {
+		return errors.New("token is not valid yet")
+	}
+	if claims.Subject == "" {
+		return errors.New("token has no subject")
+	}
+	if v.config.Issuer != "" && claims.Issuer != v.config.Issuer {
+		return errors.New("token issuer is not trusted")
+	}
+	if v.config.Audience != "" && !claims.VerifyAudience(v.config.Audience, true) {
+		return errors.New("token audience is invalid")
+	}
+	return nil
+}
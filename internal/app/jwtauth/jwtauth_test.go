@@ -0,0 +1,82 @@
+package jwtauth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidator_HS256(t *testing.T) {
+	v, err := NewValidator(&Config{HS256Secret: "secret", Issuer: "chillit"})
+	assert.NoError(t, err)
+
+	sign := func(claims jwt.MapClaims, secret string) string {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+		assert.NoError(t, err)
+		return token
+	}
+	exp := time.Now().Add(time.Hour).Unix()
+
+	claims, err := v.Validate(sign(jwt.MapClaims{"sub": "user-1", "iss": "chillit", "exp": exp, "scope": "places:read places:write"}, "secret"))
+	assert.NoError(t, err)
+	assert.Equal(t, "user-1", claims.Subject)
+	assert.Equal(t, []string{"places:read", "places:write"}, claims.Scopes)
+
+	_, err = v.Validate(sign(jwt.MapClaims{"sub": "user-1", "iss": "chillit", "exp": exp}, "other"))
+	assert.Error(t, err, "wrong signature")
+	_, err = v.Validate(sign(jwt.MapClaims{"sub": "user-1", "iss": "other", "exp": exp}, "secret"))
+	assert.Error(t, err, "wrong issuer")
+	_, err = v.Validate(sign(jwt.MapClaims{"sub": "user-1", "iss": "chillit", "exp": time.Now().Add(-time.Minute).Unix()}, "secret"))
+	assert.Error(t, err, "expired")
+	_, err = v.Validate(sign(jwt.MapClaims{"sub": "user-1", "iss": "chillit"}, "secret"))
+	assert.Error(t, err, "no expiration")
+}
+
+func TestValidator_RS256FromURL(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+
+	fetches := 0
+	jwksServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches++
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": "key-1",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	}))
+	defer jwksServer.Close()
+
+	v, err := NewValidator(&Config{JWKSURL: jwksServer.URL, JWKSCacheTTL: time.Hour})
+	assert.NoError(t, err)
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{"sub": "user-2", "exp": time.Now().Add(time.Hour).Unix(), "scp": []string{"places:read"}})
+	token.Header["kid"] = "key-1"
+	signed, err := token.SignedString(key)
+	assert.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		claims, err := v.Validate(signed)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"places:read"}, claims.Scopes)
+	}
+	assert.Equal(t, 1, fetches, "keys are cached")
+
+	// HS256 token signed with public key must not be accepted
+	confused, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "user-2", "exp": time.Now().Add(time.Hour).Unix()}).SignedString(key.N.Bytes())
+	assert.NoError(t, err)
+	_, err = v.Validate(confused)
+	assert.Error(t, err)
+}
@@ -0,0 +1,23 @@
+package metrics
+
+import (
+	"expvar"
+	"net/http"
+)
+
+// Counters exposed at metrics endpoint
+var (
+	// RequestsByAPIKey counts requests made by each API key
+	RequestsByAPIKey = expvar.NewMap("requests_by_api_key")
+	// RejectedByAPIKey counts requests rejected because of key scope or quota
+	RejectedByAPIKey = expvar.NewMap("rejected_by_api_key")
+	// RequestsByAPIVersion counts requests to each API version and to unversioned aliases
+	RequestsByAPIVersion = expvar.NewMap("requests_by_api_version")
+	// RequestsByRPC counts gRPC-Web and Connect calls of each RPC, e.g. "connect:GetCities"
+	RequestsByRPC = expvar.NewMap("requests_by_rpc")
+)
+
+// Handler serves all metrics as JSON
+func Handler() http.Handler {
+	return expvar.Handler()
+}
@@ -0,0 +1,13 @@
+package moderation
+
+import "time"
+
+// Config for moderation of submitted places
+type Config struct {
+	// DBPath is path of embedded database file keeping submissions
+	DBPath string `yaml:"db_path"`
+	// WebhookURL receives outcomes of moderation as JSON submissions, disabled if empty
+	WebhookURL string `yaml:"webhook_url"`
+	// WebhookTimeout limits webhook call, 5s by default
+	WebhookTimeout time.Duration `yaml:"webhook_timeout"`
+}
@@ -0,0 +1,348 @@
+package moderation
+
+import (
+	"bytes"
+	"chillit-rest-gateway/internal/app/cities"
+	"chillit-rest-gateway/internal/app/places"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	bolt "go.etcd.io/bbolt"
+)
+
+// Statuses of submission
+const (
+	StatusPending  = "pending"
+	StatusApproved = "approved"
+	StatusRejected = "rejected"
+)
+
+// Errors of queue
+var (
+	ErrNotFound   = errors.New("submission not found")
+	ErrNotPending = errors.New("submission is already moderated")
+)
+
+var submissionsBucket = []byte("submissions")
+
+// Submission is place waiting for moderation or moderated one
+type Submission struct {
+	ID     uint64 `json:"id"`
+	Status string `json:"status"`
+	// CityID is canonical city of place, it is zero after edit of city name until approval resolves it
+	CityID      uint64   `json:"city_id,omitempty"`
+	CityName    string   `json:"city_name"`
+	Title       string   `json:"title"`
+	Address     string   `json:"address,omitempty"`
+	Description string   `json:"description,omitempty"`
+	ImgURL      string   `json:"image_url,omitempty"`
+	Latitude    *float64 `json:"latitude,omitempty"`
+	Longitude   *float64 `json:"longitude,omitempty"`
+	// Submitter identifies caller, e.g. "user:anna"
+	Submitter   string     `json:"submitter"`
+	SubmittedAt time.Time  `json:"submitted_at"`
+	ModeratedAt *time.Time `json:"moderated_at,omitempty"`
+	Moderator   string     `json:"moderator,omitempty"`
+	// Reason of rejection
+	Reason string `json:"reason,omitempty"`
+	// PlaceID is ID of approved place in places store
+	PlaceID uint64 `json:"place_id,omitempty"`
+}
+
+// Place returns submitted place, with ID once approved
+func (s *Submission) Place() *places.Place {
+	place := &places.Place{
+		Id:          s.PlaceID,
+		Title:       s.Title,
+		Address:     s.Address,
+		Description: s.Description,
+		ImgURL:      s.ImgURL,
+	}
+	if s.Latitude != nil && s.Longitude != nil {
+		place.Location = &places.Location{Latitude: *s.Latitude, Longitude: *s.Longitude}
+	}
+	return place
+}
+
+// Edit changes fields of pending submission, nil fields are kept
+type Edit struct {
+	CityName    *string `json:"city_name,omitempty" validate:"minlen=1,maxlen=100"`
+	Title       *string `json:"title,omitempty" validate:"minlen=1,maxlen=200"`
+	Address     *string `json:"address,omitempty" validate:"maxlen=300"`
+	Description *string `json:"description,omitempty" validate:"maxlen=5000"`
+	ImgURL      *string `json:"image_url,omitempty" validate:"maxlen=2000"`
+	// Latitude and Longitude replace coordinates only together
+	Latitude  *float64 `json:"latitude,omitempty" validate:"min=-90,max=90"`
+	Longitude *float64 `json:"longitude,omitempty" validate:"min=-180,max=180"`
+}
+
+func (e *Edit) apply(s *Submission) {
+	for _, field := range []struct {
+		value  *string
+		target *string
+	}{
+		{e.CityName, &s.CityName},
+		{e.Title, &s.Title},
+		{e.Address, &s.Address},
+		{e.Description, &s.Description},
+		{e.ImgURL, &s.ImgURL},
+	} {
+		if field.value != nil {
+			*field.target = *field.value
+		}
+	}
+	if e.Latitude != nil && e.Longitude != nil {
+		lat, lon := *e.Latitude, *e.Longitude
+		s.Latitude, s.Longitude = &lat, &lon
+	}
+	if e.CityName != nil {
+		s.CityID = 0
+	}
+}
+
+// Queue keeps submissions in embedded database and adds approved ones to places store
+type Queue struct {
+	db     *bolt.DB
+	config *Config
+	client places.PlacesStoreClient
+	cities *cities.Resolver
+	logger logrus.FieldLogger
+	http   *http.Client
+
+	// mu serializes moderation, so place is not added twice by concurrent approvals
+	mu sync.Mutex
+}
+
+// NewQueue opens database
+func NewQueue(config *Config, client places.PlacesStoreClient, logger logrus.FieldLogger) (*Queue, error) {
+	if config == nil {
+		return nil, errors.New("[ NewQueue ] <nil> config")
+	}
+	if config.DBPath == "" {
+		return nil, errors.New("[ NewQueue ] db_path is required")
+	}
+	if config.WebhookTimeout <= 0 {
+		config.WebhookTimeout = 5 * time.Second
+	}
+
+	db, err := bolt.Open(config.DBPath, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, errors.New("[ NewQueue ] could not open database: " + err.Error())
+	}
+	if err := db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(submissionsBucket)
+		return err
+	}); err != nil {
+		db.Close()
+		return nil, errors.New("[ NewQueue ] could not create buckets: " + err.Error())
+	}
+
+	return &Queue{
+		db:     db,
+		config: config,
+		client: client,
+		cities: cities.NewResolver(client),
+		logger: logger,
+		http:   &http.Client{Timeout: config.WebhookTimeout},
+	}, nil
+}
+
+// Close closes database
+func (q *Queue) Close() error {
+	return q.db.Close()
+}
+
+func idKey(id uint64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, id)
+	return key
+}
+
+func put(bucket *bolt.Bucket, s *Submission) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	return bucket.Put(idKey(s.ID), data)
+}
+
+// Submit queues place, ID, status and submission time are assigned
+func (q *Queue) Submit(s *Submission) error {
+	s.Status = StatusPending
+	s.SubmittedAt = time.Now().UTC()
+	err := q.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(submissionsBucket)
+		id, err := bucket.NextSequence()
+		if err != nil {
+			return err
+		}
+		s.ID = id
+		return put(bucket, s)
+	})
+	if err != nil {
+		return errors.New("[ Queue.Submit ] could not save submission: " + err.Error())
+	}
+	return nil
+}
+
+// Get returns submission or ErrNotFound
+func (q *Queue) Get(id uint64) (*Submission, error) {
+	var s *Submission
+	err := q.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(submissionsBucket).Get(idKey(id))
+		if data == nil {
+			return ErrNotFound
+		}
+		s = &Submission{}
+		return json.Unmarshal(data, s)
+	})
+	return s, err
+}
+
+// List returns submissions in order of submission. Empty status and submitter match any
+func (q *Queue) List(status, submitter string, offset, amount int) ([]*Submission, error) {
+	list := []*Submission{}
+	err := q.db.View(func(tx *bolt.Tx) error {
+		cursor := tx.Bucket(submissionsBucket).Cursor()
+		skipped := 0
+		for k, v := cursor.First(); k != nil && (amount <= 0 || len(list) < amount); k, v = cursor.Next() {
+			var s Submission
+			if err := json.Unmarshal(v, &s); err != nil {
+				return err
+			}
+			if status != "" && s.Status != status || submitter != "" && s.Submitter != submitter {
+				continue
+			}
+			if skipped < offset {
+				skipped++
+				continue
+			}
+			list = append(list, &s)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.New("[ Queue.List ] could not read submissions: " + err.Error())
+	}
+	return list, nil
+}
+
+// update changes pending submission in single transaction
+func (q *Queue) update(id uint64, change func(s *Submission)) (*Submission, error) {
+	var s *Submission
+	err := q.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(submissionsBucket)
+		data := bucket.Get(idKey(id))
+		if data == nil {
+			return ErrNotFound
+		}
+		s = &Submission{}
+		if err := json.Unmarshal(data, s); err != nil {
+			return err
+		}
+		if s.Status != StatusPending {
+			return ErrNotPending
+		}
+		change(s)
+		return put(bucket, s)
+	})
+	return s, err
+}
+
+// Edit changes pending submission
+func (q *Queue) Edit(id uint64, edit *Edit) (*Submission, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.update(id, edit.apply)
+}
+
+// Approve adds pending place to places store and notifies submitter
+func (q *Queue) Approve(ctx context.Context, id uint64, moderator string) (*Submission, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	s, err := q.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if s.Status != StatusPending {
+		return nil, ErrNotPending
+	}
+	cityID := s.CityID
+	if cityID == 0 {
+		city, err := q.cities.Resolve(ctx, s.CityName)
+		if err != nil {
+			return nil, err
+		}
+		cityID = city.GetId()
+	}
+	resp, err := q.client.AddPlace(ctx, &places.AddPlaceRequest{
+		CityID:   cityID,
+		CityName: s.CityName,
+		Place:    s.Place(),
+	})
+	if err != nil {
+		return nil, errors.New("[ Queue.Approve ] could not add place: " + err.Error())
+	}
+
+	s, err = q.update(id, func(s *Submission) {
+		now := time.Now().UTC()
+		s.Status = StatusApproved
+		s.ModeratedAt = &now
+		s.Moderator = moderator
+		s.CityID = cityID
+		s.PlaceID = resp.GetId()
+	})
+	if err != nil {
+		return nil, errors.New("[ Queue.Approve ] place " + strconv.FormatUint(resp.GetId(), 10) + " is added, but submission is not updated: " + err.Error())
+	}
+	go q.notify(s)
+	return s, nil
+}
+
+// Reject declines pending place with reason and notifies submitter
+func (q *Queue) Reject(id uint64, moderator, reason string) (*Submission, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	s, err := q.update(id, func(s *Submission) {
+		now := time.Now().UTC()
+		s.Status = StatusRejected
+		s.ModeratedAt = &now
+		s.Moderator = moderator
+		s.Reason = reason
+	})
+	if err != nil {
+		return nil, err
+	}
+	go q.notify(s)
+	return s, nil
+}
+
+// notify posts moderated submission to webhook
+func (q *Queue) notify(s *Submission) {
+	if q.config.WebhookURL == "" {
+		return
+	}
+	data, err := json.Marshal(s)
+	if err != nil {
+		q.logger.Errorf("could not encode submission %d, error: %v", s.ID, err)
+		return
+	}
+	resp, err := q.http.Post(q.config.WebhookURL, "application/json", bytes.NewReader(data))
+	if err != nil {
+		q.logger.Errorf("could not notify about submission %d, error: %v", s.ID, err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= http.StatusBadRequest {
+		q.logger.Errorf("could not notify about submission %d, webhook responded %d", s.ID, resp.StatusCode)
+	}
+}
@@ -0,0 +1,99 @@
+package moderation
+
+import (
+	"chillit-rest-gateway/internal/app/places"
+	"chillit-rest-gateway/internal/app/places/placestest"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueue(t *testing.T) {
+	dir, err := ioutil.TempDir("", "moderation")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	notified := make(chan *Submission, 2)
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var s Submission
+		json.NewDecoder(r.Body).Decode(&s)
+		notified <- &s
+	}))
+	defer webhook.Close()
+
+	stub := placestest.New(nil)
+	stub.NextID = 101
+	config := &Config{DBPath: filepath.Join(dir, "moderation.db"), WebhookURL: webhook.URL}
+	q, err := NewQueue(config, stub, logrus.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first := &Submission{CityName: "Moscow", Title: "Cofee Bean", Submitter: "user:anna"}
+	second := &Submission{CityName: "Moscow", Title: "Spam", Submitter: "user:bob"}
+	assert.NoError(t, q.Submit(first))
+	assert.NoError(t, q.Submit(second))
+	assert.Equal(t, StatusPending, first.Status)
+
+	// Queue survives restart
+	assert.NoError(t, q.Close())
+	q, err = NewQueue(config, stub, logrus.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer q.Close()
+
+	list, err := q.List(StatusPending, "", 0, 10)
+	assert.NoError(t, err)
+	assert.Len(t, list, 2)
+	list, err = q.List("", "user:bob", 0, 10)
+	assert.NoError(t, err)
+	assert.Len(t, list, 1)
+
+	title := "Coffee Bean"
+	edited, err := q.Edit(first.ID, &Edit{Title: &title})
+	assert.NoError(t, err)
+	assert.Equal(t, "Coffee Bean", edited.Title)
+	lat, lon := 55.7525, 37.618
+	_, err = q.Edit(first.ID, &Edit{Latitude: &lat, Longitude: &lon})
+	assert.NoError(t, err)
+
+	approved, err := q.Approve(context.Background(), first.ID, "api_key:ops")
+	assert.NoError(t, err)
+	assert.Equal(t, StatusApproved, approved.Status)
+	assert.Equal(t, uint64(101), approved.PlaceID)
+	if assert.Len(t, stub.Added, 1) {
+		assert.Equal(t, "Coffee Bean", stub.Added[0].Place.Title)
+		assert.Equal(t, &places.Location{Latitude: 55.7525, Longitude: 37.618}, stub.Added[0].Place.Location)
+	}
+	_, err = q.Approve(context.Background(), first.ID, "api_key:ops")
+	assert.Equal(t, ErrNotPending, err)
+
+	rejected, err := q.Reject(second.ID, "api_key:ops", "advertisement")
+	assert.NoError(t, err)
+	assert.Equal(t, "advertisement", rejected.Reason)
+	_, err = q.Reject(100, "api_key:ops", "advertisement")
+	assert.Equal(t, ErrNotFound, err)
+
+	outcomes := map[string]string{}
+	for i := 0; i < 2; i++ {
+		select {
+		case s := <-notified:
+			outcomes[s.Submitter] = s.Status
+		case <-time.After(5 * time.Second):
+			t.Fatal("submitter is not notified")
+		}
+	}
+	assert.Equal(t, map[string]string{"user:anna": StatusApproved, "user:bob": StatusRejected}, outcomes)
+}
@@ -0,0 +1,373 @@
+package openapi
+
+import (
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Spec describes route. Query, Body and Response are values of structs, whose
+// fields are documented by `schema` and `json` tags
+type Spec struct {
+	Method      string
+	Path        string
+	Summary     string
+	Description string
+	Tags        []string
+	Query       interface{}
+	Body        interface{}
+	// Status of successful response, 200 by default
+	Status   int
+	Response interface{}
+	// MediaTypes of response besides application/json of Response, they are documented without schema
+	MediaTypes []string
+	// Parameters are documented in addition to fields of Query
+	Parameters []*Parameter
+	// Alternatives are other successful responses of route by status
+	Alternatives map[int]*Alternative
+	// Security lists names of security schemes accepted by route, empty for public routes
+	Security   []string
+	Deprecated bool
+}
+
+// Alternative is successful response documented besides the main one, e.g. 202 of request queued for review
+type Alternative struct {
+	Response interface{}
+	// MediaTypes of response besides application/json of Response
+	MediaTypes []string
+}
+
+// Generator builds document from route specs
+type Generator struct {
+	doc *Document
+	// types keeps component name of each documented struct
+	types map[reflect.Type]string
+}
+
+// NewGenerator creates generator of document with info
+func NewGenerator(title, version string) *Generator {
+	return &Generator{
+		doc: &Document{
+			OpenAPI: "3.0.3",
+			Info:    &Info{Title: title, Version: version},
+			Paths:   make(map[string]PathItem),
+			Components: &Components{
+				Schemas:         make(map[string]*Schema),
+				SecuritySchemes: make(map[string]*SecurityScheme),
+			},
+		},
+		types: make(map[reflect.Type]string),
+	}
+}
+
+// AddSecurityScheme registers security scheme by name
+func (g *Generator) AddSecurityScheme(name string, scheme *SecurityScheme) {
+	g.doc.Components.SecuritySchemes[name] = scheme
+}
+
+// Document returns generated document
+func (g *Generator) Document() *Document {
+	return g.doc
+}
+
+// Schema returns schema of named component
+func (g *Generator) Schema(name string) *Schema {
+	return g.doc.Components.Schemas[name]
+}
+
+// pathParamRe matches mux path variables with optional pattern, e.g. {id:[0-9]+}
+var pathParamRe = regexp.MustCompile(`\{([^}:]+)(:[^}]+)?\}`)
+
+// Add documents route
+func (g *Generator) Add(spec *Spec) {
+	path := pathParamRe.ReplaceAllString(spec.Path, "{$1}")
+	item, ok := g.doc.Paths[path]
+	if !ok {
+		item = make(PathItem)
+		g.doc.Paths[path] = item
+	}
+
+	op := &Operation{
+		Summary:     spec.Summary,
+		Description: spec.Description,
+		OperationID: operationID(spec.Method, path),
+		Tags:        spec.Tags,
+		Responses:   make(map[string]*Response),
+		Deprecated:  spec.Deprecated,
+	}
+
+	var queryParams []*Parameter
+	if spec.Query != nil {
+		queryParams = g.queryParameters(reflect.TypeOf(spec.Query))
+	}
+	pathParams := make(map[string]bool)
+	for _, match := range pathParamRe.FindAllStringSubmatch(spec.Path, -1) {
+		pathParams[match[1]] = true
+		param := &Parameter{Name: match[1], Schema: &Schema{Type: "string"}}
+		for _, queryParam := range queryParams {
+			if queryParam.Name == match[1] {
+				param = queryParam
+			}
+		}
+		param.In = "path"
+		param.Required = true
+		op.Parameters = append(op.Parameters, param)
+	}
+	for _, param := range queryParams {
+		if !pathParams[param.Name] {
+			op.Parameters = append(op.Parameters, param)
+		}
+	}
+	op.Parameters = append(op.Parameters, spec.Parameters...)
+
+	if spec.Body != nil {
+		op.RequestBody = &RequestBody{
+			Required: true,
+			Content: map[string]*MediaType{
+				"application/json": {Schema: g.SchemaOf(reflect.TypeOf(spec.Body))},
+			},
+		}
+	}
+
+	status := spec.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	op.Responses[strconv.Itoa(status)] = g.response(status, spec.Response, spec.MediaTypes)
+	for status, alternative := range spec.Alternatives {
+		op.Responses[strconv.Itoa(status)] = g.response(status, alternative.Response, alternative.MediaTypes)
+	}
+	op.Responses["default"] = &Response{
+		Description: "Error",
+		Content: map[string]*MediaType{
+			"application/problem+json": {Schema: g.problemSchema()},
+		},
+	}
+
+	for _, name := range spec.Security {
+		op.Security = append(op.Security, map[string][]string{name: {}})
+	}
+
+	item[strings.ToLower(spec.Method)] = op
+}
+
+// response documents successful response with JSON schema of v and other media types without schema
+func (g *Generator) response(status int, v interface{}, mediaTypes []string) *Response {
+	resp := &Response{Description: http.StatusText(status)}
+	if v != nil {
+		resp.Content = map[string]*MediaType{
+			"application/json": {Schema: g.SchemaOf(reflect.TypeOf(v))},
+		}
+	}
+	for _, mediaType := range mediaTypes {
+		if resp.Content == nil {
+			resp.Content = make(map[string]*MediaType)
+		}
+		if _, ok := resp.Content[mediaType]; !ok {
+			resp.Content[mediaType] = &MediaType{}
+		}
+	}
+	return resp
+}
+
+func operationID(method, path string) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(method))
+	for _, part := range strings.FieldsFunc(path, func(r rune) bool { return r == '/' || r == '{' || r == '}' || r == '_' || r == '-' || r == '.' }) {
+		b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return b.String()
+}
+
+func (g *Generator) problemSchema() *Schema {
+	const name = "Problem"
+	if _, ok := g.doc.Components.Schemas[name]; !ok {
+		g.doc.Components.Schemas[name] = &Schema{
+			Type: "object",
+			Properties: map[string]*Schema{
+				"type":   {Type: "string"},
+				"title":  {Type: "string"},
+				"status": {Type: "integer"},
+				"detail": {Type: "string"},
+			},
+			Required: []string{"title", "status"},
+		}
+	}
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+// queryParameters documents fields of struct decoded by gorilla/schema
+func (g *Generator) queryParameters(t reflect.Type) []*Parameter {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	var params []*Parameter
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get("schema"), ",")[0]
+		if name == "-" || field.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		schema := g.SchemaOf(field.Type)
+		applyConstraints(schema, field.Tag.Get("validate"))
+		params = append(params, &Parameter{
+			Name:        name,
+			In:          "query",
+			Description: field.Tag.Get("doc"),
+			Required:    strings.Contains(field.Tag.Get("schema"), ",required"),
+			Schema:      schema,
+		})
+	}
+	return params
+}
+
+// SchemaOf returns schema of type, named structs are referenced from components
+func (g *Generator) SchemaOf(t reflect.Type) *Schema {
+	switch t.Kind() {
+	case reflect.Ptr:
+		return g.SchemaOf(t.Elem())
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: g.SchemaOf(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object"}
+	case reflect.Struct:
+		if t.PkgPath() == "time" && t.Name() == "Time" {
+			return &Schema{Type: "string", Format: "date-time"}
+		}
+		if t.Name() == "" {
+			return g.structSchema(t)
+		}
+		name, ok := g.types[t]
+		if !ok {
+			name = g.componentName(t)
+			g.types[t] = name
+			// Reserve name first, so recursive types terminate
+			g.doc.Components.Schemas[name] = &Schema{}
+			*g.doc.Components.Schemas[name] = *g.structSchema(t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + name}
+	}
+	return ScalarSchema(t)
+}
+
+// ScalarSchema returns schema of boolean, number or string type
+func ScalarSchema(t reflect.Type) *Schema {
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		zero := 0.0
+		return &Schema{Type: "integer", Format: "int64", Minimum: &zero}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	}
+	return &Schema{}
+}
+
+// componentName returns capitalized type name, prefixed with package name
+// when other type already took it, e.g. places.AddPlaceRequest and addPlaceRequest
+func (g *Generator) componentName(t reflect.Type) string {
+	name := strings.ToUpper(t.Name()[:1]) + t.Name()[1:]
+	if _, ok := g.doc.Components.Schemas[name]; !ok {
+		return name
+	}
+	pkg := t.PkgPath()[strings.LastIndex(t.PkgPath(), "/")+1:]
+	return strings.ToUpper(pkg[:1]) + pkg[1:] + name
+}
+
+// applyConstraints sets constraints from `validate` tag, e.g. `validate:"min=1,max=100,maxlen=200,enum=a|b"`
+func applyConstraints(schema *Schema, tag string) {
+	if tag == "" {
+		return
+	}
+	for _, constraint := range strings.Split(tag, ",") {
+		parts := strings.SplitN(constraint, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		key, value := parts[0], parts[1]
+		switch key {
+		case "min", "max":
+			number, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			if key == "min" {
+				schema.Minimum = &number
+			} else {
+				schema.Maximum = &number
+			}
+		case "minlen", "maxlen":
+			length, err := strconv.Atoi(value)
+			if err != nil {
+				continue
+			}
+			if key == "minlen" {
+				schema.MinLength = &length
+			} else {
+				schema.MaxLength = &length
+			}
+		case "enum":
+			schema.Enum = strings.Split(value, "|")
+		}
+	}
+}
+
+func (g *Generator) structSchema(t reflect.Type) *Schema {
+	noAdditional := false
+	schema := &Schema{Type: "object", Properties: make(map[string]*Schema), AdditionalProperties: &noAdditional}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		tag := strings.Split(field.Tag.Get("json"), ",")
+		name := tag[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		fieldSchema := g.SchemaOf(field.Type)
+		if field.Tag.Get("protobuf") != "" && (field.Type.Kind() == reflect.Int64 || field.Type.Kind() == reflect.Uint64) {
+			// proto3 JSON mapping encodes 64 bit integers as strings
+			fieldSchema = &Schema{Type: "string", Format: field.Type.Kind().String()}
+		}
+		if fieldSchema.Ref == "" {
+			fieldSchema.Description = field.Tag.Get("doc")
+			fieldSchema.Nullable = field.Type.Kind() == reflect.Ptr
+			applyConstraints(fieldSchema, field.Tag.Get("validate"))
+		}
+		schema.Properties[name] = fieldSchema
+
+		omitempty := false
+		for _, option := range tag[1:] {
+			if option == "omitempty" {
+				omitempty = true
+			}
+		}
+		if !omitempty && field.Type.Kind() != reflect.Ptr {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+	return schema
+}
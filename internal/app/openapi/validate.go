@@ -0,0 +1,217 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"unicode/utf8"
+)
+
+// FieldError describes value violating schema
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidateQuery checks query parameters of operation. Parameters listed in ignored
+// are accepted by every operation and are not checked
+func (d *Document) ValidateQuery(op *Operation, query url.Values, ignored ...string) []FieldError {
+	var errs []FieldError
+
+	known := make(map[string]bool)
+	for _, name := range ignored {
+		known[name] = true
+	}
+	for _, param := range op.Parameters {
+		if param.In != "query" {
+			continue
+		}
+		known[param.Name] = true
+
+		values, ok := query[param.Name]
+		if !ok || len(values) == 0 {
+			if param.Required {
+				errs = append(errs, FieldError{Field: param.Name, Message: "is required"})
+			}
+			continue
+		}
+		if len(values) > 1 {
+			errs = append(errs, FieldError{Field: param.Name, Message: "must be passed once"})
+			continue
+		}
+		errs = append(errs, d.validateString(param.Name, d.Resolve(param.Schema), values[0])...)
+	}
+
+	var unknown []string
+	for name := range query {
+		if !known[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	for _, name := range unknown {
+		errs = append(errs, FieldError{Field: name, Message: "is unknown parameter"})
+	}
+	return errs
+}
+
+// validateString checks value of parameter passed as string
+func (d *Document) validateString(field string, schema *Schema, value string) []FieldError {
+	switch schema.Type {
+	case "integer":
+		if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+			if _, err := strconv.ParseUint(value, 10, 64); err != nil {
+				return []FieldError{{Field: field, Message: "must be integer"}}
+			}
+		}
+		number, _ := strconv.ParseFloat(value, 64)
+		return validateNumber(field, schema, number)
+	case "number":
+		number, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return []FieldError{{Field: field, Message: "must be number"}}
+		}
+		return validateNumber(field, schema, number)
+	case "boolean":
+		if _, err := strconv.ParseBool(value); err != nil {
+			return []FieldError{{Field: field, Message: "must be boolean"}}
+		}
+		return nil
+	}
+	return validateText(field, schema, value)
+}
+
+func validateNumber(field string, schema *Schema, number float64) []FieldError {
+	if schema.Minimum != nil && number < *schema.Minimum {
+		return []FieldError{{Field: field, Message: fmt.Sprintf("must be greater than or equal to %v", *schema.Minimum)}}
+	}
+	if schema.Maximum != nil && number > *schema.Maximum {
+		return []FieldError{{Field: field, Message: fmt.Sprintf("must be less than or equal to %v", *schema.Maximum)}}
+	}
+	return nil
+}
+
+func validateText(field string, schema *Schema, value string) []FieldError {
+	length := utf8.RuneCountInString(value)
+	if schema.MinLength != nil && length < *schema.MinLength {
+		return []FieldError{{Field: field, Message: fmt.Sprintf("must be at least %d characters long", *schema.MinLength)}}
+	}
+	if schema.MaxLength != nil && length > *schema.MaxLength {
+		return []FieldError{{Field: field, Message: fmt.Sprintf("must be at most %d characters long", *schema.MaxLength)}}
+	}
+	if len(schema.Enum) > 0 {
+		for _, allowed := range schema.Enum {
+			if value == allowed {
+				return nil
+			}
+		}
+		return []FieldError{{Field: field, Message: fmt.Sprintf("must be one of %v", schema.Enum)}}
+	}
+	return nil
+}
+
+// ValidateJSON checks JSON document against schema
+func (d *Document) ValidateJSON(schema *Schema, data []byte) []FieldError {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return []FieldError{{Field: "", Message: "is not valid JSON: " + err.Error()}}
+	}
+	return d.validateValue("", schema, value)
+}
+
+func (d *Document) validateValue(field string, schema *Schema, value interface{}) []FieldError {
+	schema = d.Resolve(schema)
+	if schema == nil {
+		return nil
+	}
+	if value == nil {
+		if schema.Nullable || schema.Type == "" {
+			return nil
+		}
+		return []FieldError{{Field: field, Message: "must not be null"}}
+	}
+
+	switch schema.Type {
+	case "object":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return []FieldError{{Field: field, Message: "must be object"}}
+		}
+		return d.validateObject(field, schema, object)
+	case "array":
+		array, ok := value.([]interface{})
+		if !ok {
+			return []FieldError{{Field: field, Message: "must be array"}}
+		}
+		var errs []FieldError
+		for i, item := range array {
+			errs = append(errs, d.validateValue(fmt.Sprintf("%s[%d]", field, i), schema.Items, item)...)
+		}
+		return errs
+	case "integer", "number":
+		number, ok := value.(json.Number)
+		if !ok {
+			return []FieldError{{Field: field, Message: "must be " + schema.Type}}
+		}
+		if schema.Type == "integer" {
+			if _, err := strconv.ParseInt(number.String(), 10, 64); err != nil {
+				if _, err := strconv.ParseUint(number.String(), 10, 64); err != nil {
+					return []FieldError{{Field: field, Message: "must be integer"}}
+				}
+			}
+		}
+		f, _ := number.Float64()
+		return validateNumber(field, schema, f)
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return []FieldError{{Field: field, Message: "must be boolean"}}
+		}
+	case "string":
+		text, ok := value.(string)
+		if number, isNumber := value.(json.Number); isNumber && (schema.Format == "int64" || schema.Format == "uint64") {
+			// proto3 JSON mapping accepts 64 bit integers both as strings and numbers
+			text, ok = number.String(), true
+		}
+		if !ok {
+			return []FieldError{{Field: field, Message: "must be string"}}
+		}
+		return validateText(field, schema, text)
+	}
+	return nil
+}
+
+func (d *Document) validateObject(field string, schema *Schema, object map[string]interface{}) []FieldError {
+	var errs []FieldError
+	prefix := field
+	if prefix != "" {
+		prefix += "."
+	}
+
+	for _, name := range schema.Required {
+		if _, ok := object[name]; !ok {
+			errs = append(errs, FieldError{Field: prefix + name, Message: "is required"})
+		}
+	}
+
+	names := make([]string, 0, len(object))
+	for name := range object {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		propertySchema, ok := schema.Properties[name]
+		if !ok {
+			if schema.AdditionalProperties != nil && !*schema.AdditionalProperties {
+				errs = append(errs, FieldError{Field: prefix + name, Message: "is unknown field"})
+			}
+			continue
+		}
+		errs = append(errs, d.validateValue(prefix+name, propertySchema, object[name])...)
+	}
+	return errs
+}
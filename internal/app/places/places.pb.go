@@ -7,6 +7,7 @@ import (
 	context "context"
 	fmt "fmt"
 	proto "github.com/golang/protobuf/proto"
+	field_mask "google.golang.org/genproto/protobuf/field_mask"
 	grpc "google.golang.org/grpc"
 	codes "google.golang.org/grpc/codes"
 	status "google.golang.org/grpc/status"
@@ -25,14 +26,18 @@ var _ = math.Inf
 const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
 
 type Place struct {
-	Id                   uint64   `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
-	Title                string   `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"`
-	Address              string   `protobuf:"bytes,3,opt,name=address,proto3" json:"address,omitempty"`
-	Description          string   `protobuf:"bytes,4,opt,name=description,proto3" json:"description,omitempty"`
-	ImgURL               string   `protobuf:"bytes,5,opt,name=imgURL,proto3" json:"imgURL,omitempty"`
-	XXX_NoUnkeyedLiteral struct{} `json:"-"`
-	XXX_unrecognized     []byte   `json:"-"`
-	XXX_sizecache        int32    `json:"-"`
+	Id          uint64 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Title       string `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"`
+	Address     string `protobuf:"bytes,3,opt,name=address,proto3" json:"address,omitempty"`
+	Description string `protobuf:"bytes,4,opt,name=description,proto3" json:"description,omitempty"`
+	ImgURL      string `protobuf:"bytes,5,opt,name=imgURL,proto3" json:"imgURL,omitempty"`
+	// version is incremented by store on every change of place
+	Version uint64 `protobuf:"varint,6,opt,name=version,proto3" json:"version,omitempty"`
+	// location is unset for places without known coordinates
+	Location             *Location `protobuf:"bytes,7,opt,name=location,proto3" json:"location,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}  `json:"-"`
+	XXX_unrecognized     []byte    `json:"-"`
+	XXX_sizecache        int32     `json:"-"`
 }
 
 func (m *Place) Reset()         { *m = Place{} }
@@ -95,9 +100,73 @@ func (m *Place) GetImgURL() string {
 	return ""
 }
 
+func (m *Place) GetVersion() uint64 {
+	if m != nil {
+		return m.Version
+	}
+	return 0
+}
+
+func (m *Place) GetLocation() *Location {
+	if m != nil {
+		return m.Location
+	}
+	return nil
+}
+
+// Location is WGS 84 point in degrees
+type Location struct {
+	Latitude             float64  `protobuf:"fixed64,1,opt,name=latitude,proto3" json:"latitude,omitempty"`
+	Longitude            float64  `protobuf:"fixed64,2,opt,name=longitude,proto3" json:"longitude,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Location) Reset()         { *m = Location{} }
+func (m *Location) String() string { return proto.CompactTextString(m) }
+func (*Location) ProtoMessage()    {}
+func (*Location) Descriptor() ([]byte, []int) {
+	return fileDescriptor_0937d2e70aaf1027, []int{1}
+}
+
+func (m *Location) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Location.Unmarshal(m, b)
+}
+func (m *Location) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Location.Marshal(b, m, deterministic)
+}
+func (m *Location) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Location.Merge(m, src)
+}
+func (m *Location) XXX_Size() int {
+	return xxx_messageInfo_Location.Size(m)
+}
+func (m *Location) XXX_DiscardUnknown() {
+	xxx_messageInfo_Location.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Location proto.InternalMessageInfo
+
+func (m *Location) GetLatitude() float64 {
+	if m != nil {
+		return m.Latitude
+	}
+	return 0
+}
+
+func (m *Location) GetLongitude() float64 {
+	if m != nil {
+		return m.Longitude
+	}
+	return 0
+}
+
 type City struct {
-	Id                   uint64   `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
-	Title                string   `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"`
+	Id    uint64 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Title string `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"`
+	// archived city is hidden from GetCities and does not accept new places
+	Archived             bool     `protobuf:"varint,3,opt,name=archived,proto3" json:"archived,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -107,7 +176,7 @@ func (m *City) Reset()         { *m = City{} }
 func (m *City) String() string { return proto.CompactTextString(m) }
 func (*City) ProtoMessage()    {}
 func (*City) Descriptor() ([]byte, []int) {
-	return fileDescriptor_0937d2e70aaf1027, []int{1}
+	return fileDescriptor_0937d2e70aaf1027, []int{2}
 }
 
 func (m *City) XXX_Unmarshal(b []byte) error {
@@ -142,10 +211,19 @@ func (m *City) GetTitle() string {
 	return ""
 }
 
-// AddPlace
+func (m *City) GetArchived() bool {
+	if m != nil {
+		return m.Archived
+	}
+	return false
+}
+
+// AddPlace adds place to city identified by cityID. cityName is kept for older clients,
+// it is used only if cityID is zero
 type AddPlaceRequest struct {
 	CityName             string   `protobuf:"bytes,1,opt,name=cityName,proto3" json:"cityName,omitempty"`
 	Place                *Place   `protobuf:"bytes,2,opt,name=place,proto3" json:"place,omitempty"`
+	CityID               uint64   `protobuf:"varint,3,opt,name=cityID,proto3" json:"cityID,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -155,7 +233,7 @@ func (m *AddPlaceRequest) Reset()         { *m = AddPlaceRequest{} }
 func (m *AddPlaceRequest) String() string { return proto.CompactTextString(m) }
 func (*AddPlaceRequest) ProtoMessage()    {}
 func (*AddPlaceRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_0937d2e70aaf1027, []int{2}
+	return fileDescriptor_0937d2e70aaf1027, []int{3}
 }
 
 func (m *AddPlaceRequest) XXX_Unmarshal(b []byte) error {
@@ -190,6 +268,13 @@ func (m *AddPlaceRequest) GetPlace() *Place {
 	return nil
 }
 
+func (m *AddPlaceRequest) GetCityID() uint64 {
+	if m != nil {
+		return m.CityID
+	}
+	return 0
+}
+
 type AddPlaceResponse struct {
 	Id                   uint64   `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
@@ -201,7 +286,7 @@ func (m *AddPlaceResponse) Reset()         { *m = AddPlaceResponse{} }
 func (m *AddPlaceResponse) String() string { return proto.CompactTextString(m) }
 func (*AddPlaceResponse) ProtoMessage()    {}
 func (*AddPlaceResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_0937d2e70aaf1027, []int{3}
+	return fileDescriptor_0937d2e70aaf1027, []int{4}
 }
 
 func (m *AddPlaceResponse) XXX_Unmarshal(b []byte) error {
@@ -242,7 +327,7 @@ func (m *GetCitiesRequest) Reset()         { *m = GetCitiesRequest{} }
 func (m *GetCitiesRequest) String() string { return proto.CompactTextString(m) }
 func (*GetCitiesRequest) ProtoMessage()    {}
 func (*GetCitiesRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_0937d2e70aaf1027, []int{4}
+	return fileDescriptor_0937d2e70aaf1027, []int{5}
 }
 
 func (m *GetCitiesRequest) XXX_Unmarshal(b []byte) error {
@@ -288,7 +373,7 @@ func (m *GetCitiesResponse) Reset()         { *m = GetCitiesResponse{} }
 func (m *GetCitiesResponse) String() string { return proto.CompactTextString(m) }
 func (*GetCitiesResponse) ProtoMessage()    {}
 func (*GetCitiesResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_0937d2e70aaf1027, []int{5}
+	return fileDescriptor_0937d2e70aaf1027, []int{6}
 }
 
 func (m *GetCitiesResponse) XXX_Unmarshal(b []byte) error {
@@ -328,7 +413,7 @@ func (m *GetRandomPlaceByCityNameRequest) Reset()         { *m = GetRandomPlaceB
 func (m *GetRandomPlaceByCityNameRequest) String() string { return proto.CompactTextString(m) }
 func (*GetRandomPlaceByCityNameRequest) ProtoMessage()    {}
 func (*GetRandomPlaceByCityNameRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_0937d2e70aaf1027, []int{6}
+	return fileDescriptor_0937d2e70aaf1027, []int{7}
 }
 
 func (m *GetRandomPlaceByCityNameRequest) XXX_Unmarshal(b []byte) error {
@@ -367,7 +452,7 @@ func (m *GetRandomPlaceByCityNameResponse) Reset()         { *m = GetRandomPlace
 func (m *GetRandomPlaceByCityNameResponse) String() string { return proto.CompactTextString(m) }
 func (*GetRandomPlaceByCityNameResponse) ProtoMessage()    {}
 func (*GetRandomPlaceByCityNameResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_0937d2e70aaf1027, []int{7}
+	return fileDescriptor_0937d2e70aaf1027, []int{8}
 }
 
 func (m *GetRandomPlaceByCityNameResponse) XXX_Unmarshal(b []byte) error {
@@ -409,7 +494,7 @@ func (m *GetPlacesByCityIDRequest) Reset()         { *m = GetPlacesByCityIDReque
 func (m *GetPlacesByCityIDRequest) String() string { return proto.CompactTextString(m) }
 func (*GetPlacesByCityIDRequest) ProtoMessage()    {}
 func (*GetPlacesByCityIDRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_0937d2e70aaf1027, []int{8}
+	return fileDescriptor_0937d2e70aaf1027, []int{9}
 }
 
 func (m *GetPlacesByCityIDRequest) XXX_Unmarshal(b []byte) error {
@@ -462,7 +547,7 @@ func (m *GetPlacesByCityIDResponse) Reset()         { *m = GetPlacesByCityIDResp
 func (m *GetPlacesByCityIDResponse) String() string { return proto.CompactTextString(m) }
 func (*GetPlacesByCityIDResponse) ProtoMessage()    {}
 func (*GetPlacesByCityIDResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_0937d2e70aaf1027, []int{9}
+	return fileDescriptor_0937d2e70aaf1027, []int{10}
 }
 
 func (m *GetPlacesByCityIDResponse) XXX_Unmarshal(b []byte) error {
@@ -490,195 +575,939 @@ func (m *GetPlacesByCityIDResponse) GetPlaces() []*Place {
 	return nil
 }
 
-func init() {
-	proto.RegisterType((*Place)(nil), "Place")
-	proto.RegisterType((*City)(nil), "City")
-	proto.RegisterType((*AddPlaceRequest)(nil), "AddPlaceRequest")
-	proto.RegisterType((*AddPlaceResponse)(nil), "AddPlaceResponse")
-	proto.RegisterType((*GetCitiesRequest)(nil), "GetCitiesRequest")
-	proto.RegisterType((*GetCitiesResponse)(nil), "GetCitiesResponse")
-	proto.RegisterType((*GetRandomPlaceByCityNameRequest)(nil), "GetRandomPlaceByCityNameRequest")
-	proto.RegisterType((*GetRandomPlaceByCityNameResponse)(nil), "GetRandomPlaceByCityNameResponse")
-	proto.RegisterType((*GetPlacesByCityIDRequest)(nil), "GetPlacesByCityIDRequest")
-	proto.RegisterType((*GetPlacesByCityIDResponse)(nil), "GetPlacesByCityIDResponse")
+// UpdatePlace changes fields of place listed in updateMask, e.g. "title", "address", "location".
+// Store responds FAILED_PRECONDITION if version is not zero and differs from version of place,
+// NOT_FOUND if place does not exist or is deleted
+type UpdatePlaceRequest struct {
+	Place                *Place                `protobuf:"bytes,1,opt,name=place,proto3" json:"place,omitempty"`
+	UpdateMask           *field_mask.FieldMask `protobuf:"bytes,2,opt,name=updateMask,proto3" json:"updateMask,omitempty"`
+	Version              uint64                `protobuf:"varint,3,opt,name=version,proto3" json:"version,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}              `json:"-"`
+	XXX_unrecognized     []byte                `json:"-"`
+	XXX_sizecache        int32                 `json:"-"`
 }
 
-func init() { proto.RegisterFile("places.proto", fileDescriptor_0937d2e70aaf1027) }
+func (m *UpdatePlaceRequest) Reset()         { *m = UpdatePlaceRequest{} }
+func (m *UpdatePlaceRequest) String() string { return proto.CompactTextString(m) }
+func (*UpdatePlaceRequest) ProtoMessage()    {}
+func (*UpdatePlaceRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_0937d2e70aaf1027, []int{11}
+}
 
-var fileDescriptor_0937d2e70aaf1027 = []byte{
-	// 422 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x93, 0x5f, 0xcb, 0xd3, 0x30,
-	0x14, 0xc6, 0x69, 0xd7, 0xd6, 0x77, 0xa7, 0xa2, 0x5b, 0x90, 0x97, 0xbc, 0xc5, 0x3f, 0x35, 0x57,
-	0xbb, 0x90, 0x08, 0xf5, 0x52, 0x04, 0xdd, 0x84, 0xe1, 0x1f, 0x44, 0x22, 0x5e, 0x4b, 0xd7, 0x64,
-	0x12, 0x58, 0x9b, 0xda, 0x64, 0x17, 0xbb, 0xf4, 0x93, 0xf8, 0x55, 0x65, 0x69, 0x3a, 0xeb, 0xb6,
-	0xb2, 0x5d, 0x3e, 0x4f, 0x7a, 0x72, 0x9e, 0xf3, 0xcb, 0x29, 0xdc, 0xaf, 0x37, 0x79, 0x21, 0x34,
-	0xad, 0x1b, 0x65, 0x14, 0xf9, 0xed, 0x41, 0xf8, 0x75, 0x6f, 0xa0, 0x07, 0xe0, 0x4b, 0x8e, 0xbd,
-	0xd4, 0x9b, 0x05, 0xcc, 0x97, 0x1c, 0x3d, 0x82, 0xd0, 0x48, 0xb3, 0x11, 0xd8, 0x4f, 0xbd, 0xd9,
-	0x98, 0xb5, 0x02, 0x61, 0xb8, 0x97, 0x73, 0xde, 0x08, 0xad, 0xf1, 0xc8, 0xfa, 0x9d, 0x44, 0x29,
-	0xc4, 0x5c, 0xe8, 0xa2, 0x91, 0xb5, 0x91, 0xaa, 0xc2, 0x81, 0x3d, 0xed, 0x5b, 0xe8, 0x16, 0x22,
-	0x59, 0xfe, 0xfc, 0xce, 0x3e, 0xe3, 0xd0, 0x1e, 0x3a, 0x45, 0x5e, 0x40, 0xb0, 0x90, 0x66, 0x77,
-	0x5d, 0x02, 0xf2, 0x09, 0x1e, 0xbe, 0xe3, 0xdc, 0x66, 0x66, 0xe2, 0xd7, 0x56, 0x68, 0x83, 0x12,
-	0xb8, 0x29, 0xa4, 0xd9, 0x7d, 0xc9, 0x4b, 0x61, 0xcb, 0xc7, 0xec, 0xa0, 0xd1, 0x63, 0x08, 0xed,
-	0xc0, 0xf6, 0x92, 0x38, 0x8b, 0x68, 0x5b, 0xd9, 0x9a, 0x84, 0xc0, 0xe4, 0xdf, 0x65, 0xba, 0x56,
-	0x95, 0x3e, 0x01, 0x41, 0xe6, 0x30, 0x59, 0x0a, 0xb3, 0x90, 0x46, 0x0a, 0xdd, 0x75, 0xbc, 0x85,
-	0x28, 0x2f, 0xd5, 0xb6, 0x32, 0xee, 0x3b, 0xa7, 0xf6, 0xbe, 0x5a, 0xaf, 0xb5, 0x30, 0xb6, 0x5d,
-	0xc0, 0x9c, 0x22, 0x19, 0x4c, 0x7b, 0x77, 0xb8, 0x46, 0x4f, 0x20, 0x2a, 0xac, 0x83, 0xbd, 0x74,
-	0x34, 0x8b, 0xb3, 0x90, 0xee, 0x31, 0x30, 0x67, 0x92, 0x37, 0xf0, 0x6c, 0x29, 0x0c, 0xcb, 0x2b,
-	0xae, 0x4a, 0x9b, 0x70, 0xbe, 0x5b, 0xb8, 0xa9, 0xae, 0x18, 0x9c, 0xbc, 0x85, 0x74, 0xb8, 0xdc,
-	0x25, 0x38, 0xc0, 0xf1, 0xce, 0xc1, 0x59, 0x01, 0x5e, 0x0a, 0x63, 0x2d, 0xdd, 0x16, 0x7f, 0x78,
-	0xdf, 0x03, 0x50, 0x58, 0xa3, 0x03, 0xd0, 0xaa, 0x21, 0x00, 0x3d, 0x60, 0xa3, 0x3e, 0x30, 0xf2,
-	0x1a, 0xee, 0xce, 0xf4, 0x70, 0xf1, 0x9e, 0x42, 0xd4, 0x2e, 0xab, 0x03, 0xd4, 0xe5, 0x73, 0x6e,
-	0xf6, 0xc7, 0x87, 0xb8, 0x2d, 0xfd, 0x66, 0x54, 0x23, 0xd0, 0x0f, 0x1b, 0xf8, 0xec, 0xc8, 0x28,
-	0xa5, 0x17, 0x60, 0x26, 0xcf, 0xe9, 0x45, 0x5e, 0x19, 0x8c, 0x0f, 0xcf, 0x88, 0xa6, 0xf4, 0x78,
-	0x2d, 0x12, 0x44, 0x4f, 0x5f, 0xf9, 0x25, 0xdc, 0x74, 0x2b, 0x86, 0x26, 0xf4, 0x68, 0x75, 0x93,
-	0x29, 0x3d, 0xd9, 0xbf, 0x8f, 0x76, 0x57, 0xfe, 0x47, 0x82, 0xee, 0xe8, 0xd0, 0x53, 0x24, 0x09,
-	0x1d, 0x24, 0xb8, 0x8a, 0xec, 0x5f, 0xfe, 0xea, 0x6f, 0x00, 0x00, 0x00, 0xff, 0xff, 0x76, 0xc9,
-	0x35, 0x1a, 0xf5, 0x03, 0x00, 0x00,
+func (m *UpdatePlaceRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_UpdatePlaceRequest.Unmarshal(m, b)
+}
+func (m *UpdatePlaceRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_UpdatePlaceRequest.Marshal(b, m, deterministic)
+}
+func (m *UpdatePlaceRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_UpdatePlaceRequest.Merge(m, src)
+}
+func (m *UpdatePlaceRequest) XXX_Size() int {
+	return xxx_messageInfo_UpdatePlaceRequest.Size(m)
+}
+func (m *UpdatePlaceRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_UpdatePlaceRequest.DiscardUnknown(m)
 }
 
-// Reference imports to suppress errors if they are not otherwise used.
-var _ context.Context
-var _ grpc.ClientConnInterface
+var xxx_messageInfo_UpdatePlaceRequest proto.InternalMessageInfo
 
-// This is a compile-time assertion to ensure that this generated file
-// is compatible with the grpc package it is being compiled against.
-const _ = grpc.SupportPackageIsVersion6
+func (m *UpdatePlaceRequest) GetPlace() *Place {
+	if m != nil {
+		return m.Place
+	}
+	return nil
+}
 
-// PlacesStoreClient is the client API for PlacesStore service.
-//
-// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
-type PlacesStoreClient interface {
-	GetRandomPlaceByCityName(ctx context.Context, in *GetRandomPlaceByCityNameRequest, opts ...grpc.CallOption) (*GetRandomPlaceByCityNameResponse, error)
-	GetCities(ctx context.Context, in *GetCitiesRequest, opts ...grpc.CallOption) (*GetCitiesResponse, error)
-	AddPlace(ctx context.Context, in *AddPlaceRequest, opts ...grpc.CallOption) (*AddPlaceResponse, error)
-	GetPlacesByCityID(ctx context.Context, in *GetPlacesByCityIDRequest, opts ...grpc.CallOption) (*GetPlacesByCityIDResponse, error)
+func (m *UpdatePlaceRequest) GetUpdateMask() *field_mask.FieldMask {
+	if m != nil {
+		return m.UpdateMask
+	}
+	return nil
 }
 
-type placesStoreClient struct {
-	cc grpc.ClientConnInterface
+func (m *UpdatePlaceRequest) GetVersion() uint64 {
+	if m != nil {
+		return m.Version
+	}
+	return 0
 }
 
-func NewPlacesStoreClient(cc grpc.ClientConnInterface) PlacesStoreClient {
-	return &placesStoreClient{cc}
+type UpdatePlaceResponse struct {
+	Place                *Place   `protobuf:"bytes,1,opt,name=place,proto3" json:"place,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
-func (c *placesStoreClient) GetRandomPlaceByCityName(ctx context.Context, in *GetRandomPlaceByCityNameRequest, opts ...grpc.CallOption) (*GetRandomPlaceByCityNameResponse, error) {
-	out := new(GetRandomPlaceByCityNameResponse)
-	err := c.cc.Invoke(ctx, "/PlacesStore/GetRandomPlaceByCityName", in, out, opts...)
-	if err != nil {
-		return nil, err
-	}
-	return out, nil
+func (m *UpdatePlaceResponse) Reset()         { *m = UpdatePlaceResponse{} }
+func (m *UpdatePlaceResponse) String() string { return proto.CompactTextString(m) }
+func (*UpdatePlaceResponse) ProtoMessage()    {}
+func (*UpdatePlaceResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_0937d2e70aaf1027, []int{12}
 }
 
-func (c *placesStoreClient) GetCities(ctx context.Context, in *GetCitiesRequest, opts ...grpc.CallOption) (*GetCitiesResponse, error) {
-	out := new(GetCitiesResponse)
-	err := c.cc.Invoke(ctx, "/PlacesStore/GetCities", in, out, opts...)
-	if err != nil {
-		return nil, err
+func (m *UpdatePlaceResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_UpdatePlaceResponse.Unmarshal(m, b)
+}
+func (m *UpdatePlaceResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_UpdatePlaceResponse.Marshal(b, m, deterministic)
+}
+func (m *UpdatePlaceResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_UpdatePlaceResponse.Merge(m, src)
+}
+func (m *UpdatePlaceResponse) XXX_Size() int {
+	return xxx_messageInfo_UpdatePlaceResponse.Size(m)
+}
+func (m *UpdatePlaceResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_UpdatePlaceResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_UpdatePlaceResponse proto.InternalMessageInfo
+
+func (m *UpdatePlaceResponse) GetPlace() *Place {
+	if m != nil {
+		return m.Place
 	}
-	return out, nil
+	return nil
 }
 
-func (c *placesStoreClient) AddPlace(ctx context.Context, in *AddPlaceRequest, opts ...grpc.CallOption) (*AddPlaceResponse, error) {
-	out := new(AddPlaceResponse)
-	err := c.cc.Invoke(ctx, "/PlacesStore/AddPlace", in, out, opts...)
-	if err != nil {
-		return nil, err
+// DeletePlace hides place from listings, store keeps it. Version is checked as in UpdatePlace
+type DeletePlaceRequest struct {
+	Id                   uint64   `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Version              uint64   `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *DeletePlaceRequest) Reset()         { *m = DeletePlaceRequest{} }
+func (m *DeletePlaceRequest) String() string { return proto.CompactTextString(m) }
+func (*DeletePlaceRequest) ProtoMessage()    {}
+func (*DeletePlaceRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_0937d2e70aaf1027, []int{13}
+}
+
+func (m *DeletePlaceRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DeletePlaceRequest.Unmarshal(m, b)
+}
+func (m *DeletePlaceRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DeletePlaceRequest.Marshal(b, m, deterministic)
+}
+func (m *DeletePlaceRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DeletePlaceRequest.Merge(m, src)
+}
+func (m *DeletePlaceRequest) XXX_Size() int {
+	return xxx_messageInfo_DeletePlaceRequest.Size(m)
+}
+func (m *DeletePlaceRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_DeletePlaceRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DeletePlaceRequest proto.InternalMessageInfo
+
+func (m *DeletePlaceRequest) GetId() uint64 {
+	if m != nil {
+		return m.Id
 	}
-	return out, nil
+	return 0
 }
 
-func (c *placesStoreClient) GetPlacesByCityID(ctx context.Context, in *GetPlacesByCityIDRequest, opts ...grpc.CallOption) (*GetPlacesByCityIDResponse, error) {
-	out := new(GetPlacesByCityIDResponse)
-	err := c.cc.Invoke(ctx, "/PlacesStore/GetPlacesByCityID", in, out, opts...)
-	if err != nil {
-		return nil, err
+func (m *DeletePlaceRequest) GetVersion() uint64 {
+	if m != nil {
+		return m.Version
 	}
-	return out, nil
+	return 0
 }
 
-// PlacesStoreServer is the server API for PlacesStore service.
-type PlacesStoreServer interface {
-	GetRandomPlaceByCityName(context.Context, *GetRandomPlaceByCityNameRequest) (*GetRandomPlaceByCityNameResponse, error)
-	GetCities(context.Context, *GetCitiesRequest) (*GetCitiesResponse, error)
-	AddPlace(context.Context, *AddPlaceRequest) (*AddPlaceResponse, error)
-	GetPlacesByCityID(context.Context, *GetPlacesByCityIDRequest) (*GetPlacesByCityIDResponse, error)
+type DeletePlaceResponse struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
-// UnimplementedPlacesStoreServer can be embedded to have forward compatible implementations.
-type UnimplementedPlacesStoreServer struct {
+func (m *DeletePlaceResponse) Reset()         { *m = DeletePlaceResponse{} }
+func (m *DeletePlaceResponse) String() string { return proto.CompactTextString(m) }
+func (*DeletePlaceResponse) ProtoMessage()    {}
+func (*DeletePlaceResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_0937d2e70aaf1027, []int{14}
 }
 
-func (*UnimplementedPlacesStoreServer) GetRandomPlaceByCityName(ctx context.Context, req *GetRandomPlaceByCityNameRequest) (*GetRandomPlaceByCityNameResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method GetRandomPlaceByCityName not implemented")
+func (m *DeletePlaceResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DeletePlaceResponse.Unmarshal(m, b)
 }
-func (*UnimplementedPlacesStoreServer) GetCities(ctx context.Context, req *GetCitiesRequest) (*GetCitiesResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method GetCities not implemented")
+func (m *DeletePlaceResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DeletePlaceResponse.Marshal(b, m, deterministic)
 }
-func (*UnimplementedPlacesStoreServer) AddPlace(ctx context.Context, req *AddPlaceRequest) (*AddPlaceResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method AddPlace not implemented")
+func (m *DeletePlaceResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DeletePlaceResponse.Merge(m, src)
 }
-func (*UnimplementedPlacesStoreServer) GetPlacesByCityID(ctx context.Context, req *GetPlacesByCityIDRequest) (*GetPlacesByCityIDResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method GetPlacesByCityID not implemented")
+func (m *DeletePlaceResponse) XXX_Size() int {
+	return xxx_messageInfo_DeletePlaceResponse.Size(m)
+}
+func (m *DeletePlaceResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_DeletePlaceResponse.DiscardUnknown(m)
 }
 
-func RegisterPlacesStoreServer(s *grpc.Server, srv PlacesStoreServer) {
-	s.RegisterService(&_PlacesStore_serviceDesc, srv)
+var xxx_messageInfo_DeletePlaceResponse proto.InternalMessageInfo
+
+// GetPlace responds NOT_FOUND if place does not exist or is deleted
+type GetPlaceRequest struct {
+	Id                   uint64   `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
-func _PlacesStore_GetRandomPlaceByCityName_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(GetRandomPlaceByCityNameRequest)
-	if err := dec(in); err != nil {
-		return nil, err
-	}
-	if interceptor == nil {
-		return srv.(PlacesStoreServer).GetRandomPlaceByCityName(ctx, in)
-	}
-	info := &grpc.UnaryServerInfo{
-		Server:     srv,
-		FullMethod: "/PlacesStore/GetRandomPlaceByCityName",
-	}
-	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(PlacesStoreServer).GetRandomPlaceByCityName(ctx, req.(*GetRandomPlaceByCityNameRequest))
-	}
-	return interceptor(ctx, in, info, handler)
+func (m *GetPlaceRequest) Reset()         { *m = GetPlaceRequest{} }
+func (m *GetPlaceRequest) String() string { return proto.CompactTextString(m) }
+func (*GetPlaceRequest) ProtoMessage()    {}
+func (*GetPlaceRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_0937d2e70aaf1027, []int{15}
 }
 
-func _PlacesStore_GetCities_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(GetCitiesRequest)
-	if err := dec(in); err != nil {
-		return nil, err
-	}
-	if interceptor == nil {
-		return srv.(PlacesStoreServer).GetCities(ctx, in)
-	}
-	info := &grpc.UnaryServerInfo{
-		Server:     srv,
-		FullMethod: "/PlacesStore/GetCities",
-	}
-	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(PlacesStoreServer).GetCities(ctx, req.(*GetCitiesRequest))
-	}
-	return interceptor(ctx, in, info, handler)
+func (m *GetPlaceRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetPlaceRequest.Unmarshal(m, b)
+}
+func (m *GetPlaceRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetPlaceRequest.Marshal(b, m, deterministic)
+}
+func (m *GetPlaceRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetPlaceRequest.Merge(m, src)
+}
+func (m *GetPlaceRequest) XXX_Size() int {
+	return xxx_messageInfo_GetPlaceRequest.Size(m)
+}
+func (m *GetPlaceRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetPlaceRequest.DiscardUnknown(m)
 }
 
-func _PlacesStore_AddPlace_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(AddPlaceRequest)
-	if err := dec(in); err != nil {
-		return nil, err
-	}
-	if interceptor == nil {
-		return srv.(PlacesStoreServer).AddPlace(ctx, in)
-	}
-	info := &grpc.UnaryServerInfo{
-		Server:     srv,
-		FullMethod: "/PlacesStore/AddPlace",
-	}
-	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(PlacesStoreServer).AddPlace(ctx, req.(*AddPlaceRequest))
+var xxx_messageInfo_GetPlaceRequest proto.InternalMessageInfo
+
+func (m *GetPlaceRequest) GetId() uint64 {
+	if m != nil {
+		return m.Id
 	}
-	return interceptor(ctx, in, info, handler)
+	return 0
+}
+
+type GetPlaceResponse struct {
+	Place                *Place   `protobuf:"bytes,1,opt,name=place,proto3" json:"place,omitempty"`
+	CityID               uint64   `protobuf:"varint,2,opt,name=cityID,proto3" json:"cityID,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetPlaceResponse) Reset()         { *m = GetPlaceResponse{} }
+func (m *GetPlaceResponse) String() string { return proto.CompactTextString(m) }
+func (*GetPlaceResponse) ProtoMessage()    {}
+func (*GetPlaceResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_0937d2e70aaf1027, []int{16}
+}
+
+func (m *GetPlaceResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetPlaceResponse.Unmarshal(m, b)
+}
+func (m *GetPlaceResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetPlaceResponse.Marshal(b, m, deterministic)
+}
+func (m *GetPlaceResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetPlaceResponse.Merge(m, src)
+}
+func (m *GetPlaceResponse) XXX_Size() int {
+	return xxx_messageInfo_GetPlaceResponse.Size(m)
+}
+func (m *GetPlaceResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetPlaceResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetPlaceResponse proto.InternalMessageInfo
+
+func (m *GetPlaceResponse) GetPlace() *Place {
+	if m != nil {
+		return m.Place
+	}
+	return nil
+}
+
+func (m *GetPlaceResponse) GetCityID() uint64 {
+	if m != nil {
+		return m.CityID
+	}
+	return 0
+}
+
+// CreateCity responds ALREADY_EXISTS if city with the same title ignoring case exists
+type CreateCityRequest struct {
+	Title                string   `protobuf:"bytes,1,opt,name=title,proto3" json:"title,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *CreateCityRequest) Reset()         { *m = CreateCityRequest{} }
+func (m *CreateCityRequest) String() string { return proto.CompactTextString(m) }
+func (*CreateCityRequest) ProtoMessage()    {}
+func (*CreateCityRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_0937d2e70aaf1027, []int{17}
+}
+
+func (m *CreateCityRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CreateCityRequest.Unmarshal(m, b)
+}
+func (m *CreateCityRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CreateCityRequest.Marshal(b, m, deterministic)
+}
+func (m *CreateCityRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CreateCityRequest.Merge(m, src)
+}
+func (m *CreateCityRequest) XXX_Size() int {
+	return xxx_messageInfo_CreateCityRequest.Size(m)
+}
+func (m *CreateCityRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_CreateCityRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CreateCityRequest proto.InternalMessageInfo
+
+func (m *CreateCityRequest) GetTitle() string {
+	if m != nil {
+		return m.Title
+	}
+	return ""
+}
+
+type CreateCityResponse struct {
+	City                 *City    `protobuf:"bytes,1,opt,name=city,proto3" json:"city,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *CreateCityResponse) Reset()         { *m = CreateCityResponse{} }
+func (m *CreateCityResponse) String() string { return proto.CompactTextString(m) }
+func (*CreateCityResponse) ProtoMessage()    {}
+func (*CreateCityResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_0937d2e70aaf1027, []int{18}
+}
+
+func (m *CreateCityResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CreateCityResponse.Unmarshal(m, b)
+}
+func (m *CreateCityResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CreateCityResponse.Marshal(b, m, deterministic)
+}
+func (m *CreateCityResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CreateCityResponse.Merge(m, src)
+}
+func (m *CreateCityResponse) XXX_Size() int {
+	return xxx_messageInfo_CreateCityResponse.Size(m)
+}
+func (m *CreateCityResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_CreateCityResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CreateCityResponse proto.InternalMessageInfo
+
+func (m *CreateCityResponse) GetCity() *City {
+	if m != nil {
+		return m.City
+	}
+	return nil
+}
+
+// RenameCity responds ALREADY_EXISTS if other city has the same title ignoring case
+type RenameCityRequest struct {
+	Id                   uint64   `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Title                string   `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *RenameCityRequest) Reset()         { *m = RenameCityRequest{} }
+func (m *RenameCityRequest) String() string { return proto.CompactTextString(m) }
+func (*RenameCityRequest) ProtoMessage()    {}
+func (*RenameCityRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_0937d2e70aaf1027, []int{19}
+}
+
+func (m *RenameCityRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RenameCityRequest.Unmarshal(m, b)
+}
+func (m *RenameCityRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RenameCityRequest.Marshal(b, m, deterministic)
+}
+func (m *RenameCityRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RenameCityRequest.Merge(m, src)
+}
+func (m *RenameCityRequest) XXX_Size() int {
+	return xxx_messageInfo_RenameCityRequest.Size(m)
+}
+func (m *RenameCityRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_RenameCityRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RenameCityRequest proto.InternalMessageInfo
+
+func (m *RenameCityRequest) GetId() uint64 {
+	if m != nil {
+		return m.Id
+	}
+	return 0
+}
+
+func (m *RenameCityRequest) GetTitle() string {
+	if m != nil {
+		return m.Title
+	}
+	return ""
+}
+
+type RenameCityResponse struct {
+	City                 *City    `protobuf:"bytes,1,opt,name=city,proto3" json:"city,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *RenameCityResponse) Reset()         { *m = RenameCityResponse{} }
+func (m *RenameCityResponse) String() string { return proto.CompactTextString(m) }
+func (*RenameCityResponse) ProtoMessage()    {}
+func (*RenameCityResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_0937d2e70aaf1027, []int{20}
+}
+
+func (m *RenameCityResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RenameCityResponse.Unmarshal(m, b)
+}
+func (m *RenameCityResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RenameCityResponse.Marshal(b, m, deterministic)
+}
+func (m *RenameCityResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RenameCityResponse.Merge(m, src)
+}
+func (m *RenameCityResponse) XXX_Size() int {
+	return xxx_messageInfo_RenameCityResponse.Size(m)
+}
+func (m *RenameCityResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_RenameCityResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RenameCityResponse proto.InternalMessageInfo
+
+func (m *RenameCityResponse) GetCity() *City {
+	if m != nil {
+		return m.City
+	}
+	return nil
+}
+
+// MergeCities moves places of source city to target city and deletes source city
+type MergeCitiesRequest struct {
+	SourceID             uint64   `protobuf:"varint,1,opt,name=sourceID,proto3" json:"sourceID,omitempty"`
+	TargetID             uint64   `protobuf:"varint,2,opt,name=targetID,proto3" json:"targetID,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *MergeCitiesRequest) Reset()         { *m = MergeCitiesRequest{} }
+func (m *MergeCitiesRequest) String() string { return proto.CompactTextString(m) }
+func (*MergeCitiesRequest) ProtoMessage()    {}
+func (*MergeCitiesRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_0937d2e70aaf1027, []int{21}
+}
+
+func (m *MergeCitiesRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_MergeCitiesRequest.Unmarshal(m, b)
+}
+func (m *MergeCitiesRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_MergeCitiesRequest.Marshal(b, m, deterministic)
+}
+func (m *MergeCitiesRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_MergeCitiesRequest.Merge(m, src)
+}
+func (m *MergeCitiesRequest) XXX_Size() int {
+	return xxx_messageInfo_MergeCitiesRequest.Size(m)
+}
+func (m *MergeCitiesRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_MergeCitiesRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_MergeCitiesRequest proto.InternalMessageInfo
+
+func (m *MergeCitiesRequest) GetSourceID() uint64 {
+	if m != nil {
+		return m.SourceID
+	}
+	return 0
+}
+
+func (m *MergeCitiesRequest) GetTargetID() uint64 {
+	if m != nil {
+		return m.TargetID
+	}
+	return 0
+}
+
+type MergeCitiesResponse struct {
+	City                 *City    `protobuf:"bytes,1,opt,name=city,proto3" json:"city,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *MergeCitiesResponse) Reset()         { *m = MergeCitiesResponse{} }
+func (m *MergeCitiesResponse) String() string { return proto.CompactTextString(m) }
+func (*MergeCitiesResponse) ProtoMessage()    {}
+func (*MergeCitiesResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_0937d2e70aaf1027, []int{22}
+}
+
+func (m *MergeCitiesResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_MergeCitiesResponse.Unmarshal(m, b)
+}
+func (m *MergeCitiesResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_MergeCitiesResponse.Marshal(b, m, deterministic)
+}
+func (m *MergeCitiesResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_MergeCitiesResponse.Merge(m, src)
+}
+func (m *MergeCitiesResponse) XXX_Size() int {
+	return xxx_messageInfo_MergeCitiesResponse.Size(m)
+}
+func (m *MergeCitiesResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_MergeCitiesResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_MergeCitiesResponse proto.InternalMessageInfo
+
+func (m *MergeCitiesResponse) GetCity() *City {
+	if m != nil {
+		return m.City
+	}
+	return nil
+}
+
+// ArchiveCity archives or, if archived is false, restores city
+type ArchiveCityRequest struct {
+	Id                   uint64   `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Archived             bool     `protobuf:"varint,2,opt,name=archived,proto3" json:"archived,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ArchiveCityRequest) Reset()         { *m = ArchiveCityRequest{} }
+func (m *ArchiveCityRequest) String() string { return proto.CompactTextString(m) }
+func (*ArchiveCityRequest) ProtoMessage()    {}
+func (*ArchiveCityRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_0937d2e70aaf1027, []int{23}
+}
+
+func (m *ArchiveCityRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ArchiveCityRequest.Unmarshal(m, b)
+}
+func (m *ArchiveCityRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ArchiveCityRequest.Marshal(b, m, deterministic)
+}
+func (m *ArchiveCityRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ArchiveCityRequest.Merge(m, src)
+}
+func (m *ArchiveCityRequest) XXX_Size() int {
+	return xxx_messageInfo_ArchiveCityRequest.Size(m)
+}
+func (m *ArchiveCityRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ArchiveCityRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ArchiveCityRequest proto.InternalMessageInfo
+
+func (m *ArchiveCityRequest) GetId() uint64 {
+	if m != nil {
+		return m.Id
+	}
+	return 0
+}
+
+func (m *ArchiveCityRequest) GetArchived() bool {
+	if m != nil {
+		return m.Archived
+	}
+	return false
+}
+
+type ArchiveCityResponse struct {
+	City                 *City    `protobuf:"bytes,1,opt,name=city,proto3" json:"city,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ArchiveCityResponse) Reset()         { *m = ArchiveCityResponse{} }
+func (m *ArchiveCityResponse) String() string { return proto.CompactTextString(m) }
+func (*ArchiveCityResponse) ProtoMessage()    {}
+func (*ArchiveCityResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_0937d2e70aaf1027, []int{24}
+}
+
+func (m *ArchiveCityResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ArchiveCityResponse.Unmarshal(m, b)
+}
+func (m *ArchiveCityResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ArchiveCityResponse.Marshal(b, m, deterministic)
+}
+func (m *ArchiveCityResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ArchiveCityResponse.Merge(m, src)
+}
+func (m *ArchiveCityResponse) XXX_Size() int {
+	return xxx_messageInfo_ArchiveCityResponse.Size(m)
+}
+func (m *ArchiveCityResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ArchiveCityResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ArchiveCityResponse proto.InternalMessageInfo
+
+func (m *ArchiveCityResponse) GetCity() *City {
+	if m != nil {
+		return m.City
+	}
+	return nil
+}
+
+func init() {
+	proto.RegisterType((*Place)(nil), "Place")
+	proto.RegisterType((*Location)(nil), "Location")
+	proto.RegisterType((*City)(nil), "City")
+	proto.RegisterType((*AddPlaceRequest)(nil), "AddPlaceRequest")
+	proto.RegisterType((*AddPlaceResponse)(nil), "AddPlaceResponse")
+	proto.RegisterType((*GetCitiesRequest)(nil), "GetCitiesRequest")
+	proto.RegisterType((*GetCitiesResponse)(nil), "GetCitiesResponse")
+	proto.RegisterType((*GetRandomPlaceByCityNameRequest)(nil), "GetRandomPlaceByCityNameRequest")
+	proto.RegisterType((*GetRandomPlaceByCityNameResponse)(nil), "GetRandomPlaceByCityNameResponse")
+	proto.RegisterType((*GetPlacesByCityIDRequest)(nil), "GetPlacesByCityIDRequest")
+	proto.RegisterType((*GetPlacesByCityIDResponse)(nil), "GetPlacesByCityIDResponse")
+	proto.RegisterType((*UpdatePlaceRequest)(nil), "UpdatePlaceRequest")
+	proto.RegisterType((*UpdatePlaceResponse)(nil), "UpdatePlaceResponse")
+	proto.RegisterType((*DeletePlaceRequest)(nil), "DeletePlaceRequest")
+	proto.RegisterType((*DeletePlaceResponse)(nil), "DeletePlaceResponse")
+	proto.RegisterType((*GetPlaceRequest)(nil), "GetPlaceRequest")
+	proto.RegisterType((*GetPlaceResponse)(nil), "GetPlaceResponse")
+	proto.RegisterType((*CreateCityRequest)(nil), "CreateCityRequest")
+	proto.RegisterType((*CreateCityResponse)(nil), "CreateCityResponse")
+	proto.RegisterType((*RenameCityRequest)(nil), "RenameCityRequest")
+	proto.RegisterType((*RenameCityResponse)(nil), "RenameCityResponse")
+	proto.RegisterType((*MergeCitiesRequest)(nil), "MergeCitiesRequest")
+	proto.RegisterType((*MergeCitiesResponse)(nil), "MergeCitiesResponse")
+	proto.RegisterType((*ArchiveCityRequest)(nil), "ArchiveCityRequest")
+	proto.RegisterType((*ArchiveCityResponse)(nil), "ArchiveCityResponse")
+}
+
+func init() {
+	proto.RegisterFile("places.proto", fileDescriptor_0937d2e70aaf1027)
+}
+
+var fileDescriptor_0937d2e70aaf1027 = []byte{
+	// 834 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x55, 0xed, 0x4e, 0xe3, 0x46,
+	0x14, 0x95, 0xf3, 0x45, 0x72, 0x5d, 0x95, 0x64, 0x9c, 0x22, 0x33, 0xa2, 0xad, 0x19, 0xa9, 0x52,
+	0xfa, 0x67, 0x82, 0x82, 0x90, 0xd2, 0x56, 0xad, 0x80, 0x44, 0x05, 0x2a, 0xa8, 0x56, 0x5e, 0xf1,
+	0x1b, 0x99, 0x78, 0x92, 0xb5, 0x70, 0xe2, 0xac, 0x3d, 0x41, 0xe2, 0x0d, 0xf6, 0x49, 0xf6, 0x41,
+	0xf6, 0xc9, 0x56, 0x1e, 0x8f, 0x9d, 0x71, 0xec, 0x90, 0xfc, 0xbc, 0x77, 0xe6, 0xde, 0x73, 0xe7,
+	0xf8, 0x9e, 0x63, 0xf8, 0x61, 0xe9, 0x3b, 0x13, 0x16, 0xd1, 0x65, 0x18, 0xf0, 0x00, 0x5b, 0xb3,
+	0x20, 0x98, 0xf9, 0xac, 0x2f, 0xa2, 0xe7, 0xd5, 0xb4, 0x3f, 0xf5, 0x98, 0xef, 0x3e, 0xcd, 0x9d,
+	0xe8, 0x25, 0xb9, 0x41, 0xbe, 0x69, 0x50, 0xff, 0x10, 0x97, 0xa0, 0x1f, 0xa1, 0xe2, 0xb9, 0xa6,
+	0x66, 0x69, 0xbd, 0x9a, 0x5d, 0xf1, 0x5c, 0xd4, 0x85, 0x3a, 0xf7, 0xb8, 0xcf, 0xcc, 0x8a, 0xa5,
+	0xf5, 0x5a, 0x76, 0x12, 0x20, 0x13, 0x0e, 0x1c, 0xd7, 0x0d, 0x59, 0x14, 0x99, 0x55, 0x91, 0x4f,
+	0x43, 0x64, 0x81, 0xee, 0xb2, 0x68, 0x12, 0x7a, 0x4b, 0xee, 0x05, 0x0b, 0xb3, 0x26, 0x4e, 0xd5,
+	0x14, 0x3a, 0x82, 0x86, 0x37, 0x9f, 0x3d, 0xda, 0xf7, 0x66, 0x5d, 0x1c, 0xca, 0x28, 0xee, 0xf9,
+	0xca, 0xc2, 0x28, 0xae, 0x6a, 0x08, 0xf8, 0x34, 0x44, 0xbf, 0x41, 0xd3, 0x0f, 0x26, 0x8e, 0x68,
+	0x78, 0x60, 0x69, 0x3d, 0x7d, 0xd0, 0xa2, 0xf7, 0x32, 0x61, 0x67, 0x47, 0x64, 0x0c, 0xcd, 0x34,
+	0x8b, 0x30, 0x34, 0x7d, 0x87, 0x7b, 0x7c, 0xe5, 0x32, 0xf1, 0x18, 0xcd, 0xce, 0x62, 0x74, 0x02,
+	0x2d, 0x3f, 0x58, 0xcc, 0x92, 0xc3, 0x8a, 0x38, 0x5c, 0x27, 0xc8, 0x2d, 0xd4, 0x46, 0x1e, 0x7f,
+	0xdb, 0x93, 0x08, 0x0c, 0x4d, 0x27, 0x9c, 0x7c, 0xf2, 0x5e, 0x99, 0x2b, 0x98, 0x68, 0xda, 0x59,
+	0x4c, 0x26, 0x70, 0x78, 0xe5, 0xba, 0x82, 0x56, 0x9b, 0x7d, 0x5e, 0xb1, 0x88, 0xc7, 0xd7, 0x27,
+	0x1e, 0x7f, 0xfb, 0xdf, 0x99, 0x27, 0x63, 0xb5, 0xec, 0x2c, 0x46, 0x27, 0x50, 0x17, 0x5f, 0x4d,
+	0x00, 0xe8, 0x83, 0x06, 0x4d, 0x2a, 0x93, 0x64, 0xcc, 0x5a, 0x7c, 0xf3, 0x6e, 0x2c, 0x60, 0x6a,
+	0xb6, 0x8c, 0x08, 0x81, 0xf6, 0x1a, 0x24, 0x5a, 0x06, 0x8b, 0xa8, 0xf0, 0x0d, 0xc9, 0x35, 0xb4,
+	0x6f, 0x18, 0x1f, 0x79, 0xdc, 0x63, 0x51, 0x3a, 0xc9, 0x11, 0x34, 0x9c, 0x79, 0xb0, 0x5a, 0x70,
+	0x79, 0x4f, 0x46, 0x71, 0x3e, 0x98, 0x4e, 0x23, 0xc6, 0xc5, 0x18, 0x35, 0x5b, 0x46, 0x64, 0x00,
+	0x1d, 0xa5, 0x87, 0x04, 0xfa, 0x59, 0x0c, 0xe5, 0xb1, 0xc8, 0xd4, 0xac, 0x6a, 0x4f, 0x1f, 0xd4,
+	0x69, 0x4c, 0x9d, 0x2d, 0x93, 0xe4, 0x6f, 0xf8, 0xf5, 0x86, 0x71, 0xdb, 0x59, 0xb8, 0xc1, 0x5c,
+	0x4c, 0x78, 0xfd, 0x36, 0x92, 0xaf, 0xdd, 0x83, 0x10, 0x72, 0x09, 0xd6, 0xf6, 0x72, 0x39, 0x41,
+	0x46, 0x9a, 0x56, 0x42, 0x1a, 0x79, 0x06, 0xf3, 0x86, 0x71, 0x91, 0x8a, 0x92, 0xe2, 0xbb, 0xb1,
+	0x42, 0x80, 0x24, 0x54, 0x53, 0x09, 0xdd, 0x46, 0x80, 0x42, 0x58, 0x55, 0x25, 0x8c, 0xfc, 0x05,
+	0xc7, 0x25, 0x18, 0x72, 0xbc, 0x5f, 0xa0, 0x91, 0x28, 0x51, 0x12, 0x94, 0xce, 0x27, 0xb3, 0xe4,
+	0x8b, 0x06, 0xe8, 0x71, 0xe9, 0x3a, 0x9c, 0xe5, 0xd6, 0xe4, 0xdd, 0x57, 0xa1, 0x3f, 0x01, 0x56,
+	0xa2, 0xe6, 0xc1, 0x89, 0x5e, 0xe4, 0xb6, 0x60, 0x9a, 0x68, 0x9c, 0xa6, 0x1a, 0xa7, 0xff, 0xc6,
+	0x1a, 0x8f, 0x6f, 0xd8, 0xca, 0x6d, 0x55, 0x64, 0xd5, 0x9c, 0xc8, 0xc8, 0x39, 0x18, 0xb9, 0x49,
+	0xf6, 0x22, 0xf8, 0x1f, 0x40, 0x63, 0xe6, 0xb3, 0x8d, 0xf1, 0x37, 0xa5, 0xa3, 0x80, 0x56, 0xf2,
+	0xa0, 0x3f, 0x81, 0x91, 0xab, 0x4f, 0x40, 0xc9, 0x29, 0x1c, 0xa6, 0x9c, 0x6e, 0xe9, 0x49, 0x6e,
+	0xa1, 0xbd, 0xbe, 0xb2, 0xcf, 0xac, 0xca, 0x07, 0xaf, 0xe4, 0x14, 0xf4, 0x3b, 0x74, 0x46, 0x21,
+	0x73, 0x38, 0x13, 0xbb, 0x2b, 0xe1, 0x32, 0xb5, 0x6b, 0x8a, 0xda, 0x49, 0x1f, 0x90, 0x7a, 0x55,
+	0xc2, 0x1e, 0x43, 0x2d, 0x6e, 0x25, 0x51, 0xa5, 0x06, 0x44, 0x8a, 0xfc, 0x01, 0x1d, 0x9b, 0x2d,
+	0x9c, 0x79, 0xae, 0xf7, 0x5e, 0xce, 0x12, 0x63, 0xa9, 0xa5, 0xbb, 0xb1, 0xee, 0x01, 0x3d, 0xb0,
+	0x70, 0xc6, 0xf2, 0x3a, 0xc7, 0xd0, 0x8c, 0x82, 0x55, 0x38, 0x61, 0xd9, 0xa2, 0x67, 0x71, 0x7c,
+	0xc6, 0x9d, 0x70, 0xc6, 0x78, 0xc6, 0x49, 0x16, 0x93, 0x33, 0x30, 0x72, 0xdd, 0x76, 0xe3, 0x5f,
+	0x02, 0xba, 0x4a, 0xac, 0xef, 0xbd, 0xc7, 0xaa, 0x86, 0x59, 0xd9, 0x30, 0xcc, 0x33, 0x30, 0x72,
+	0x1d, 0x76, 0x62, 0x0e, 0xbe, 0xd6, 0x41, 0x4f, 0xa4, 0xf7, 0x91, 0x07, 0x21, 0x43, 0x4f, 0x60,
+	0x6e, 0xb3, 0x0c, 0x64, 0xd1, 0x1d, 0x66, 0x84, 0x4f, 0xe9, 0x4e, 0xbf, 0x19, 0x40, 0x2b, 0xb3,
+	0x41, 0xd4, 0xa1, 0x9b, 0xb6, 0x8a, 0x11, 0x2d, 0xba, 0x64, 0x1f, 0x9a, 0xa9, 0x45, 0xa3, 0x36,
+	0xdd, 0xf8, 0x25, 0xe0, 0x0e, 0x2d, 0xf8, 0xf7, 0x7f, 0xc2, 0x6b, 0xf3, 0x96, 0x82, 0x8e, 0xe9,
+	0x36, 0x2b, 0xc3, 0x98, 0x6e, 0x77, 0xa0, 0x21, 0xe8, 0x8a, 0xac, 0x91, 0x41, 0x8b, 0x76, 0x83,
+	0xbb, 0xb4, 0x4c, 0xf9, 0x43, 0xd0, 0x15, 0x6d, 0x22, 0x83, 0x16, 0x95, 0x8e, 0xbb, 0xb4, 0x44,
+	0xbe, 0xf1, 0x83, 0xd3, 0x81, 0x50, 0x9b, 0x6e, 0x28, 0x19, 0x77, 0x68, 0x41, 0xb8, 0x17, 0x00,
+	0x6b, 0x5d, 0x21, 0x44, 0x0b, 0x7a, 0xc4, 0x06, 0x2d, 0x11, 0xde, 0x05, 0xc0, 0x5a, 0x22, 0x08,
+	0xd1, 0x82, 0xd4, 0xb0, 0x41, 0x4b, 0x34, 0x34, 0x04, 0x5d, 0x59, 0x6d, 0x64, 0xd0, 0xa2, 0x6c,
+	0x70, 0x97, 0x96, 0x6d, 0xff, 0x10, 0x74, 0x65, 0x41, 0x91, 0x41, 0x8b, 0x0b, 0x8f, 0xbb, 0xb4,
+	0x64, 0x87, 0x9f, 0x1b, 0xc2, 0x97, 0xcf, 0xbf, 0x0f, 0x00, 0xe4, 0xc7, 0x0c, 0x37, 0x99, 0x09,
+	0x00, 0x00,
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConnInterface
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion6
+
+// PlacesStoreClient is the client API for PlacesStore service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type PlacesStoreClient interface {
+	GetRandomPlaceByCityName(ctx context.Context, in *GetRandomPlaceByCityNameRequest, opts ...grpc.CallOption) (*GetRandomPlaceByCityNameResponse, error)
+	GetCities(ctx context.Context, in *GetCitiesRequest, opts ...grpc.CallOption) (*GetCitiesResponse, error)
+	AddPlace(ctx context.Context, in *AddPlaceRequest, opts ...grpc.CallOption) (*AddPlaceResponse, error)
+	GetPlacesByCityID(ctx context.Context, in *GetPlacesByCityIDRequest, opts ...grpc.CallOption) (*GetPlacesByCityIDResponse, error)
+	UpdatePlace(ctx context.Context, in *UpdatePlaceRequest, opts ...grpc.CallOption) (*UpdatePlaceResponse, error)
+	DeletePlace(ctx context.Context, in *DeletePlaceRequest, opts ...grpc.CallOption) (*DeletePlaceResponse, error)
+	GetPlace(ctx context.Context, in *GetPlaceRequest, opts ...grpc.CallOption) (*GetPlaceResponse, error)
+	CreateCity(ctx context.Context, in *CreateCityRequest, opts ...grpc.CallOption) (*CreateCityResponse, error)
+	RenameCity(ctx context.Context, in *RenameCityRequest, opts ...grpc.CallOption) (*RenameCityResponse, error)
+	MergeCities(ctx context.Context, in *MergeCitiesRequest, opts ...grpc.CallOption) (*MergeCitiesResponse, error)
+	ArchiveCity(ctx context.Context, in *ArchiveCityRequest, opts ...grpc.CallOption) (*ArchiveCityResponse, error)
+}
+
+type placesStoreClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewPlacesStoreClient(cc grpc.ClientConnInterface) PlacesStoreClient {
+	return &placesStoreClient{cc}
+}
+
+func (c *placesStoreClient) GetRandomPlaceByCityName(ctx context.Context, in *GetRandomPlaceByCityNameRequest, opts ...grpc.CallOption) (*GetRandomPlaceByCityNameResponse, error) {
+	out := new(GetRandomPlaceByCityNameResponse)
+	err := c.cc.Invoke(ctx, "/PlacesStore/GetRandomPlaceByCityName", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *placesStoreClient) GetCities(ctx context.Context, in *GetCitiesRequest, opts ...grpc.CallOption) (*GetCitiesResponse, error) {
+	out := new(GetCitiesResponse)
+	err := c.cc.Invoke(ctx, "/PlacesStore/GetCities", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *placesStoreClient) AddPlace(ctx context.Context, in *AddPlaceRequest, opts ...grpc.CallOption) (*AddPlaceResponse, error) {
+	out := new(AddPlaceResponse)
+	err := c.cc.Invoke(ctx, "/PlacesStore/AddPlace", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *placesStoreClient) GetPlacesByCityID(ctx context.Context, in *GetPlacesByCityIDRequest, opts ...grpc.CallOption) (*GetPlacesByCityIDResponse, error) {
+	out := new(GetPlacesByCityIDResponse)
+	err := c.cc.Invoke(ctx, "/PlacesStore/GetPlacesByCityID", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *placesStoreClient) UpdatePlace(ctx context.Context, in *UpdatePlaceRequest, opts ...grpc.CallOption) (*UpdatePlaceResponse, error) {
+	out := new(UpdatePlaceResponse)
+	err := c.cc.Invoke(ctx, "/PlacesStore/UpdatePlace", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *placesStoreClient) DeletePlace(ctx context.Context, in *DeletePlaceRequest, opts ...grpc.CallOption) (*DeletePlaceResponse, error) {
+	out := new(DeletePlaceResponse)
+	err := c.cc.Invoke(ctx, "/PlacesStore/DeletePlace", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *placesStoreClient) GetPlace(ctx context.Context, in *GetPlaceRequest, opts ...grpc.CallOption) (*GetPlaceResponse, error) {
+	out := new(GetPlaceResponse)
+	err := c.cc.Invoke(ctx, "/PlacesStore/GetPlace", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *placesStoreClient) CreateCity(ctx context.Context, in *CreateCityRequest, opts ...grpc.CallOption) (*CreateCityResponse, error) {
+	out := new(CreateCityResponse)
+	err := c.cc.Invoke(ctx, "/PlacesStore/CreateCity", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *placesStoreClient) RenameCity(ctx context.Context, in *RenameCityRequest, opts ...grpc.CallOption) (*RenameCityResponse, error) {
+	out := new(RenameCityResponse)
+	err := c.cc.Invoke(ctx, "/PlacesStore/RenameCity", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *placesStoreClient) MergeCities(ctx context.Context, in *MergeCitiesRequest, opts ...grpc.CallOption) (*MergeCitiesResponse, error) {
+	out := new(MergeCitiesResponse)
+	err := c.cc.Invoke(ctx, "/PlacesStore/MergeCities", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *placesStoreClient) ArchiveCity(ctx context.Context, in *ArchiveCityRequest, opts ...grpc.CallOption) (*ArchiveCityResponse, error) {
+	out := new(ArchiveCityResponse)
+	err := c.cc.Invoke(ctx, "/PlacesStore/ArchiveCity", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// PlacesStoreServer is the server API for PlacesStore service.
+type PlacesStoreServer interface {
+	GetRandomPlaceByCityName(context.Context, *GetRandomPlaceByCityNameRequest) (*GetRandomPlaceByCityNameResponse, error)
+	GetCities(context.Context, *GetCitiesRequest) (*GetCitiesResponse, error)
+	AddPlace(context.Context, *AddPlaceRequest) (*AddPlaceResponse, error)
+	GetPlacesByCityID(context.Context, *GetPlacesByCityIDRequest) (*GetPlacesByCityIDResponse, error)
+	UpdatePlace(context.Context, *UpdatePlaceRequest) (*UpdatePlaceResponse, error)
+	DeletePlace(context.Context, *DeletePlaceRequest) (*DeletePlaceResponse, error)
+	GetPlace(context.Context, *GetPlaceRequest) (*GetPlaceResponse, error)
+	CreateCity(context.Context, *CreateCityRequest) (*CreateCityResponse, error)
+	RenameCity(context.Context, *RenameCityRequest) (*RenameCityResponse, error)
+	MergeCities(context.Context, *MergeCitiesRequest) (*MergeCitiesResponse, error)
+	ArchiveCity(context.Context, *ArchiveCityRequest) (*ArchiveCityResponse, error)
+}
+
+// UnimplementedPlacesStoreServer can be embedded to have forward compatible implementations.
+type UnimplementedPlacesStoreServer struct {
+}
+
+func (*UnimplementedPlacesStoreServer) GetRandomPlaceByCityName(ctx context.Context, req *GetRandomPlaceByCityNameRequest) (*GetRandomPlaceByCityNameResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetRandomPlaceByCityName not implemented")
+}
+func (*UnimplementedPlacesStoreServer) GetCities(ctx context.Context, req *GetCitiesRequest) (*GetCitiesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetCities not implemented")
+}
+func (*UnimplementedPlacesStoreServer) AddPlace(ctx context.Context, req *AddPlaceRequest) (*AddPlaceResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddPlace not implemented")
+}
+func (*UnimplementedPlacesStoreServer) GetPlacesByCityID(ctx context.Context, req *GetPlacesByCityIDRequest) (*GetPlacesByCityIDResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetPlacesByCityID not implemented")
+}
+func (*UnimplementedPlacesStoreServer) UpdatePlace(ctx context.Context, req *UpdatePlaceRequest) (*UpdatePlaceResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdatePlace not implemented")
+}
+func (*UnimplementedPlacesStoreServer) DeletePlace(ctx context.Context, req *DeletePlaceRequest) (*DeletePlaceResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeletePlace not implemented")
+}
+func (*UnimplementedPlacesStoreServer) GetPlace(ctx context.Context, req *GetPlaceRequest) (*GetPlaceResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetPlace not implemented")
+}
+func (*UnimplementedPlacesStoreServer) CreateCity(ctx context.Context, req *CreateCityRequest) (*CreateCityResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateCity not implemented")
+}
+func (*UnimplementedPlacesStoreServer) RenameCity(ctx context.Context, req *RenameCityRequest) (*RenameCityResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RenameCity not implemented")
+}
+func (*UnimplementedPlacesStoreServer) MergeCities(ctx context.Context, req *MergeCitiesRequest) (*MergeCitiesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method MergeCities not implemented")
+}
+func (*UnimplementedPlacesStoreServer) ArchiveCity(ctx context.Context, req *ArchiveCityRequest) (*ArchiveCityResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ArchiveCity not implemented")
+}
+
+func RegisterPlacesStoreServer(s *grpc.Server, srv PlacesStoreServer) {
+	s.RegisterService(&_PlacesStore_serviceDesc, srv)
+}
+
+func _PlacesStore_GetRandomPlaceByCityName_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRandomPlaceByCityNameRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PlacesStoreServer).GetRandomPlaceByCityName(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/PlacesStore/GetRandomPlaceByCityName",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PlacesStoreServer).GetRandomPlaceByCityName(ctx, req.(*GetRandomPlaceByCityNameRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PlacesStore_GetCities_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetCitiesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PlacesStoreServer).GetCities(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/PlacesStore/GetCities",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PlacesStoreServer).GetCities(ctx, req.(*GetCitiesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PlacesStore_AddPlace_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddPlaceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PlacesStoreServer).AddPlace(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/PlacesStore/AddPlace",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PlacesStoreServer).AddPlace(ctx, req.(*AddPlaceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
 func _PlacesStore_GetPlacesByCityID_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
@@ -699,6 +1528,132 @@ func _PlacesStore_GetPlacesByCityID_Handler(srv interface{}, ctx context.Context
 	return interceptor(ctx, in, info, handler)
 }
 
+func _PlacesStore_UpdatePlace_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdatePlaceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PlacesStoreServer).UpdatePlace(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/PlacesStore/UpdatePlace",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PlacesStoreServer).UpdatePlace(ctx, req.(*UpdatePlaceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PlacesStore_DeletePlace_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeletePlaceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PlacesStoreServer).DeletePlace(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/PlacesStore/DeletePlace",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PlacesStoreServer).DeletePlace(ctx, req.(*DeletePlaceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PlacesStore_GetPlace_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetPlaceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PlacesStoreServer).GetPlace(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/PlacesStore/GetPlace",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PlacesStoreServer).GetPlace(ctx, req.(*GetPlaceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PlacesStore_CreateCity_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateCityRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PlacesStoreServer).CreateCity(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/PlacesStore/CreateCity",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PlacesStoreServer).CreateCity(ctx, req.(*CreateCityRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PlacesStore_RenameCity_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RenameCityRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PlacesStoreServer).RenameCity(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/PlacesStore/RenameCity",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PlacesStoreServer).RenameCity(ctx, req.(*RenameCityRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PlacesStore_MergeCities_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MergeCitiesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PlacesStoreServer).MergeCities(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/PlacesStore/MergeCities",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PlacesStoreServer).MergeCities(ctx, req.(*MergeCitiesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PlacesStore_ArchiveCity_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ArchiveCityRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PlacesStoreServer).ArchiveCity(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/PlacesStore/ArchiveCity",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PlacesStoreServer).ArchiveCity(ctx, req.(*ArchiveCityRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _PlacesStore_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "PlacesStore",
 	HandlerType: (*PlacesStoreServer)(nil),
@@ -719,6 +1674,34 @@ var _PlacesStore_serviceDesc = grpc.ServiceDesc{
 			MethodName: "GetPlacesByCityID",
 			Handler:    _PlacesStore_GetPlacesByCityID_Handler,
 		},
+		{
+			MethodName: "UpdatePlace",
+			Handler:    _PlacesStore_UpdatePlace_Handler,
+		},
+		{
+			MethodName: "DeletePlace",
+			Handler:    _PlacesStore_DeletePlace_Handler,
+		},
+		{
+			MethodName: "GetPlace",
+			Handler:    _PlacesStore_GetPlace_Handler,
+		},
+		{
+			MethodName: "CreateCity",
+			Handler:    _PlacesStore_CreateCity_Handler,
+		},
+		{
+			MethodName: "RenameCity",
+			Handler:    _PlacesStore_RenameCity_Handler,
+		},
+		{
+			MethodName: "MergeCities",
+			Handler:    _PlacesStore_MergeCities_Handler,
+		},
+		{
+			MethodName: "ArchiveCity",
+			Handler:    _PlacesStore_ArchiveCity_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "places.proto",
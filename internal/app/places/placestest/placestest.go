@@ -0,0 +1,279 @@
+package placestest
+
+import (
+	"chillit-rest-gateway/internal/app/places"
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/golang/protobuf/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Store is places store kept in memory for tests of packages calling places store.
+// Tests may change its fields between calls, RPCs not implemented here panic
+type Store struct {
+	places.PlacesStoreClient
+
+	mu     sync.Mutex
+	Cities []*places.City
+	// Places by ID of city
+	Places map[uint64][]*places.Place
+	// Added are requests of AddPlace calls in order of calls
+	Added []*places.AddPlaceRequest
+	// NextID is ID of next added place, 1 if it is not set
+	NextID uint64
+	calls  map[string]int
+}
+
+// New creates store with cities Moscow (1) and Kazan (2) and places by ID of city
+func New(cityPlaces map[uint64][]*places.Place) *Store {
+	if cityPlaces == nil {
+		cityPlaces = make(map[uint64][]*places.Place)
+	}
+	return &Store{
+		Cities: []*places.City{{Id: 1, Title: "Moscow"}, {Id: 2, Title: "Kazan"}},
+		Places: cityPlaces,
+	}
+}
+
+// Calls returns number of calls of RPC by its name, e.g. "GetCities"
+func (s *Store) Calls(rpc string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls[rpc]
+}
+
+// call counts call of RPC, caller holds lock
+func (s *Store) call(rpc string) {
+	if s.calls == nil {
+		s.calls = make(map[string]int)
+	}
+	s.calls[rpc]++
+}
+
+// AddedTitles returns titles of places in AddPlace calls in order of calls
+func (s *Store) AddedTitles() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	titles := []string{}
+	for _, req := range s.Added {
+		titles = append(titles, req.GetPlace().GetTitle())
+	}
+	return titles
+}
+
+// Place returns stored place by ID or nil
+func (s *Store) Place(id uint64) *places.Place {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if cityID, i := s.findPlace(id); i >= 0 {
+		return s.Places[cityID][i]
+	}
+	return nil
+}
+
+func (s *Store) findCity(id uint64) *places.City {
+	for _, city := range s.Cities {
+		if city.Id == id {
+			return city
+		}
+	}
+	return nil
+}
+
+func (s *Store) findPlace(id uint64) (uint64, int) {
+	for cityID, cityPlaces := range s.Places {
+		for i, place := range cityPlaces {
+			if place.Id == id {
+				return cityID, i
+			}
+		}
+	}
+	return 0, -1
+}
+
+func (s *Store) GetCities(ctx context.Context, in *places.GetCitiesRequest, opts ...grpc.CallOption) (*places.GetCitiesResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.call("GetCities")
+	if in.Offset >= uint64(len(s.Cities)) {
+		return &places.GetCitiesResponse{}, nil
+	}
+	cities := s.Cities[in.Offset:]
+	if in.Amount > 0 && in.Amount < uint64(len(cities)) {
+		cities = cities[:in.Amount]
+	}
+	return &places.GetCitiesResponse{Cities: cities}, nil
+}
+
+func (s *Store) GetPlacesByCityID(ctx context.Context, in *places.GetPlacesByCityIDRequest, opts ...grpc.CallOption) (*places.GetPlacesByCityIDResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.call("GetPlacesByCityID")
+	cityPlaces := s.Places[in.CityID]
+	if in.Offset >= uint64(len(cityPlaces)) {
+		return &places.GetPlacesByCityIDResponse{}, nil
+	}
+	cityPlaces = cityPlaces[in.Offset:]
+	if in.Amount > 0 && in.Amount < uint64(len(cityPlaces)) {
+		cityPlaces = cityPlaces[:in.Amount]
+	}
+	return &places.GetPlacesByCityIDResponse{Places: cityPlaces}, nil
+}
+
+// GetRandomPlaceByCityName returns the first place of city, so responses are predictable
+func (s *Store) GetRandomPlaceByCityName(ctx context.Context, in *places.GetRandomPlaceByCityNameRequest, opts ...grpc.CallOption) (*places.GetRandomPlaceByCityNameResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.call("GetRandomPlaceByCityName")
+	for _, city := range s.Cities {
+		if strings.EqualFold(city.Title, in.CityName) && len(s.Places[city.Id]) > 0 {
+			return &places.GetRandomPlaceByCityNameResponse{Place: s.Places[city.Id][0]}, nil
+		}
+	}
+	return nil, status.Error(codes.NotFound, "city "+in.CityName+" is not found")
+}
+
+func (s *Store) AddPlace(ctx context.Context, in *places.AddPlaceRequest, opts ...grpc.CallOption) (*places.AddPlaceResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.call("AddPlace")
+	cityID := in.CityID
+	for _, city := range s.Cities {
+		if cityID == 0 && strings.EqualFold(city.Title, in.CityName) {
+			cityID = city.Id
+		}
+	}
+	if city := s.findCity(cityID); city == nil || city.Archived {
+		return nil, status.Error(codes.NotFound, "city not found")
+	}
+	if s.NextID == 0 {
+		s.NextID = 1
+	}
+	place := proto.Clone(in.Place).(*places.Place)
+	place.Id = s.NextID
+	s.NextID++
+	if s.Places == nil {
+		s.Places = make(map[uint64][]*places.Place)
+	}
+	s.Places[cityID] = append(s.Places[cityID], place)
+	s.Added = append(s.Added, in)
+	return &places.AddPlaceResponse{Id: place.Id}, nil
+}
+
+func (s *Store) GetPlace(ctx context.Context, in *places.GetPlaceRequest, opts ...grpc.CallOption) (*places.GetPlaceResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.call("GetPlace")
+	cityID, i := s.findPlace(in.Id)
+	if i < 0 {
+		return nil, status.Error(codes.NotFound, "place not found")
+	}
+	return &places.GetPlaceResponse{Place: s.Places[cityID][i], CityID: cityID}, nil
+}
+
+// UpdatePlace changes fields "title", "address", "description", "imgURL" and "location"
+func (s *Store) UpdatePlace(ctx context.Context, in *places.UpdatePlaceRequest, opts ...grpc.CallOption) (*places.UpdatePlaceResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.call("UpdatePlace")
+	cityID, i := s.findPlace(in.Place.Id)
+	if i < 0 {
+		return nil, status.Error(codes.NotFound, "place not found")
+	}
+	place := s.Places[cityID][i]
+	if in.Version != 0 && in.Version != place.Version {
+		return nil, status.Error(codes.FailedPrecondition, "version mismatch")
+	}
+	for _, path := range in.UpdateMask.Paths {
+		switch path {
+		case "title":
+			place.Title = in.Place.Title
+		case "address":
+			place.Address = in.Place.Address
+		case "description":
+			place.Description = in.Place.Description
+		case "imgURL":
+			place.ImgURL = in.Place.ImgURL
+		case "location":
+			place.Location = in.Place.Location
+		}
+	}
+	place.Version++
+	return &places.UpdatePlaceResponse{Place: place}, nil
+}
+
+func (s *Store) DeletePlace(ctx context.Context, in *places.DeletePlaceRequest, opts ...grpc.CallOption) (*places.DeletePlaceResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.call("DeletePlace")
+	cityID, i := s.findPlace(in.Id)
+	if i < 0 {
+		return nil, status.Error(codes.NotFound, "place not found")
+	}
+	if in.Version != 0 && in.Version != s.Places[cityID][i].Version {
+		return nil, status.Error(codes.FailedPrecondition, "version mismatch")
+	}
+	s.Places[cityID] = append(s.Places[cityID][:i], s.Places[cityID][i+1:]...)
+	return &places.DeletePlaceResponse{}, nil
+}
+
+func (s *Store) CreateCity(ctx context.Context, in *places.CreateCityRequest, opts ...grpc.CallOption) (*places.CreateCityResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.call("CreateCity")
+	for _, city := range s.Cities {
+		if strings.EqualFold(city.Title, in.Title) {
+			return nil, status.Error(codes.AlreadyExists, "city already exists")
+		}
+	}
+	city := &places.City{Id: uint64(len(s.Cities) + 1), Title: in.Title}
+	s.Cities = append(s.Cities, city)
+	return &places.CreateCityResponse{City: city}, nil
+}
+
+func (s *Store) RenameCity(ctx context.Context, in *places.RenameCityRequest, opts ...grpc.CallOption) (*places.RenameCityResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.call("RenameCity")
+	city := s.findCity(in.Id)
+	if city == nil {
+		return nil, status.Error(codes.NotFound, "city not found")
+	}
+	city.Title = in.Title
+	return &places.RenameCityResponse{City: city}, nil
+}
+
+func (s *Store) MergeCities(ctx context.Context, in *places.MergeCitiesRequest, opts ...grpc.CallOption) (*places.MergeCitiesResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.call("MergeCities")
+	target := s.findCity(in.TargetID)
+	if target == nil || s.findCity(in.SourceID) == nil {
+		return nil, status.Error(codes.NotFound, "city not found")
+	}
+	s.Places[in.TargetID] = append(s.Places[in.TargetID], s.Places[in.SourceID]...)
+	delete(s.Places, in.SourceID)
+	for i, city := range s.Cities {
+		if city.Id == in.SourceID {
+			s.Cities = append(s.Cities[:i], s.Cities[i+1:]...)
+			break
+		}
+	}
+	return &places.MergeCitiesResponse{City: target}, nil
+}
+
+func (s *Store) ArchiveCity(ctx context.Context, in *places.ArchiveCityRequest, opts ...grpc.CallOption) (*places.ArchiveCityResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.call("ArchiveCity")
+	city := s.findCity(in.Id)
+	if city == nil {
+		return nil, status.Error(codes.NotFound, "city not found")
+	}
+	city.Archived = in.Archived
+	return &places.ArchiveCityResponse{City: city}, nil
+}
@@ -0,0 +1,209 @@
+package placesync
+
+import (
+	"chillit-rest-gateway/internal/app/places"
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// PageSize is amount of places requested from places store at once
+const PageSize = 100
+
+// EachPlace calls fn for every place of city, reading places page by page
+func EachPlace(ctx context.Context, client places.PlacesStoreClient, cityID uint64, fn func(place *places.Place)) error {
+	for offset := uint64(0); ; offset += PageSize {
+		resp, err := client.GetPlacesByCityID(ctx, &places.GetPlacesByCityIDRequest{
+			CityID: cityID,
+			Offset: offset,
+			Amount: PageSize,
+		})
+		if err != nil {
+			return err
+		}
+		for _, place := range resp.GetPlaces() {
+			fn(place)
+		}
+		if len(resp.GetPlaces()) < PageSize {
+			return nil
+		}
+	}
+}
+
+// Snapshot is places of active cities read from places store
+type Snapshot struct {
+	// Cities are ordered by ID
+	Cities []*places.City
+	// Places by ID of city
+	Places map[uint64][]*places.Place
+	syncer *Syncer
+}
+
+// Written reports whether place was written through gateway since snapshot was started,
+// index keeps such place as it is instead of taking it from snapshot
+func (s *Snapshot) Written(id uint64) bool {
+	if s.syncer == nil {
+		return false
+	}
+	s.syncer.mu.Lock()
+	defer s.syncer.mu.Unlock()
+	return s.syncer.written[id]
+}
+
+// Target is index filled from places store
+type Target interface {
+	// Reload replaces contents of index with snapshot
+	Reload(snapshot *Snapshot) error
+}
+
+// Syncer reads places store in background and reloads all targets from the same snapshot,
+// so every index costs no extra calls to places store
+type Syncer struct {
+	client   places.PlacesStoreClient
+	logger   logrus.FieldLogger
+	targets  []Target
+	interval time.Duration
+
+	// syncMu serializes syncs
+	syncMu sync.Mutex
+	mu     sync.Mutex
+	// written are places written while sync runs
+	written map[uint64]bool
+	syncing bool
+
+	// trigger wakes background sync before its interval
+	trigger chan struct{}
+	cancel  context.CancelFunc
+	done    sync.WaitGroup
+}
+
+// NewSyncer creates syncer without targets
+func NewSyncer(client places.PlacesStoreClient, logger logrus.FieldLogger) *Syncer {
+	return &Syncer{client: client, logger: logger, written: make(map[uint64]bool), trigger: make(chan struct{}, 1)}
+}
+
+// Add registers target reloaded at least every interval, syncer runs at the shortest interval of its targets
+func (s *Syncer) Add(target Target, interval time.Duration) {
+	s.targets = append(s.targets, target)
+	if s.interval <= 0 || interval < s.interval {
+		s.interval = interval
+	}
+}
+
+// Start syncs targets in background, the first sync runs at once. Syncer without targets does nothing
+func (s *Syncer) Start() {
+	if len(s.targets) == 0 || s.cancel != nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	s.done.Add(1)
+	go s.run(ctx)
+}
+
+// Close stops syncing
+func (s *Syncer) Close() error {
+	if s.cancel != nil {
+		s.cancel()
+	}
+	s.done.Wait()
+	return nil
+}
+
+func (s *Syncer) run(ctx context.Context) {
+	defer s.done.Done()
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		if err := s.Sync(ctx); err != nil && ctx.Err() == nil {
+			s.logger.Errorf("could not sync indexes, error: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-s.trigger:
+		}
+	}
+}
+
+// Trigger asks background sync to run at once, e.g. after cities are merged or archived and places
+// of many cities change. Triggers coming while sync runs cause one more sync after it
+func (s *Syncer) Trigger() {
+	select {
+	case s.trigger <- struct{}{}:
+	default:
+	}
+}
+
+// Touch marks place written through gateway, so sync in progress does not override it with stale state
+func (s *Syncer) Touch(id uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.syncing {
+		s.written[id] = true
+	}
+}
+
+// Sync reads snapshot of places store and reloads targets with it
+func (s *Syncer) Sync(ctx context.Context) error {
+	s.syncMu.Lock()
+	defer s.syncMu.Unlock()
+	s.mu.Lock()
+	s.syncing = true
+	s.written = make(map[uint64]bool)
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		s.syncing = false
+		s.mu.Unlock()
+	}()
+
+	snapshot, err := s.read(ctx)
+	if err != nil {
+		return err
+	}
+	// Failure of one target does not keep others stale
+	var failed error
+	for _, target := range s.targets {
+		if err := target.Reload(snapshot); err != nil && failed == nil {
+			failed = errors.New("[ Syncer.Sync ] could not reload index: " + err.Error())
+		}
+	}
+	return failed
+}
+
+// read reads places of active cities. Cities differing only in case are read all, unlike index
+// resolving names, so places of legacy duplicate cities stay in indexes
+func (s *Syncer) read(ctx context.Context) (*Snapshot, error) {
+	snapshot := &Snapshot{Places: make(map[uint64][]*places.Place), syncer: s}
+	for offset := uint64(0); ; offset += PageSize {
+		resp, err := s.client.GetCities(ctx, &places.GetCitiesRequest{Offset: offset, Amount: PageSize})
+		if err != nil {
+			return nil, errors.New("[ Syncer.Sync ] could not get cities: " + err.Error())
+		}
+		for _, city := range resp.GetCities() {
+			if !city.GetArchived() {
+				snapshot.Cities = append(snapshot.Cities, city)
+			}
+		}
+		if len(resp.GetCities()) < PageSize {
+			break
+		}
+	}
+	sort.Slice(snapshot.Cities, func(i, j int) bool { return snapshot.Cities[i].GetId() < snapshot.Cities[j].GetId() })
+
+	for _, city := range snapshot.Cities {
+		cityID := city.GetId()
+		if err := EachPlace(ctx, s.client, cityID, func(place *places.Place) {
+			snapshot.Places[cityID] = append(snapshot.Places[cityID], place)
+		}); err != nil {
+			return nil, errors.New("[ Syncer.Sync ] could not get places: " + err.Error())
+		}
+	}
+	return snapshot, nil
+}
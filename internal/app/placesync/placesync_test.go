@@ -0,0 +1,110 @@
+package placesync
+
+import (
+	"chillit-rest-gateway/internal/app/places"
+	"chillit-rest-gateway/internal/app/places/placestest"
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+type targetStub struct {
+	snapshots []*Snapshot
+	err       error
+	// during is called while snapshot is being reloaded
+	during func(snapshot *Snapshot)
+}
+
+func (t *targetStub) Reload(snapshot *Snapshot) error {
+	if t.during != nil {
+		t.during(snapshot)
+	}
+	t.snapshots = append(t.snapshots, snapshot)
+	return t.err
+}
+
+func TestEachPlace(t *testing.T) {
+	store := placestest.New(nil)
+	for i := 0; i < 2*PageSize; i++ {
+		store.Places[1] = append(store.Places[1], &places.Place{Id: uint64(i + 1), Title: "Place " + strconv.Itoa(i+1)})
+	}
+
+	var ids []uint64
+	assert.NoError(t, EachPlace(context.Background(), store, 1, func(place *places.Place) {
+		ids = append(ids, place.GetId())
+	}))
+	assert.Len(t, ids, 2*PageSize)
+	assert.Equal(t, uint64(2*PageSize), ids[len(ids)-1])
+	// Full last page is followed by one more request
+	assert.Equal(t, 3, store.Calls("GetPlacesByCityID"))
+}
+
+func TestSyncer(t *testing.T) {
+	store := placestest.New(map[uint64][]*places.Place{
+		1: {{Id: 1, Title: "Coffee Bean"}},
+		2: {{Id: 2, Title: "Tea House"}},
+	})
+	store.Cities = append(store.Cities, &places.City{Id: 3, Title: "Sochi", Archived: true})
+	store.Places[3] = []*places.Place{{Id: 3, Title: "Pier"}}
+	// Legacy city differing only in case keeps its places in indexes
+	store.Cities = append(store.Cities, &places.City{Id: 4, Title: "moscow"})
+	store.Places[4] = []*places.Place{{Id: 4, Title: "Bakery"}}
+
+	syncer := NewSyncer(store, logrus.New())
+	first, second := &targetStub{}, &targetStub{err: errors.New("broken")}
+	syncer.Add(first, time.Hour)
+	syncer.Add(second, time.Minute)
+	assert.Equal(t, time.Minute, syncer.interval)
+
+	// Both targets get the same snapshot read once, failure of one does not stop other
+	first.during = func(snapshot *Snapshot) {
+		syncer.Touch(2)
+		assert.True(t, snapshot.Written(2))
+		assert.False(t, snapshot.Written(1))
+	}
+	assert.Error(t, syncer.Sync(context.Background()))
+	if assert.Len(t, first.snapshots, 1) && assert.Len(t, second.snapshots, 1) {
+		snapshot := first.snapshots[0]
+		assert.Equal(t, snapshot, second.snapshots[0])
+		if assert.Len(t, snapshot.Cities, 3) {
+			assert.Equal(t, "Moscow", snapshot.Cities[0].Title)
+			assert.Equal(t, "moscow", snapshot.Cities[2].Title)
+		}
+		assert.Len(t, snapshot.Places, 3)
+		assert.Equal(t, "Tea House", snapshot.Places[2][0].Title)
+		assert.Equal(t, "Bakery", snapshot.Places[4][0].Title)
+	}
+	assert.Equal(t, 3, store.Calls("GetPlacesByCityID"))
+
+	// Writes are tracked only while sync runs
+	first.during = nil
+	syncer.Touch(1)
+	assert.NoError(t, syncer.Close())
+	second.err = nil
+	assert.NoError(t, syncer.Sync(context.Background()))
+	assert.False(t, first.snapshots[1].Written(1))
+	assert.False(t, first.snapshots[1].Written(2))
+}
+
+func TestSyncer_Trigger(t *testing.T) {
+	syncer := NewSyncer(placestest.New(nil), logrus.New())
+	reloaded := make(chan struct{}, 2)
+	syncer.Add(&targetStub{during: func(*Snapshot) { reloaded <- struct{}{} }}, time.Hour)
+	syncer.Start()
+	defer syncer.Close()
+
+	// The first sync runs on start, the next one on trigger instead of after an hour
+	for i := 0; i < 2; i++ {
+		select {
+		case <-reloaded:
+		case <-time.After(5 * time.Second):
+			t.Fatal("index is not reloaded")
+		}
+		syncer.Trigger()
+	}
+}
@@ -0,0 +1,29 @@
+package ratelimit
+
+import "time"
+
+// Config for rate limiter
+type Config struct {
+	// Backend is "local" (default) or "redis"
+	Backend string `yaml:"backend"`
+	// TrustedProxies lists addresses or CIDR ranges whose X-Forwarded-For
+	// header is trusted when resolving client IP
+	TrustedProxies []string  `yaml:"trusted_proxies"`
+	Policies       []*Policy `yaml:"policies"`
+}
+
+// Policy describes token bucket applied to a route
+type Policy struct {
+	// Route is a path template as registered in router, e.g. "/places".
+	// Empty route or "*" matches every route without own policy
+	Route string `yaml:"route"`
+	// KeyBy is one of "ip", "api_key" or "header"
+	KeyBy string `yaml:"key_by"`
+	// Header name used when KeyBy is "header"
+	Header string `yaml:"header"`
+	// Requests allowed per Period
+	Requests uint64        `yaml:"requests"`
+	Period   time.Duration `yaml:"period"`
+	// Burst is bucket capacity, defaults to Requests
+	Burst uint64 `yaml:"burst"`
+}
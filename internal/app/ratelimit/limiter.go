@@ -0,0 +1,211 @@
+package ratelimit
+
+import (
+	"chillit-rest-gateway/internal/app/apikeys"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Key sources for policies
+const (
+	KeyByIP     = "ip"
+	KeyByAPIKey = "api_key"
+	KeyByHeader = "header"
+)
+
+// Result of taking token from bucket
+type Result struct {
+	Allowed    bool
+	Limit      uint64
+	Remaining  uint64
+	Reset      time.Duration
+	RetryAfter time.Duration
+}
+
+// Limiter applies policies to incoming requests
+type Limiter struct {
+	policies       map[string]*Policy
+	defaultPolicy  *Policy
+	trustedProxies []*net.IPNet
+	store          Store
+	now            func() time.Time
+	knownKey       func(key string) bool
+}
+
+// NewLimiter creates limiter from config, keeping buckets in store
+func NewLimiter(config *Config, store Store) (*Limiter, error) {
+	if config == nil {
+		return nil, errors.New("[ NewLimiter ] <nil> config")
+	}
+	if store == nil {
+		return nil, errors.New("[ NewLimiter ] <nil> store")
+	}
+
+	l := &Limiter{
+		policies: make(map[string]*Policy),
+		store:    store,
+		now:      time.Now,
+	}
+
+	for _, policy := range config.Policies {
+		if err := validatePolicy(policy); err != nil {
+			return nil, errors.New("[ NewLimiter ] invalid policy: " + err.Error())
+		}
+		if policy.Route == "" || policy.Route == "*" {
+			l.defaultPolicy = policy
+			continue
+		}
+		l.policies[policy.Route] = policy
+	}
+
+	for _, proxy := range config.TrustedProxies {
+		ipNet, err := parseNet(proxy)
+		if err != nil {
+			return nil, errors.New("[ NewLimiter ] invalid trusted proxy: " + err.Error())
+		}
+		l.trustedProxies = append(l.trustedProxies, ipNet)
+	}
+
+	return l, nil
+}
+
+func validatePolicy(policy *Policy) error {
+	if policy.Requests == 0 || policy.Period <= 0 {
+		return fmt.Errorf("route '%s': requests and period must be positive", policy.Route)
+	}
+	switch policy.KeyBy {
+	case "":
+		policy.KeyBy = KeyByIP
+	case KeyByIP, KeyByAPIKey:
+	case KeyByHeader:
+		if policy.Header == "" {
+			return fmt.Errorf("route '%s': header name is required", policy.Route)
+		}
+	default:
+		return fmt.Errorf("route '%s': unknown key_by '%s'", policy.Route, policy.KeyBy)
+	}
+	if policy.Burst == 0 {
+		policy.Burst = policy.Requests
+	}
+	return nil
+}
+
+func parseNet(s string) (*net.IPNet, error) {
+	if !strings.Contains(s, "/") {
+		ip := net.ParseIP(s)
+		if ip == nil {
+			return nil, fmt.Errorf("could not parse '%s'", s)
+		}
+		bits := 8 * net.IPv6len
+		if ip.To4() != nil {
+			ip = ip.To4()
+			bits = 8 * net.IPv4len
+		}
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+	}
+	_, ipNet, err := net.ParseCIDR(s)
+	return ipNet, err
+}
+
+// KnownKeys lets policies keyed by API key count requests by keys accepted by known.
+// Requests with unknown keys, or with any keys until KnownKeys is called, are counted by client address
+func (l *Limiter) KnownKeys(known func(key string) bool) {
+	l.knownKey = known
+}
+
+// Policy returns policy for route or nil if route is not limited
+func (l *Limiter) Policy(route string) *Policy {
+	if policy, ok := l.policies[route]; ok {
+		return policy
+	}
+	return l.defaultPolicy
+}
+
+// Take takes token for request from bucket of policy
+func (l *Limiter) Take(policy *Policy, r *http.Request) (Result, error) {
+	key := policy.Route + "|" + l.clientKey(policy, r)
+	return TakeToken(l.store, key, policy.Requests, policy.Burst, policy.Period, l.now())
+}
+
+// TakeToken takes token from bucket allowing requests per period with burst capacity
+func TakeToken(store Store, key string, requests, burst uint64, period time.Duration, now time.Time) (Result, error) {
+	capacity := float64(burst)
+	perToken := period / time.Duration(requests)
+	rate := float64(requests) / period.Seconds()
+
+	tokens, allowed, err := store.Take(key, capacity, rate, now)
+	if err != nil {
+		return Result{}, errors.New("[ TakeToken ] could not take token: " + err.Error())
+	}
+
+	result := Result{Limit: burst, Allowed: allowed}
+	if !allowed {
+		result.RetryAfter = time.Duration((1 - tokens) / rate * float64(time.Second))
+		if result.RetryAfter < perToken/10 {
+			result.RetryAfter = perToken / 10
+		}
+	}
+	result.Remaining = uint64(math.Max(tokens, 0))
+	result.Reset = time.Duration((capacity - tokens) / rate * float64(time.Second))
+
+	return result, nil
+}
+
+func (l *Limiter) clientKey(policy *Policy, r *http.Request) string {
+	switch policy.KeyBy {
+	case KeyByAPIKey:
+		key := apikeys.FromRequest(r)
+		// Rate limit runs before authentication, unknown keys must not get buckets of their own
+		if key != "" && l.knownKey != nil && l.knownKey(key) {
+			return "key:" + key
+		}
+	case KeyByHeader:
+		if value := r.Header.Get(policy.Header); value != "" {
+			return "header:" + value
+		}
+	}
+	return "ip:" + l.ClientIP(r)
+}
+
+// ClientIP resolves client address, using X-Forwarded-For only when request came from trusted proxy
+func (l *Limiter) ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	if !l.isTrusted(host) {
+		return host
+	}
+
+	forwarded := strings.Split(strings.Join(r.Header["X-Forwarded-For"], ","), ",")
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		addr := strings.TrimSpace(forwarded[i])
+		if addr == "" {
+			continue
+		}
+		if !l.isTrusted(addr) {
+			return addr
+		}
+		host = addr
+	}
+	return host
+}
+
+func (l *Limiter) isTrusted(addr string) bool {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+	for _, ipNet := range l.trustedProxies {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
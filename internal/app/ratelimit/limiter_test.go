@@ -0,0 +1,67 @@
+package ratelimit
+
+import (
+	"chillit-rest-gateway/internal/app/apikeys"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLimiter_ClientIP(t *testing.T) {
+	l, err := NewLimiter(&Config{TrustedProxies: []string{"10.0.0.0/8", "192.168.1.1"}}, NewMemoryStore())
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  string
+		want       string
+	}{
+		{"direct client", "1.2.3.4:1000", "", "1.2.3.4"},
+		{"untrusted proxy is ignored", "1.2.3.4:1000", "5.6.7.8", "1.2.3.4"},
+		{"trusted proxy", "10.0.0.1:1000", "5.6.7.8", "5.6.7.8"},
+		{"spoofed chain", "10.0.0.1:1000", "6.6.6.6, 5.6.7.8, 192.168.1.1", "5.6.7.8"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			r.RemoteAddr = tt.remoteAddr
+			if tt.forwarded != "" {
+				r.Header.Set("X-Forwarded-For", tt.forwarded)
+			}
+			assert.Equal(t, tt.want, l.ClientIP(r))
+		})
+	}
+}
+
+func TestLimiter_KeyByAPIKey(t *testing.T) {
+	l, err := NewLimiter(&Config{Policies: []*Policy{{Route: "*", KeyBy: KeyByAPIKey, Requests: 1, Period: time.Minute}}}, NewMemoryStore())
+	assert.NoError(t, err)
+	policy := l.Policy("/cities")
+
+	take := func(key string) bool {
+		r := httptest.NewRequest("GET", "/cities", nil)
+		r.Header.Set(apikeys.Header, key)
+		result, err := l.Take(policy, r)
+		assert.NoError(t, err)
+		return result.Allowed
+	}
+
+	// Keys are not trusted until they can be checked
+	assert.True(t, take("first"))
+	assert.False(t, take("second"))
+
+	l.KnownKeys(func(key string) bool { return key == "partner" || key == "other" })
+	assert.True(t, take("partner"))
+	assert.False(t, take("partner"))
+	assert.True(t, take("other"))
+	assert.False(t, take("bogus"))
+
+	// Key in query parameter shares bucket with the same key in header
+	r := httptest.NewRequest("GET", "/cities?"+apikeys.QueryParam+"=other", nil)
+	result, err := l.Take(policy, r)
+	assert.NoError(t, err)
+	assert.False(t, result.Allowed)
+}
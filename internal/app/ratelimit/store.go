@@ -0,0 +1,163 @@
+package ratelimit
+
+import (
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v7"
+	"github.com/sirupsen/logrus"
+)
+
+// Store keeps token buckets
+type Store interface {
+	// Take refills bucket identified by key up to capacity with rate tokens per second
+	// and takes one token if possible, returning tokens left
+	Take(key string, capacity, rate float64, now time.Time) (tokens float64, allowed bool, err error)
+}
+
+// memoryStore keeps buckets in process memory
+type memoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// NewMemoryStore creates in-process bucket store
+func NewMemoryStore() Store {
+	return &memoryStore{buckets: make(map[string]*bucket)}
+}
+
+func (s *memoryStore) Take(key string, capacity, rate float64, now time.Time) (float64, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: capacity, updated: now}
+		s.buckets[key] = b
+	}
+	b.tokens = math.Min(capacity, b.tokens+now.Sub(b.updated).Seconds()*rate)
+	b.updated = now
+
+	allowed := false
+	if b.tokens >= 1 {
+		b.tokens--
+		allowed = true
+	}
+
+	s.cleanup(now)
+
+	return b.tokens, allowed, nil
+}
+
+// cleanup drops stale buckets, keeping memory bounded by active clients
+func (s *memoryStore) cleanup(now time.Time) {
+	if len(s.buckets) < 10000 {
+		return
+	}
+	for key, b := range s.buckets {
+		if now.Sub(b.updated) > time.Hour {
+			delete(s.buckets, key)
+		}
+	}
+}
+
+// takeScript refills and takes token atomically, so instances sharing Redis see same bucket
+var takeScript = redis.NewScript(`
+local capacity = tonumber(ARGV[1])
+local rate = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local bucket = redis.call("HMGET", KEYS[1], "tokens", "updated")
+local tokens = tonumber(bucket[1]) or capacity
+local updated = tonumber(bucket[2]) or now
+tokens = math.min(capacity, tokens + math.max(0, now - updated) * rate)
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+redis.call("HMSET", KEYS[1], "tokens", tostring(tokens), "updated", tostring(now))
+redis.call("EXPIRE", KEYS[1], math.ceil(capacity / rate) + 1)
+return {allowed, tostring(tokens)}
+`)
+
+// redisStore keeps buckets in Redis
+type redisStore struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedisStore creates bucket store shared between gateway instances
+func NewRedisStore(client *redis.Client) Store {
+	return &redisStore{client: client, prefix: "chillit:ratelimit:"}
+}
+
+func (s *redisStore) Take(key string, capacity, rate float64, now time.Time) (float64, bool, error) {
+	nowSeconds := float64(now.UnixNano()) / float64(time.Second)
+	res, err := takeScript.Run(s.client, []string{s.prefix + key},
+		strconv.FormatFloat(capacity, 'f', -1, 64),
+		strconv.FormatFloat(rate, 'f', -1, 64),
+		strconv.FormatFloat(nowSeconds, 'f', 6, 64),
+	).Result()
+	if err != nil {
+		return 0, false, err
+	}
+
+	values, ok := res.([]interface{})
+	if !ok || len(values) != 2 {
+		return 0, false, redis.Nil
+	}
+	allowed, _ := values[0].(int64)
+	tokensStr, _ := values[1].(string)
+	tokens, err := strconv.ParseFloat(tokensStr, 64)
+	if err != nil {
+		return 0, false, err
+	}
+	return tokens, allowed == 1, nil
+}
+
+// fallbackStore uses local store while primary is unreachable
+type fallbackStore struct {
+	primary    Store
+	local      Store
+	logger     logrus.FieldLogger
+	retryDelay time.Duration
+
+	mu      sync.Mutex
+	retryAt time.Time
+}
+
+// NewFallbackStore creates store which switches to local mode on primary errors
+// and tries primary again after retryDelay
+func NewFallbackStore(primary, local Store, retryDelay time.Duration, logger logrus.FieldLogger) Store {
+	return &fallbackStore{
+		primary:    primary,
+		local:      local,
+		logger:     logger,
+		retryDelay: retryDelay,
+	}
+}
+
+func (s *fallbackStore) Take(key string, capacity, rate float64, now time.Time) (float64, bool, error) {
+	s.mu.Lock()
+	degraded := now.Before(s.retryAt)
+	s.mu.Unlock()
+
+	if !degraded {
+		tokens, allowed, err := s.primary.Take(key, capacity, rate, now)
+		if err == nil {
+			return tokens, allowed, nil
+		}
+		s.logger.Warnf("rate limit store is unreachable, falling back to local mode, error: %v", err)
+		s.mu.Lock()
+		s.retryAt = now.Add(s.retryDelay)
+		s.mu.Unlock()
+	}
+	return s.local.Take(key, capacity, rate, now)
+}
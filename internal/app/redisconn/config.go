@@ -0,0 +1,11 @@
+package redisconn
+
+import "time"
+
+// Config for Redis connection
+type Config struct {
+	Address     string        `yaml:"address"`
+	Password    string        `yaml:"password"`
+	DB          int           `yaml:"db"`
+	DialTimeout time.Duration `yaml:"dial_timeout"`
+}
@@ -0,0 +1,26 @@
+package redisconn
+
+import (
+	"errors"
+
+	"github.com/go-redis/redis/v7"
+)
+
+// Connect creates Redis client and checks connection. Client is returned even if
+// Redis is unreachable, so callers are able to work in degraded mode and reconnect later
+func Connect(config *Config) (*redis.Client, error) {
+	if config == nil {
+		return nil, errors.New("[ Connect ] <nil> config")
+	}
+
+	client := redis.NewClient(&redis.Options{
+		Addr:        config.Address,
+		Password:    config.Password,
+		DB:          config.DB,
+		DialTimeout: config.DialTimeout,
+	})
+	if err := client.Ping().Err(); err != nil {
+		return client, errors.New("[ Connect ] redis is unreachable: " + err.Error())
+	}
+	return client, nil
+}
@@ -0,0 +1,127 @@
+package render
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
+	"github.com/vmihailenco/msgpack/v4"
+)
+
+// ProtoMessager is implemented by values having protobuf representation
+type ProtoMessager interface {
+	ToProto() proto.Message
+}
+
+// Table is implemented by lists having CSV representation
+type Table interface {
+	CSV() (header []string, rows [][]string)
+}
+
+// JSONEncoder encodes any value as JSON, protobuf messages with proto3 JSON mapping
+type JSONEncoder struct{}
+
+// MediaTypes of JSON
+func (JSONEncoder) MediaTypes() []string {
+	return []string{"application/json"}
+}
+
+// Supports any value
+func (JSONEncoder) Supports(v interface{}) bool {
+	return true
+}
+
+// Encode v as JSON
+func (JSONEncoder) Encode(w io.Writer, v interface{}) error {
+	if msg, ok := v.(proto.Message); ok {
+		marshaler := jsonpb.Marshaler{EmitDefaults: true}
+		return marshaler.Marshal(w, msg)
+	}
+	return json.NewEncoder(w).Encode(v)
+}
+
+// ProtobufEncoder encodes protobuf messages and ProtoMessager values in binary format
+type ProtobufEncoder struct{}
+
+// MediaTypes of protobuf
+func (ProtobufEncoder) MediaTypes() []string {
+	return []string{"application/x-protobuf", "application/protobuf", "application/vnd.google.protobuf"}
+}
+
+// Supports protobuf messages and ProtoMessager values
+func (ProtobufEncoder) Supports(v interface{}) bool {
+	switch v.(type) {
+	case proto.Message, ProtoMessager:
+		return true
+	}
+	return false
+}
+
+// Encode v in protobuf binary format
+func (ProtobufEncoder) Encode(w io.Writer, v interface{}) error {
+	msg, ok := v.(proto.Message)
+	if m, isMessager := v.(ProtoMessager); isMessager {
+		msg, ok = m.ToProto(), true
+	}
+	if !ok {
+		return errors.New("[ ProtobufEncoder.Encode ] value has no protobuf representation")
+	}
+	data, err := proto.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// MsgpackEncoder encodes any value as MessagePack map with keys taken from json tags
+type MsgpackEncoder struct{}
+
+// MediaTypes of MessagePack
+func (MsgpackEncoder) MediaTypes() []string {
+	return []string{"application/msgpack", "application/x-msgpack"}
+}
+
+// Supports any value
+func (MsgpackEncoder) Supports(v interface{}) bool {
+	return true
+}
+
+// Encode v as MessagePack
+func (MsgpackEncoder) Encode(w io.Writer, v interface{}) error {
+	return msgpack.NewEncoder(w).UseJSONTag(true).Encode(v)
+}
+
+// CSVEncoder encodes Table values with header row
+type CSVEncoder struct{}
+
+// MediaTypes of CSV
+func (CSVEncoder) MediaTypes() []string {
+	return []string{"text/csv"}
+}
+
+// Supports Table values
+func (CSVEncoder) Supports(v interface{}) bool {
+	_, ok := v.(Table)
+	return ok
+}
+
+// Encode v as CSV
+func (CSVEncoder) Encode(w io.Writer, v interface{}) error {
+	table, ok := v.(Table)
+	if !ok {
+		return errors.New("[ CSVEncoder.Encode ] value is not a table")
+	}
+	header, rows := table.CSV()
+	writer := csv.NewWriter(w)
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+	if err := writer.WriteAll(rows); err != nil {
+		return err
+	}
+	return writer.Error()
+}
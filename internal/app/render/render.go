@@ -0,0 +1,144 @@
+package render
+
+import (
+	"errors"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ErrNotAcceptable is returned when no registered encoder matches request and value
+var ErrNotAcceptable = errors.New("not acceptable")
+
+// Encoder writes values in its media type
+type Encoder interface {
+	// MediaTypes accepted by encoder, first one is used as Content-Type
+	MediaTypes() []string
+	// Supports reports whether v may be encoded
+	Supports(v interface{}) bool
+	Encode(w io.Writer, v interface{}) error
+}
+
+// Registry selects encoder by format parameter or Accept header
+type Registry struct {
+	formats  []string
+	encoders map[string]Encoder
+}
+
+// NewRegistry creates registry of JSON, protobuf, MessagePack and CSV encoders, JSON is default
+func NewRegistry() *Registry {
+	r := &Registry{encoders: make(map[string]Encoder)}
+	r.Register("json", JSONEncoder{})
+	r.Register("protobuf", ProtobufEncoder{})
+	r.Register("msgpack", MsgpackEncoder{})
+	r.Register("csv", CSVEncoder{})
+	return r
+}
+
+// Register adds encoder selected by format parameter value, first registered encoder is default
+func (r *Registry) Register(format string, encoder Encoder) {
+	if _, ok := r.encoders[format]; !ok {
+		r.formats = append(r.formats, format)
+	}
+	r.encoders[format] = encoder
+}
+
+// Formats returns names of registered formats
+func (r *Registry) Formats() []string {
+	return r.formats
+}
+
+// Negotiate picks encoder of v. Format parameter wins over Accept header,
+// media ranges of Accept header are tried in order of their quality
+func (r *Registry) Negotiate(format, accept string, v interface{}) (Encoder, error) {
+	if format != "" {
+		encoder, ok := r.encoders[format]
+		if !ok || !encoder.Supports(v) {
+			return nil, ErrNotAcceptable
+		}
+		return encoder, nil
+	}
+
+	for _, mediaRange := range parseAccept(accept) {
+		for _, name := range r.formats {
+			encoder := r.encoders[name]
+			if encoder.Supports(v) && matches(mediaRange, encoder.MediaTypes()) {
+				return encoder, nil
+			}
+		}
+	}
+	return nil, ErrNotAcceptable
+}
+
+func matches(mediaRange string, mediaTypes []string) bool {
+	for _, mediaType := range mediaTypes {
+		switch {
+		case mediaRange == "*/*", mediaRange == mediaType:
+			return true
+		case strings.HasSuffix(mediaRange, "/*") && strings.HasPrefix(mediaType, strings.TrimSuffix(mediaRange, "*")):
+			return true
+		}
+	}
+	return false
+}
+
+// parseAccept returns media ranges sorted by quality, missing header accepts everything
+func parseAccept(accept string) []string {
+	if strings.TrimSpace(accept) == "" {
+		return []string{"*/*"}
+	}
+
+	type weighted struct {
+		mediaRange string
+		q          float64
+	}
+	var ranges []weighted
+	for _, part := range strings.Split(accept, ",") {
+		params := strings.Split(part, ";")
+		item := weighted{mediaRange: strings.ToLower(strings.TrimSpace(params[0])), q: 1}
+		for _, param := range params[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if q, err := strconv.ParseFloat(strings.TrimPrefix(param, "q="), 64); err == nil {
+					item.q = q
+				}
+			}
+		}
+		if item.mediaRange != "" && item.q > 0 {
+			ranges = append(ranges, item)
+		}
+	}
+	sort.SliceStable(ranges, func(i, j int) bool {
+		return ranges[i].q > ranges[j].q
+	})
+
+	mediaRanges := make([]string, len(ranges))
+	for i, item := range ranges {
+		mediaRanges[i] = item.mediaRange
+	}
+	return mediaRanges
+}
+
+// MediaTypes returns main media types of encoders supporting v
+func (r *Registry) MediaTypes(v interface{}) []string {
+	var mediaTypes []string
+	for _, name := range r.formats {
+		if encoder := r.encoders[name]; encoder.Supports(v) {
+			mediaTypes = append(mediaTypes, encoder.MediaTypes()[0])
+		}
+	}
+	return mediaTypes
+}
+
+// Preferred returns offered media type most preferred by Accept header
+func Preferred(accept string, offered []string) (string, bool) {
+	for _, mediaRange := range parseAccept(accept) {
+		for _, mediaType := range offered {
+			if matches(mediaRange, []string{mediaType}) {
+				return mediaType, true
+			}
+		}
+	}
+	return "", false
+}
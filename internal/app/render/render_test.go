@@ -0,0 +1,51 @@
+package render
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type table struct{}
+
+func (table) CSV() ([]string, [][]string) {
+	return []string{"id", "title"}, [][]string{{"1", "Moscow"}, {"2", "Saint \"Petersburg\""}}
+}
+
+func TestRegistry_Negotiate(t *testing.T) {
+	r := NewRegistry()
+
+	for _, tc := range []struct {
+		format, accept string
+		v              interface{}
+		mediaType      string
+	}{
+		{"", "", table{}, "application/json"},
+		{"", "*/*", table{}, "application/json"},
+		{"", "text/csv", table{}, "text/csv"},
+		{"", "text/*", table{}, "text/csv"},
+		{"", "application/xml, application/msgpack;q=0.5, text/csv;q=0.9", table{}, "text/csv"},
+		{"", "application/x-protobuf, application/json;q=0.1", table{}, "application/json"},
+		{"msgpack", "application/json", table{}, "application/msgpack"},
+	} {
+		encoder, err := r.Negotiate(tc.format, tc.accept, tc.v)
+		if assert.NoError(t, err, tc.accept) {
+			assert.Equal(t, tc.mediaType, encoder.MediaTypes()[0], tc.accept)
+		}
+	}
+
+	_, err := r.Negotiate("", "application/xml", table{})
+	assert.Equal(t, ErrNotAcceptable, err)
+	_, err = r.Negotiate("csv", "", struct{}{})
+	assert.Equal(t, ErrNotAcceptable, err)
+	_, err = r.Negotiate("yaml", "", table{})
+	assert.Equal(t, ErrNotAcceptable, err)
+	assert.Equal(t, []string{"application/json", "application/msgpack", "text/csv"}, r.MediaTypes(table{}))
+}
+
+func TestCSVEncoder(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, CSVEncoder{}.Encode(&buf, table{}))
+	assert.Equal(t, "id,title\n1,Moscow\n2,\"Saint \"\"Petersburg\"\"\"\n", buf.String())
+}
@@ -0,0 +1,9 @@
+package search
+
+import "time"
+
+// Config for full-text search over places
+type Config struct {
+	// SyncInterval is how often index is reconciled with places store, 10m by default
+	SyncInterval time.Duration `yaml:"sync_interval"`
+}
@@ -0,0 +1,315 @@
+package search
+
+import (
+	"chillit-rest-gateway/internal/app/places"
+	"chillit-rest-gateway/internal/app/placesync"
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/blevesearch/bleve"
+	"github.com/blevesearch/bleve/analysis/analyzer/custom"
+	"github.com/blevesearch/bleve/analysis/analyzer/keyword"
+	"github.com/blevesearch/bleve/analysis/lang/en"
+	"github.com/blevesearch/bleve/analysis/lang/ru"
+	"github.com/blevesearch/bleve/analysis/token/lowercase"
+	"github.com/blevesearch/bleve/analysis/tokenizer/unicode"
+	"github.com/blevesearch/bleve/mapping"
+	"github.com/blevesearch/bleve/search/highlight/highlighter/html"
+	"github.com/blevesearch/bleve/search/query"
+)
+
+// placesAnalyzer stems both English and Russian words, each stemmer leaves words of other alphabet as they are
+const placesAnalyzer = "places"
+
+// Boosts of fields in relevance ranking
+const (
+	titleBoost       = 3
+	addressBoost     = 1.5
+	descriptionBoost = 1
+)
+
+// document is indexed place
+type document struct {
+	CityID      string `json:"city_id"`
+	Title       string `json:"title"`
+	Address     string `json:"address"`
+	Description string `json:"description"`
+	ImgURL      string `json:"image_url"`
+	Version     uint64 `json:"version"`
+	// Latitude and Longitude are nil for places without location
+	Latitude  *float64 `json:"latitude,omitempty"`
+	Longitude *float64 `json:"longitude,omitempty"`
+}
+
+// Request of search
+type Request struct {
+	Query  string
+	CityID uint64
+	Offset int
+	Amount int
+}
+
+// Hit is found place with relevance score and highlighted fragments by field
+type Hit struct {
+	Place      *places.Place
+	CityID     uint64
+	Score      float64
+	Highlights map[string][]string
+}
+
+// Result of search
+type Result struct {
+	Total uint64
+	Hits  []*Hit
+}
+
+// Index keeps places in memory index reloaded from places store and updated by gateway writes
+type Index struct {
+	config *Config
+	index  bleve.Index
+}
+
+func newMapping() (mapping.IndexMapping, error) {
+	m := bleve.NewIndexMapping()
+	if err := m.AddCustomAnalyzer(placesAnalyzer, map[string]interface{}{
+		"type":      custom.Name,
+		"tokenizer": unicode.Name,
+		"token_filters": []string{
+			lowercase.Name,
+			en.PossessiveName,
+			en.StopName,
+			ru.StopName,
+			en.SnowballStemmerName,
+			ru.SnowballStemmerName,
+		},
+	}); err != nil {
+		return nil, err
+	}
+
+	text := bleve.NewTextFieldMapping()
+	text.Analyzer = placesAnalyzer
+	text.IncludeTermVectors = true
+	city := bleve.NewTextFieldMapping()
+	city.Analyzer = keyword.Name
+	stored := bleve.NewTextFieldMapping()
+	stored.Index = false
+	stored.IncludeInAll = false
+	number := bleve.NewNumericFieldMapping()
+	number.Index = false
+	number.IncludeInAll = false
+
+	doc := bleve.NewDocumentStaticMapping()
+	doc.AddFieldMappingsAt("city_id", city)
+	doc.AddFieldMappingsAt("title", text)
+	doc.AddFieldMappingsAt("address", text)
+	doc.AddFieldMappingsAt("description", text)
+	doc.AddFieldMappingsAt("image_url", stored)
+	doc.AddFieldMappingsAt("version", number)
+	doc.AddFieldMappingsAt("latitude", number)
+	doc.AddFieldMappingsAt("longitude", number)
+	m.DefaultMapping = doc
+	m.DefaultAnalyzer = placesAnalyzer
+	return m, nil
+}
+
+// NewIndex creates empty index, it is filled by placesync.Syncer
+func NewIndex(config *Config) (*Index, error) {
+	if config == nil {
+		return nil, errors.New("[ NewIndex ] <nil> config")
+	}
+	if config.SyncInterval <= 0 {
+		config.SyncInterval = 10 * time.Minute
+	}
+
+	m, err := newMapping()
+	if err != nil {
+		return nil, errors.New("[ NewIndex ] could not create mapping: " + err.Error())
+	}
+	index, err := bleve.NewMemOnly(m)
+	if err != nil {
+		return nil, errors.New("[ NewIndex ] could not create index: " + err.Error())
+	}
+	return &Index{config: config, index: index}, nil
+}
+
+// Config returns config with defaults applied
+func (i *Index) Config() *Config {
+	return i.config
+}
+
+// Close closes index
+func (i *Index) Close() error {
+	return i.index.Close()
+}
+
+func docID(id uint64) string {
+	return strconv.FormatUint(id, 10)
+}
+
+// Put indexes place of city
+func (i *Index) Put(cityID uint64, place *places.Place) error {
+	if err := i.index.Index(docID(place.GetId()), newDocument(cityID, place)); err != nil {
+		return errors.New("[ Index.Put ] could not index place: " + err.Error())
+	}
+	return nil
+}
+
+// Update reindexes changed place in its known city, unknown place is left for next sync
+func (i *Index) Update(place *places.Place) error {
+	id := docID(place.GetId())
+	existing, err := i.index.Document(id)
+	if err != nil {
+		return errors.New("[ Index.Update ] could not read place: " + err.Error())
+	}
+	if existing == nil {
+		return nil
+	}
+	for _, field := range existing.Fields {
+		if field.Name() == "city_id" {
+			cityID, err := strconv.ParseUint(string(field.Value()), 10, 64)
+			if err != nil {
+				return errors.New("[ Index.Update ] invalid city of place: " + err.Error())
+			}
+			return i.Put(cityID, place)
+		}
+	}
+	return nil
+}
+
+// Delete removes place from index
+func (i *Index) Delete(id uint64) error {
+	if err := i.index.Delete(docID(id)); err != nil {
+		return errors.New("[ Index.Delete ] could not delete place: " + err.Error())
+	}
+	return nil
+}
+
+func newDocument(cityID uint64, place *places.Place) *document {
+	doc := &document{
+		CityID:      docID(cityID),
+		Title:       place.GetTitle(),
+		Address:     place.GetAddress(),
+		Description: place.GetDescription(),
+		ImgURL:      place.GetImgURL(),
+		Version:     place.GetVersion(),
+	}
+	if location := place.GetLocation(); location != nil {
+		lat, lon := location.GetLatitude(), location.GetLongitude()
+		doc.Latitude, doc.Longitude = &lat, &lon
+	}
+	return doc
+}
+
+// Reload indexes places of snapshot and drops places missing from it,
+// places written since snapshot was started are left as they are
+func (i *Index) Reload(snapshot *placesync.Snapshot) error {
+	seen := make(map[string]bool)
+	batch := i.index.NewBatch()
+	for _, city := range snapshot.Cities {
+		for _, place := range snapshot.Places[city.GetId()] {
+			id := docID(place.GetId())
+			seen[id] = true
+			if snapshot.Written(place.GetId()) {
+				continue
+			}
+			if err := batch.Index(id, newDocument(city.GetId(), place)); err != nil {
+				return errors.New("[ Index.Reload ] could not index place: " + err.Error())
+			}
+			if batch.Size() >= placesync.PageSize {
+				if err := i.index.Batch(batch); err != nil {
+					return errors.New("[ Index.Reload ] could not index places: " + err.Error())
+				}
+				batch = i.index.NewBatch()
+			}
+		}
+	}
+	if err := i.index.Batch(batch); err != nil {
+		return errors.New("[ Index.Reload ] could not index places: " + err.Error())
+	}
+
+	count, err := i.index.DocCount()
+	if err != nil {
+		return errors.New("[ Index.Reload ] could not count places: " + err.Error())
+	}
+	found, err := i.index.Search(bleve.NewSearchRequestOptions(bleve.NewMatchAllQuery(), int(count), 0, false))
+	if err != nil {
+		return errors.New("[ Index.Reload ] could not list places: " + err.Error())
+	}
+	batch = i.index.NewBatch()
+	for _, hit := range found.Hits {
+		id, err := strconv.ParseUint(hit.ID, 10, 64)
+		if !seen[hit.ID] && (err != nil || !snapshot.Written(id)) {
+			batch.Delete(hit.ID)
+		}
+	}
+	if err := i.index.Batch(batch); err != nil {
+		return errors.New("[ Index.Reload ] could not drop stale places: " + err.Error())
+	}
+	return nil
+}
+
+// Search finds places matching words of query in title, address or description,
+// most relevant first, with matches highlighted by <mark> tags
+func (i *Index) Search(ctx context.Context, req *Request) (*Result, error) {
+	var fields []query.Query
+	for _, field := range []struct {
+		name  string
+		boost float64
+	}{
+		{"title", titleBoost},
+		{"address", addressBoost},
+		{"description", descriptionBoost},
+	} {
+		match := bleve.NewMatchQuery(req.Query)
+		match.SetField(field.name)
+		match.SetBoost(field.boost)
+		fields = append(fields, match)
+	}
+	var q query.Query = bleve.NewDisjunctionQuery(fields...)
+	if req.CityID != 0 {
+		city := bleve.NewTermQuery(docID(req.CityID))
+		city.SetField("city_id")
+		q = bleve.NewConjunctionQuery(city, q)
+	}
+
+	searchReq := bleve.NewSearchRequestOptions(q, req.Amount, req.Offset, false)
+	searchReq.Fields = []string{"*"}
+	searchReq.Highlight = bleve.NewHighlightWithStyle(html.Name)
+	searchReq.Highlight.Fields = []string{"title", "address", "description"}
+	found, err := i.index.SearchInContext(ctx, searchReq)
+	if err != nil {
+		return nil, errors.New("[ Index.Search ] could not search places: " + err.Error())
+	}
+
+	result := &Result{Total: found.Total, Hits: []*Hit{}}
+	for _, hit := range found.Hits {
+		id, err := strconv.ParseUint(hit.ID, 10, 64)
+		if err != nil {
+			continue
+		}
+		place := &places.Place{Id: id}
+		place.Title, _ = hit.Fields["title"].(string)
+		place.Address, _ = hit.Fields["address"].(string)
+		place.Description, _ = hit.Fields["description"].(string)
+		place.ImgURL, _ = hit.Fields["image_url"].(string)
+		if version, ok := hit.Fields["version"].(float64); ok {
+			place.Version = uint64(version)
+		}
+		lat, latOK := hit.Fields["latitude"].(float64)
+		lon, lonOK := hit.Fields["longitude"].(float64)
+		if latOK && lonOK {
+			place.Location = &places.Location{Latitude: lat, Longitude: lon}
+		}
+		city, _ := hit.Fields["city_id"].(string)
+		cityID, _ := strconv.ParseUint(city, 10, 64)
+		result.Hits = append(result.Hits, &Hit{
+			Place:      place,
+			CityID:     cityID,
+			Score:      hit.Score,
+			Highlights: hit.Fragments,
+		})
+	}
+	return result, nil
+}
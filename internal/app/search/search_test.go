@@ -0,0 +1,87 @@
+package search
+
+import (
+	"chillit-rest-gateway/internal/app/places"
+	"chillit-rest-gateway/internal/app/places/placestest"
+	"chillit-rest-gateway/internal/app/placesync"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIndex(t *testing.T) {
+	store := placestest.New(map[uint64][]*places.Place{
+		1: {
+			{Id: 1, Title: "Coffee Bean", Address: "Tverskaya 1", Version: 2, Location: &places.Location{Latitude: 55.7575, Longitude: 37.6125}},
+			{Id: 2, Title: "Sky Bar", Description: "Cocktails on rooftops with a view of coffee shops"},
+			{Id: 3, Title: "Кофейня у дома", Address: "Арбат 5", Description: "Лучшие пирожки"},
+		},
+		2: {{Id: 4, Title: "Coffee House"}},
+	})
+	index, err := NewIndex(&Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer index.Close()
+	syncer := placesync.NewSyncer(store, logrus.New())
+	syncer.Add(index, time.Hour)
+	ctx := context.Background()
+	assert.NoError(t, syncer.Sync(ctx))
+
+	// English stemming, title outranks description
+	result, err := index.Search(ctx, &Request{Query: "coffees", CityID: 1, Amount: 10})
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(2), result.Total)
+	if assert.Len(t, result.Hits, 2) {
+		assert.Equal(t, uint64(1), result.Hits[0].Place.Id)
+		assert.Equal(t, "Tverskaya 1", result.Hits[0].Place.Address)
+		assert.Equal(t, uint64(2), result.Hits[0].Place.Version)
+		assert.Equal(t, &places.Location{Latitude: 55.7575, Longitude: 37.6125}, result.Hits[0].Place.Location)
+		assert.Nil(t, result.Hits[1].Place.Location)
+		assert.Equal(t, uint64(1), result.Hits[0].CityID)
+		assert.Equal(t, []string{"<mark>Coffee</mark> Bean"}, result.Hits[0].Highlights["title"])
+		assert.Equal(t, uint64(2), result.Hits[1].Place.Id)
+	}
+
+	result, err = index.Search(ctx, &Request{Query: "rooftop", CityID: 1, Amount: 10})
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), result.Total)
+
+	// Russian stemming
+	result, err = index.Search(ctx, &Request{Query: "кофейни пирожок", CityID: 1, Amount: 10})
+	assert.NoError(t, err)
+	if assert.Len(t, result.Hits, 1) {
+		assert.Equal(t, uint64(3), result.Hits[0].Place.Id)
+	}
+
+	// Pagination over all cities
+	result, err = index.Search(ctx, &Request{Query: "coffee", Offset: 2, Amount: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(3), result.Total)
+	assert.Len(t, result.Hits, 1)
+
+	// Writes through gateway
+	assert.NoError(t, index.Put(2, &places.Place{Id: 5, Title: "Rooftop Coffee"}))
+	assert.NoError(t, index.Update(&places.Place{Id: 1, Title: "Bean Bar", Address: "Tverskaya 1"}))
+	assert.NoError(t, index.Update(&places.Place{Id: 9, Title: "Unknown Coffee"}))
+	assert.NoError(t, index.Delete(2))
+	result, err = index.Search(ctx, &Request{Query: "coffee", Amount: 10})
+	assert.NoError(t, err)
+	ids := []uint64{}
+	for _, hit := range result.Hits {
+		ids = append(ids, hit.Place.Id)
+	}
+	assert.ElementsMatch(t, []uint64{4, 5}, ids)
+
+	// Sync drops places missing from places store
+	assert.NoError(t, syncer.Sync(ctx))
+	result, err = index.Search(ctx, &Request{Query: "rooftop", Amount: 10})
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), result.Total)
+	if assert.Len(t, result.Hits, 1) {
+		assert.Equal(t, uint64(2), result.Hits[0].Place.Id)
+	}
+}
@@ -0,0 +1,13 @@
+package suggest
+
+import "time"
+
+// Config for autocomplete of city and place names
+type Config struct {
+	// RefreshInterval is how often names are reloaded from places store, 5m by default
+	RefreshInterval time.Duration `yaml:"refresh_interval"`
+	// MaxResults is limit of suggestions, 10 by default
+	MaxResults int `yaml:"max_results"`
+	// MaxEdits is number of typos tolerated in queries of 4 and more letters, 1 by default
+	MaxEdits int `yaml:"max_edits"`
+}
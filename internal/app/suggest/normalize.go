@@ -0,0 +1,43 @@
+package suggest
+
+import (
+	"chillit-rest-gateway/internal/app/textnorm"
+	"strings"
+)
+
+// translit spells Cyrillic letters in Latin, so "Москва" and "moskva" share keys
+var translit = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "e", 'ж': "zh",
+	'з': "z", 'и': "i", 'й': "y", 'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o",
+	'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u", 'ф': "f", 'х': "kh", 'ц': "ts",
+	'ч': "ch", 'ш': "sh", 'щ': "shch", 'ъ': "", 'ы': "y", 'ь': "", 'э': "e", 'ю': "yu",
+	'я': "ya",
+}
+
+// Normalize transliterates name and normalizes it as textnorm.Normalize does
+func Normalize(name string) string {
+	var latin strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if t, ok := translit[r]; ok {
+			latin.WriteString(t)
+			continue
+		}
+		latin.WriteRune(r)
+	}
+	return textnorm.Normalize(latin.String())
+}
+
+// keys returns normalized name starting from each of its words, so places are found by any word
+func keys(name string) []string {
+	normalized := Normalize(name)
+	if normalized == "" {
+		return nil
+	}
+	list := []string{normalized}
+	for i, r := range normalized {
+		if r == ' ' {
+			list = append(list, normalized[i+1:])
+		}
+	}
+	return list
+}
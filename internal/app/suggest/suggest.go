@@ -0,0 +1,140 @@
+package suggest
+
+import (
+	"chillit-rest-gateway/internal/app/placesync"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// minFuzzyLength is length of query from which typos are tolerated, shorter prefixes match too much
+const minFuzzyLength = 4
+
+// Kinds of suggestions
+const (
+	KindCity  = "city"
+	KindPlace = "place"
+)
+
+// Suggestion is city or place with name matching query
+type Suggestion struct {
+	Kind  string `json:"type"`
+	ID    uint64 `json:"id"`
+	Title string `json:"title"`
+	// CityID is city of place
+	CityID uint64 `json:"city_id,omitempty"`
+}
+
+// Index suggests names of cities and places from trie reloaded from places store
+type Index struct {
+	config *Config
+
+	mu   sync.RWMutex
+	trie *trie
+}
+
+// NewIndex creates empty index, it is filled by placesync.Syncer
+func NewIndex(config *Config) (*Index, error) {
+	if config == nil {
+		return nil, errors.New("[ NewIndex ] <nil> config")
+	}
+	if config.RefreshInterval <= 0 {
+		config.RefreshInterval = 5 * time.Minute
+	}
+	if config.MaxResults <= 0 {
+		config.MaxResults = 10
+	}
+	if config.MaxEdits <= 0 {
+		config.MaxEdits = 1
+	}
+
+	return &Index{config: config, trie: newTrie(nil, config.MaxResults)}, nil
+}
+
+// Config returns config with defaults applied
+func (i *Index) Config() *Config {
+	return i.config
+}
+
+// Reload replaces names with names of cities and places of snapshot
+func (i *Index) Reload(snapshot *placesync.Snapshot) error {
+	var entries []*Suggestion
+	for _, city := range snapshot.Cities {
+		entries = append(entries, &Suggestion{Kind: KindCity, ID: city.GetId(), Title: city.GetTitle()})
+		for _, place := range snapshot.Places[city.GetId()] {
+			entries = append(entries, &Suggestion{
+				Kind:   KindPlace,
+				ID:     place.GetId(),
+				Title:  place.GetTitle(),
+				CityID: city.GetId(),
+			})
+		}
+	}
+	i.Load(entries)
+	return nil
+}
+
+// Load replaces suggested names
+func (i *Index) Load(entries []*Suggestion) {
+	sort.SliceStable(entries, func(a, b int) bool {
+		return ranksBefore(entries[a], entries[b])
+	})
+	t := newTrie(entries, i.config.MaxResults)
+
+	i.mu.Lock()
+	i.trie = t
+	i.mu.Unlock()
+}
+
+// ranksBefore orders cities before places, then shorter names, then names alphabetically
+func ranksBefore(a, b *Suggestion) bool {
+	if a.Kind != b.Kind {
+		return a.Kind == KindCity
+	}
+	if len(a.Title) != len(b.Title) {
+		return len(a.Title) < len(b.Title)
+	}
+	return a.Title < b.Title
+}
+
+// Suggest returns up to limit names starting with query, exact matches first
+func (i *Index) Suggest(query string, limit int) []*Suggestion {
+	if limit <= 0 || limit > i.config.MaxResults {
+		limit = i.config.MaxResults
+	}
+	query = Normalize(query)
+	if query == "" {
+		return []*Suggestion{}
+	}
+	maxEdits := 0
+	if len([]rune(query)) >= minFuzzyLength {
+		maxEdits = i.config.MaxEdits
+	}
+
+	i.mu.RLock()
+	t := i.trie
+	i.mu.RUnlock()
+
+	found := t.search(query, maxEdits)
+	matched := make([]int32, 0, len(found))
+	for entry := range found {
+		matched = append(matched, entry)
+	}
+	// Entries are numbered in rank order
+	sort.Slice(matched, func(a, b int) bool {
+		if found[matched[a]] != found[matched[b]] {
+			return found[matched[a]] < found[matched[b]]
+		}
+		return matched[a] < matched[b]
+	})
+	if len(matched) > limit {
+		matched = matched[:limit]
+	}
+
+	suggestions := make([]*Suggestion, len(matched))
+	for n, entry := range matched {
+		suggestions[n] = t.entries[entry]
+	}
+	return suggestions
+}
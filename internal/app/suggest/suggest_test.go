@@ -0,0 +1,81 @@
+package suggest
+
+import (
+	"chillit-rest-gateway/internal/app/places"
+	"chillit-rest-gateway/internal/app/places/placestest"
+	"chillit-rest-gateway/internal/app/placesync"
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func titles(list []*Suggestion) []string {
+	result := []string{}
+	for _, s := range list {
+		result = append(result, s.Title)
+	}
+	return result
+}
+
+func TestNormalize(t *testing.T) {
+	assert.Equal(t, "moskva", Normalize("Москва"))
+	assert.Equal(t, "kofeynya u doma", Normalize("Кофейня «У дома»"))
+	assert.Equal(t, "cafe de flore", Normalize("  Café-de Flore!"))
+}
+
+func TestIndex(t *testing.T) {
+	store := placestest.New(map[uint64][]*places.Place{
+		1: {{Id: 1, Title: "Coffee Bean"}, {Id: 2, Title: "Coffeemania"}, {Id: 3, Title: "Кофейня у дома"}},
+		3: {{Id: 4, Title: "Coffee Museum"}},
+	})
+	store.Cities = []*places.City{{Id: 1, Title: "Moscow"}, {Id: 2, Title: "Москва-Сити"}, {Id: 3, Title: "Old", Archived: true}}
+	index, err := NewIndex(&Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	syncer := placesync.NewSyncer(store, logrus.New())
+	syncer.Add(index, time.Hour)
+	assert.NoError(t, syncer.Sync(context.Background()))
+
+	// Shorter names first, places of archived cities are skipped
+	assert.Equal(t, []string{"Coffee Bean", "Coffeemania"}, titles(index.Suggest("coffee", 0)))
+	// Any word of name
+	assert.Equal(t, []string{"Coffee Bean"}, titles(index.Suggest("bea", 0)))
+	// Cities before places, Cyrillic and Latin spellings match each other
+	assert.Equal(t, []string{"Москва-Сити"}, titles(index.Suggest("moskva", 0)))
+	assert.Equal(t, []string{"Moscow", "Москва-Сити"}, titles(index.Suggest("мос", 0)))
+	assert.Equal(t, []string{"Кофейня у дома"}, titles(index.Suggest("kofey", 0)))
+	assert.Equal(t, []string{"Кофейня у дома"}, titles(index.Suggest("дома", 0)))
+
+	// Typos are tolerated in longer queries, exact matches rank first
+	assert.Equal(t, []string{"Coffee Bean", "Coffeemania"}, titles(index.Suggest("cofee", 0)))
+	assert.Equal(t, "Moscow", index.Suggest("mosvow", 0)[0].Title)
+	assert.Empty(t, index.Suggest("cpf", 0))
+
+	list := index.Suggest("co", 1)
+	if assert.Len(t, list, 1) {
+		assert.Equal(t, &Suggestion{Kind: KindPlace, ID: 1, Title: "Coffee Bean", CityID: 1}, list[0])
+	}
+	assert.Empty(t, index.Suggest("!!", 0))
+}
+
+func BenchmarkIndex_Suggest(b *testing.B) {
+	index := &Index{config: &Config{MaxResults: 10, MaxEdits: 1}}
+	words := []string{"coffee", "bar", "bistro", "кофейня", "пельменная", "garden", "rooftop", "bakery", "grill", "chai"}
+	var entries []*Suggestion
+	for i := 0; i < 50000; i++ {
+		title := fmt.Sprintf("%s %s %d", words[i%len(words)], words[(i/len(words))%len(words)], i)
+		entries = append(entries, &Suggestion{Kind: KindPlace, ID: uint64(i + 1), Title: title, CityID: uint64(i%100 + 1)})
+	}
+	index.Load(entries)
+
+	queries := []string{"c", "cof", "cofee", "пельм", "rooftop gar", "bakry", "1234"}
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		index.Suggest(queries[n%len(queries)], 10)
+	}
+}
@@ -0,0 +1,99 @@
+package suggest
+
+// node of trie, top keeps best ranked entries of subtree, so short prefixes are answered without walking it
+type node struct {
+	children map[rune]*node
+	top      []int32
+}
+
+type trie struct {
+	root    *node
+	entries []*Suggestion
+	limit   int
+}
+
+// newTrie indexes entries already sorted by rank, keeping up to limit entries at each node
+func newTrie(entries []*Suggestion, limit int) *trie {
+	t := &trie{root: &node{}, entries: entries, limit: limit}
+	for i, entry := range entries {
+		for _, key := range keys(entry.Title) {
+			t.insert(key, int32(i))
+		}
+	}
+	return t
+}
+
+func (t *trie) insert(key string, entry int32) {
+	n := t.root
+	for _, r := range key {
+		child := n.children[r]
+		if child == nil {
+			if n.children == nil {
+				n.children = make(map[rune]*node)
+			}
+			child = &node{}
+			n.children[r] = child
+		}
+		n = child
+		// Entries come in rank order, so entry already added by its other key is the last one
+		if len(n.top) < t.limit && (len(n.top) == 0 || n.top[len(n.top)-1] != entry) {
+			n.top = append(n.top, entry)
+		}
+	}
+}
+
+// search returns entries whose keys start with query within maxEdits edits, best distance of each entry
+func (t *trie) search(query string, maxEdits int) map[int32]int {
+	q := []rune(query)
+	found := make(map[int32]int)
+	row := make([]int, len(q)+1)
+	for i := range row {
+		row[i] = i
+	}
+	for r, child := range t.root.children {
+		t.walk(child, r, q, row, maxEdits, found)
+	}
+	return found
+}
+
+// walk advances Levenshtein row by letter of child, standard trie traversal bounded by maxEdits
+func (t *trie) walk(n *node, letter rune, q []rune, prev []int, maxEdits int, found map[int32]int) {
+	row := make([]int, len(prev))
+	row[0] = prev[0] + 1
+	best := row[0]
+	for i := 1; i < len(row); i++ {
+		cost := 1
+		if q[i-1] == letter {
+			cost = 0
+		}
+		row[i] = minInt(minInt(row[i-1]+1, prev[i]+1), prev[i-1]+cost)
+		if row[i] < best {
+			best = row[i]
+		}
+	}
+
+	if distance := row[len(row)-1]; distance <= maxEdits {
+		t.collect(n, distance, found)
+	}
+	if best > maxEdits {
+		return
+	}
+	for r, child := range n.children {
+		t.walk(child, r, q, row, maxEdits, found)
+	}
+}
+
+func (t *trie) collect(n *node, distance int, found map[int32]int) {
+	for _, entry := range n.top {
+		if d, ok := found[entry]; !ok || distance < d {
+			found[entry] = distance
+		}
+	}
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,13 @@
+package textnorm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalize(t *testing.T) {
+	assert.Equal(t, "cafe pushkin 2", Normalize("  Café «Pushkin»-2!"))
+	assert.Equal(t, "moscow", Normalize("Moscow "))
+	assert.Equal(t, "", Normalize(" -- "))
+}
@@ -0,0 +1,24 @@
+package transcoding
+
+// Config for transcoding of places store RPCs to REST
+type Config struct {
+	Rules []*Rule `yaml:"rules"`
+}
+
+// Rule binds RPC to HTTP route the same way google.api.http annotation does
+type Rule struct {
+	// Selector is RPC name, e.g. "PlacesStore.GetCities"
+	Selector string `yaml:"selector"`
+	// One of methods is set to path template, e.g. get: "/store/cities/{cityID}/places"
+	Get    string `yaml:"get"`
+	Post   string `yaml:"post"`
+	Put    string `yaml:"put"`
+	Patch  string `yaml:"patch"`
+	Delete string `yaml:"delete"`
+	// Body is "*" to map whole JSON body to request message, name of request field
+	// to map body to that field, or empty if route has no body
+	Body string `yaml:"body"`
+	// Scope required from callers, anonymous callers pass if AllowAnonymous is set
+	Scope          string `yaml:"scope"`
+	AllowAnonymous bool   `yaml:"allow_anonymous"`
+}
@@ -0,0 +1,321 @@
+package transcoding
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
+	"google.golang.org/grpc/codes"
+)
+
+// serviceName of transcoded client
+const serviceName = "PlacesStore"
+
+var (
+	contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+	messageType = reflect.TypeOf((*proto.Message)(nil)).Elem()
+	errorType   = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// Endpoint is RPC exposed as REST route
+type Endpoint struct {
+	Rule   *Rule
+	RPC    string
+	Method string
+	Path   string
+	// Request and Response are zero messages of RPC, Body is zero message
+	// decoded from request body or nil if route has no body
+	Request  proto.Message
+	Response proto.Message
+	Body     proto.Message
+
+	requestType reflect.Type
+	invoke      reflect.Value
+}
+
+// NewEndpoints binds rules to methods of gRPC client, e.g. places.PlacesStoreClient
+func NewEndpoints(config *Config, client interface{}) ([]*Endpoint, error) {
+	if config == nil {
+		return nil, errors.New("[ NewEndpoints ] <nil> config")
+	}
+
+	clientValue := reflect.ValueOf(client)
+	var endpoints []*Endpoint
+	for _, rule := range config.Rules {
+		endpoint, err := newEndpoint(rule, clientValue)
+		if err != nil {
+			return nil, errors.New("[ NewEndpoints ] " + err.Error())
+		}
+		endpoints = append(endpoints, endpoint)
+	}
+	return endpoints, nil
+}
+
+func newEndpoint(rule *Rule, client reflect.Value) (*Endpoint, error) {
+	parts := strings.Split(rule.Selector, ".")
+	if len(parts) != 2 || parts[0] != serviceName {
+		return nil, fmt.Errorf("selector '%s' must be '%s.<RPC>'", rule.Selector, serviceName)
+	}
+
+	endpoint := &Endpoint{Rule: rule, RPC: parts[1]}
+	for method, path := range map[string]string{
+		http.MethodGet:    rule.Get,
+		http.MethodPost:   rule.Post,
+		http.MethodPut:    rule.Put,
+		http.MethodPatch:  rule.Patch,
+		http.MethodDelete: rule.Delete,
+	} {
+		if path == "" {
+			continue
+		}
+		if endpoint.Path != "" {
+			return nil, fmt.Errorf("rule '%s' must have exactly one HTTP method", rule.Selector)
+		}
+		endpoint.Method, endpoint.Path = method, path
+	}
+	if endpoint.Path == "" {
+		return nil, fmt.Errorf("rule '%s' has no HTTP method", rule.Selector)
+	}
+
+	invoke := client.MethodByName(endpoint.RPC)
+	if !invoke.IsValid() {
+		return nil, fmt.Errorf("RPC '%s' is not found", rule.Selector)
+	}
+	t := invoke.Type()
+	if t.NumIn() < 2 || t.In(0) != contextType || !t.In(1).Implements(messageType) ||
+		t.NumOut() != 2 || !t.Out(0).Implements(messageType) || t.Out(1) != errorType {
+		return nil, fmt.Errorf("'%s' is not unary RPC", rule.Selector)
+	}
+	endpoint.invoke = invoke
+	endpoint.requestType = t.In(1).Elem()
+	endpoint.Request = reflect.New(endpoint.requestType).Interface().(proto.Message)
+	endpoint.Response = reflect.New(t.Out(0).Elem()).Interface().(proto.Message)
+
+	for _, name := range PathVariables(endpoint.Path) {
+		if _, ok := fieldByName(reflect.New(endpoint.requestType).Elem(), name); !ok {
+			return nil, fmt.Errorf("rule '%s': path variable '%s' is not a field of request", rule.Selector, name)
+		}
+	}
+	switch rule.Body {
+	case "":
+	case "*":
+		endpoint.Body = endpoint.Request
+	default:
+		field, ok := fieldByName(reflect.New(endpoint.requestType).Elem(), rule.Body)
+		if !ok || !field.Type().Implements(messageType) {
+			return nil, fmt.Errorf("rule '%s': body '%s' is not a message field of request", rule.Selector, rule.Body)
+		}
+		endpoint.Body = reflect.New(field.Type().Elem()).Interface().(proto.Message)
+	}
+	return endpoint, nil
+}
+
+// PathVariables returns names of variables of path template
+func PathVariables(path string) []string {
+	var names []string
+	for _, segment := range strings.Split(path, "/") {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			names = append(names, strings.SplitN(segment[1:len(segment)-1], ":", 2)[0])
+		}
+	}
+	return names
+}
+
+// Decode builds request message from body, query parameters and path variables
+func (e *Endpoint) Decode(body io.Reader, query url.Values, vars map[string]string) (proto.Message, error) {
+	req := reflect.New(e.requestType)
+	msg := req.Interface().(proto.Message)
+
+	switch e.Rule.Body {
+	case "":
+	case "*":
+		if err := jsonpb.Unmarshal(body, msg); err != nil && err != io.EOF {
+			return nil, errors.New("could not decode body: " + err.Error())
+		}
+	default:
+		field, _ := fieldByName(req.Elem(), e.Rule.Body)
+		if field.Kind() == reflect.Ptr && field.Type().Implements(messageType) {
+			field.Set(reflect.New(field.Type().Elem()))
+			if err := jsonpb.Unmarshal(body, field.Interface().(proto.Message)); err != nil && err != io.EOF {
+				return nil, errors.New("could not decode body: " + err.Error())
+			}
+		}
+	}
+
+	if e.Rule.Body != "*" {
+		queryFields := e.QueryFields()
+		for name, values := range query {
+			if _, ok := queryFields[name]; !ok {
+				continue
+			}
+			if err := setField(req.Elem(), name, values[len(values)-1]); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for name, value := range vars {
+		if err := setField(req.Elem(), name, value); err != nil {
+			return nil, err
+		}
+	}
+	return msg, nil
+}
+
+// Invoke calls RPC
+func (e *Endpoint) Invoke(ctx context.Context, req proto.Message) (proto.Message, error) {
+	out := e.invoke.Call([]reflect.Value{reflect.ValueOf(ctx), reflect.ValueOf(req)})
+	if err, _ := out[1].Interface().(error); err != nil {
+		return nil, err
+	}
+	return out[0].Interface().(proto.Message), nil
+}
+
+// QueryFields returns names of scalar request fields which may be passed as query parameters
+func (e *Endpoint) QueryFields() map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+	if e.Rule.Body == "*" {
+		return fields
+	}
+	pathVars := make(map[string]bool)
+	for _, name := range PathVariables(e.Path) {
+		pathVars[name] = true
+	}
+	collectScalarFields(e.requestType, "", pathVars, e.Rule.Body, fields)
+	return fields
+}
+
+func collectScalarFields(t reflect.Type, prefix string, skip map[string]bool, body string, fields map[string]reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := protoFieldName(field)
+		if name == "" || skip[prefix+name] || (prefix == "" && name == body) {
+			continue
+		}
+		switch {
+		case field.Type.Kind() == reflect.Ptr && field.Type.Elem().Kind() == reflect.Struct:
+			collectScalarFields(field.Type.Elem(), prefix+name+".", skip, body, fields)
+		case field.Type.Kind() != reflect.Slice && field.Type.Kind() != reflect.Map:
+			fields[prefix+name] = field.Type
+		}
+	}
+}
+
+// protoFieldName returns JSON name of generated message field or empty string for internal fields
+func protoFieldName(field reflect.StructField) string {
+	tag := field.Tag.Get("protobuf")
+	if tag == "" {
+		return ""
+	}
+	var name string
+	for _, option := range strings.Split(tag, ",") {
+		if strings.HasPrefix(option, "name=") {
+			name = strings.TrimPrefix(option, "name=")
+		}
+		if strings.HasPrefix(option, "json=") {
+			return strings.TrimPrefix(option, "json=")
+		}
+	}
+	return name
+}
+
+// fieldByName finds field by dotted path of proto field names, allocating nested messages
+func fieldByName(v reflect.Value, path string) (reflect.Value, bool) {
+	parts := strings.SplitN(path, ".", 2)
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if protoFieldName(t.Field(i)) != parts[0] {
+			continue
+		}
+		field := v.Field(i)
+		if len(parts) == 1 {
+			return field, true
+		}
+		if field.Kind() != reflect.Ptr || field.Type().Elem().Kind() != reflect.Struct {
+			return reflect.Value{}, false
+		}
+		if field.IsNil() {
+			field.Set(reflect.New(field.Type().Elem()))
+		}
+		return fieldByName(field.Elem(), parts[1])
+	}
+	return reflect.Value{}, false
+}
+
+// setField parses value into scalar field. Unknown fields are rejected
+func setField(v reflect.Value, path, value string) error {
+	field, ok := fieldByName(v, path)
+	if !ok {
+		return fmt.Errorf("unknown field '%s'", path)
+	}
+
+	var err error
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		var b bool
+		b, err = strconv.ParseBool(value)
+		field.SetBool(b)
+	case reflect.Int32, reflect.Int64:
+		var n int64
+		n, err = strconv.ParseInt(value, 10, field.Type().Bits())
+		field.SetInt(n)
+	case reflect.Uint32, reflect.Uint64:
+		var n uint64
+		n, err = strconv.ParseUint(value, 10, field.Type().Bits())
+		field.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		var f float64
+		f, err = strconv.ParseFloat(value, field.Type().Bits())
+		field.SetFloat(f)
+	default:
+		return fmt.Errorf("field '%s' could not be set from string", path)
+	}
+	if err != nil {
+		return fmt.Errorf("invalid value of field '%s': %v", path, err)
+	}
+	return nil
+}
+
+// Marshal encodes response message as proto3 JSON
+func Marshal(w io.Writer, msg proto.Message) error {
+	marshaler := jsonpb.Marshaler{EmitDefaults: true}
+	return marshaler.Marshal(w, msg)
+}
+
+// HTTPStatus maps gRPC status code to HTTP status
+func HTTPStatus(code codes.Code) int {
+	switch code {
+	case codes.OK:
+		return http.StatusOK
+	case codes.Canceled:
+		return 499
+	case codes.InvalidArgument, codes.FailedPrecondition, codes.OutOfRange:
+		return http.StatusBadRequest
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
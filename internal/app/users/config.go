@@ -0,0 +1,15 @@
+package users
+
+import "time"
+
+// Config for user accounts
+type Config struct {
+	// DBPath is path of embedded database file
+	DBPath     string        `yaml:"db_path"`
+	SessionTTL time.Duration `yaml:"session_ttl"`
+	// CookieName of session cookie, "chillit_session" by default
+	CookieName   string `yaml:"cookie_name"`
+	CookieSecure bool   `yaml:"cookie_secure"`
+	// DefaultScopes are granted to registered users
+	DefaultScopes []string `yaml:"default_scopes"`
+}
@@ -0,0 +1,283 @@
+package users
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	bolt "go.etcd.io/bbolt"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Errors returned by store
+var (
+	ErrUserExists         = errors.New("user already exists")
+	ErrInvalidUsername    = errors.New("username must be 3-32 characters of letters, digits, '_', '-' or '.'")
+	ErrWeakPassword       = errors.New("password must be at least 8 characters long")
+	ErrInvalidCredentials = errors.New("invalid username or password")
+	ErrSessionNotFound    = errors.New("session not found")
+)
+
+var (
+	usersBucket    = []byte("users")
+	sessionsBucket = []byte("sessions")
+)
+
+// User account
+type User struct {
+	Username     string    `json:"username"`
+	PasswordHash []byte    `json:"password_hash"`
+	Scopes       []string  `json:"scopes"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// Session of logged in user
+type Session struct {
+	// Token is known only right after session creation, store keeps its hash
+	Token     string    `json:"-"`
+	Username  string    `json:"username"`
+	CSRFToken string    `json:"csrf_token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Store keeps users and sessions in embedded database
+type Store struct {
+	db     *bolt.DB
+	config *Config
+}
+
+// NewStore opens database
+func NewStore(config *Config) (*Store, error) {
+	if config == nil {
+		return nil, errors.New("[ NewStore ] <nil> config")
+	}
+	if config.DBPath == "" {
+		return nil, errors.New("[ NewStore ] db_path is required")
+	}
+	if config.SessionTTL <= 0 {
+		config.SessionTTL = 7 * 24 * time.Hour
+	}
+	if config.CookieName == "" {
+		config.CookieName = "chillit_session"
+	}
+
+	db, err := bolt.Open(config.DBPath, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, errors.New("[ NewStore ] could not open database: " + err.Error())
+	}
+	if err := db.Update(func(tx *bolt.Tx) error {
+		for _, bucket := range [][]byte{usersBucket, sessionsBucket} {
+			if _, err := tx.CreateBucketIfNotExists(bucket); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		db.Close()
+		return nil, errors.New("[ NewStore ] could not create buckets: " + err.Error())
+	}
+
+	return &Store{db: db, config: config}, nil
+}
+
+// Config returns store config with defaults applied
+func (s *Store) Config() *Config {
+	return s.config
+}
+
+// Close closes database
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+func validateUsername(username string) error {
+	if utf8.RuneCountInString(username) < 3 || utf8.RuneCountInString(username) > 32 {
+		return ErrInvalidUsername
+	}
+	for _, r := range username {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '-' || r == '.') {
+			return ErrInvalidUsername
+		}
+	}
+	return nil
+}
+
+// Register creates user with hashed password
+func (s *Store) Register(username, password string) (*User, error) {
+	if err := validateUsername(username); err != nil {
+		return nil, err
+	}
+	if utf8.RuneCountInString(password) < 8 {
+		return nil, ErrWeakPassword
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, errors.New("[ Register ] could not hash password: " + err.Error())
+	}
+	user := &User{
+		Username:     username,
+		PasswordHash: hash,
+		Scopes:       s.config.DefaultScopes,
+		CreatedAt:    time.Now().UTC(),
+	}
+
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(usersBucket)
+		key := []byte(strings.ToLower(username))
+		if bucket.Get(key) != nil {
+			return ErrUserExists
+		}
+		data, err := json.Marshal(user)
+		if err != nil {
+			return err
+		}
+		return bucket.Put(key, data)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// User finds user by name
+func (s *Store) User(username string) (*User, error) {
+	var user *User
+	err := s.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(usersBucket).Get([]byte(strings.ToLower(username)))
+		if data == nil {
+			return nil
+		}
+		user = &User{}
+		return json.Unmarshal(data, user)
+	})
+	return user, err
+}
+
+// Authenticate checks user password
+func (s *Store) Authenticate(username, password string) (*User, error) {
+	user, err := s.User(username)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		// Compare anyway, so response time does not reveal which usernames exist
+		bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+		return nil, ErrInvalidCredentials
+	}
+	if err := bcrypt.CompareHashAndPassword(user.PasswordHash, []byte(password)); err != nil {
+		return nil, ErrInvalidCredentials
+	}
+	return user, nil
+}
+
+var dummyHash, _ = bcrypt.GenerateFromPassword([]byte("dummy password"), bcrypt.DefaultCost)
+
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func sessionKey(token string) []byte {
+	hash := sha256.Sum256([]byte(token))
+	return hash[:]
+}
+
+// CreateSession starts session for user
+func (s *Store) CreateSession(user *User) (*Session, error) {
+	token, err := randomToken()
+	if err != nil {
+		return nil, errors.New("[ CreateSession ] could not generate token: " + err.Error())
+	}
+	csrfToken, err := randomToken()
+	if err != nil {
+		return nil, errors.New("[ CreateSession ] could not generate token: " + err.Error())
+	}
+
+	session := &Session{
+		Token:     token,
+		Username:  user.Username,
+		CSRFToken: csrfToken,
+		ExpiresAt: time.Now().Add(s.config.SessionTTL).UTC(),
+	}
+	data, err := json.Marshal(session)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(sessionsBucket).Put(sessionKey(token), data)
+	}); err != nil {
+		return nil, errors.New("[ CreateSession ] could not save session: " + err.Error())
+	}
+	return session, nil
+}
+
+// Session finds active session and its user by token
+func (s *Store) Session(token string) (*Session, *User, error) {
+	session := &Session{}
+	var user *User
+	err := s.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(sessionsBucket).Get(sessionKey(token))
+		if data == nil {
+			return ErrSessionNotFound
+		}
+		if err := json.Unmarshal(data, session); err != nil {
+			return err
+		}
+		data = tx.Bucket(usersBucket).Get([]byte(strings.ToLower(session.Username)))
+		if data == nil {
+			return ErrSessionNotFound
+		}
+		user = &User{}
+		return json.Unmarshal(data, user)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	if time.Now().After(session.ExpiresAt) {
+		s.DeleteSession(token)
+		return nil, nil, ErrSessionNotFound
+	}
+	session.Token = token
+	return session, user, nil
+}
+
+// DeleteSession ends session
+func (s *Store) DeleteSession(token string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(sessionsBucket).Delete(sessionKey(token))
+	})
+}
+
+// DeleteExpiredSessions removes sessions which are expired
+func (s *Store) DeleteExpiredSessions() error {
+	now := time.Now()
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(sessionsBucket)
+		// Deleting under cursor skips next key, so keys are collected first
+		var expired [][]byte
+		if err := bucket.ForEach(func(k, v []byte) error {
+			var session Session
+			if err := json.Unmarshal(v, &session); err != nil || now.After(session.ExpiresAt) {
+				expired = append(expired, append([]byte(nil), k...))
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+		for _, k := range expired {
+			if err := bucket.Delete(k); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,169 @@
+package users
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	bolt "go.etcd.io/bbolt"
+)
+
+func newTestStore(t *testing.T) (*Store, func()) {
+	dir, err := ioutil.TempDir("", "users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewStore(&Config{DBPath: filepath.Join(dir, "users.db"), DefaultScopes: []string{"places:read"}})
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+	return s, func() {
+		s.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func countSessions(t *testing.T, s *Store) int {
+	var n int
+	assert.NoError(t, s.db.View(func(tx *bolt.Tx) error {
+		n = tx.Bucket(sessionsBucket).Stats().KeyN
+		return nil
+	}))
+	return n
+}
+
+func TestNewStore(t *testing.T) {
+	_, err := NewStore(nil)
+	assert.Error(t, err)
+	_, err = NewStore(&Config{})
+	assert.Error(t, err)
+
+	s, cleanup := newTestStore(t)
+	defer cleanup()
+	assert.Equal(t, 7*24*time.Hour, s.Config().SessionTTL)
+	assert.Equal(t, "chillit_session", s.Config().CookieName)
+}
+
+func TestStore_Register(t *testing.T) {
+	s, cleanup := newTestStore(t)
+	defer cleanup()
+
+	for _, username := range []string{"an", "anna smith", "анна", "a234567890123456789012345678901234"} {
+		_, err := s.Register(username, "long password")
+		assert.Equal(t, ErrInvalidUsername, err, username)
+	}
+	_, err := s.Register("anna", "short")
+	assert.Equal(t, ErrWeakPassword, err)
+
+	user, err := s.Register("Anna.K", "long password")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"places:read"}, user.Scopes)
+	// Password is kept only as bcrypt hash
+	assert.NotContains(t, string(user.PasswordHash), "long password")
+
+	// Usernames are unique ignoring case
+	_, err = s.Register("anna.k", "other password")
+	assert.Equal(t, ErrUserExists, err)
+
+	found, err := s.User("ANNA.K")
+	assert.NoError(t, err)
+	if assert.NotNil(t, found) {
+		assert.Equal(t, "Anna.K", found.Username)
+	}
+	found, err = s.User("boris")
+	assert.NoError(t, err)
+	assert.Nil(t, found)
+}
+
+func TestStore_Authenticate(t *testing.T) {
+	s, cleanup := newTestStore(t)
+	defer cleanup()
+
+	_, err := s.Register("anna", "long password")
+	assert.NoError(t, err)
+
+	user, err := s.Authenticate("Anna", "long password")
+	assert.NoError(t, err)
+	if assert.NotNil(t, user) {
+		assert.Equal(t, "anna", user.Username)
+	}
+	_, err = s.Authenticate("anna", "wrong password")
+	assert.Equal(t, ErrInvalidCredentials, err)
+	_, err = s.Authenticate("boris", "long password")
+	assert.Equal(t, ErrInvalidCredentials, err)
+}
+
+func TestStore_Session(t *testing.T) {
+	s, cleanup := newTestStore(t)
+	defer cleanup()
+
+	user, err := s.Register("anna", "long password")
+	assert.NoError(t, err)
+	session, err := s.CreateSession(user)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, session.Token)
+	// CSRF token is random per session and differs from session token
+	assert.NotEmpty(t, session.CSRFToken)
+	assert.NotEqual(t, session.Token, session.CSRFToken)
+	other, err := s.CreateSession(user)
+	assert.NoError(t, err)
+	assert.NotEqual(t, session.CSRFToken, other.CSRFToken)
+
+	found, foundUser, err := s.Session(session.Token)
+	assert.NoError(t, err)
+	assert.Equal(t, session.CSRFToken, found.CSRFToken)
+	assert.Equal(t, session.Token, found.Token)
+	assert.Equal(t, "anna", foundUser.Username)
+
+	// Store keeps only hash of token
+	assert.NoError(t, s.db.View(func(tx *bolt.Tx) error {
+		assert.Nil(t, tx.Bucket(sessionsBucket).Get([]byte(session.Token)))
+		return nil
+	}))
+
+	_, _, err = s.Session("unknown")
+	assert.Equal(t, ErrSessionNotFound, err)
+	assert.NoError(t, s.DeleteSession(session.Token))
+	_, _, err = s.Session(session.Token)
+	assert.Equal(t, ErrSessionNotFound, err)
+	_, _, err = s.Session(other.Token)
+	assert.NoError(t, err)
+}
+
+func TestStore_DeleteExpiredSessions(t *testing.T) {
+	s, cleanup := newTestStore(t)
+	defer cleanup()
+
+	user, err := s.Register("anna", "long password")
+	assert.NoError(t, err)
+
+	// Consecutive expired sessions are all removed, live ones are kept
+	s.Config().SessionTTL = time.Millisecond
+	for i := 0; i < 500; i++ {
+		_, err := s.CreateSession(user)
+		assert.NoError(t, err)
+	}
+	s.Config().SessionTTL = time.Hour
+	live, err := s.CreateSession(user)
+	assert.NoError(t, err)
+	time.Sleep(5 * time.Millisecond)
+	assert.Equal(t, 501, countSessions(t, s))
+
+	assert.NoError(t, s.DeleteExpiredSessions())
+	assert.Equal(t, 1, countSessions(t, s))
+	_, _, err = s.Session(live.Token)
+	assert.NoError(t, err)
+
+	// Expired session is not returned and is deleted on lookup
+	s.Config().SessionTTL = time.Millisecond
+	expired, err := s.CreateSession(user)
+	assert.NoError(t, err)
+	time.Sleep(5 * time.Millisecond)
+	_, _, err = s.Session(expired.Token)
+	assert.Equal(t, ErrSessionNotFound, err)
+	assert.Equal(t, 1, countSessions(t, s))
+}